@@ -1,39 +1,141 @@
 package adapters
 
 import (
+	"context"
 	"net/http"
 	"time"
 
+	"github.com/andreascandle/FlexiResponseGo/audit"
 	"github.com/andreascandle/FlexiResponseGo/core"
-	"github.com/andreascandle/FlexiResponseGo/logger"
-	"github.com/andreascandle/FlexiResponseGo/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"go.uber.org/zap"
 )
 
+// responseBuilder builds the envelope for a request once the adapter has been
+// scoped to its tenant, route and locale.
+type responseBuilder func(scoped *Adapter, traceID string) core.StandardResponse
+
+// Adapter binds a core.Responder to the supported web frameworks.
+type Adapter struct {
+	responder *core.Responder
+}
+
+// New creates an Adapter writing responses through the given Responder.
+func New(responder *core.Responder) *Adapter {
+	return &Adapter{responder: responder}
+}
+
+// Default returns an Adapter bound to the default core Responder.
+func Default() *Adapter {
+	return &Adapter{responder: core.Default()}
+}
+
+// Responder returns the Responder backing the adapter.
+func (a *Adapter) Responder() *core.Responder {
+	return a.responder
+}
+
+// forRequest returns an Adapter whose responder is scoped to the request's
+// route and resolved tenant, or the receiver when neither changes anything.
+func (a *Adapter) forRequest(headers http.Header, host, path string) *Adapter {
+	scoped := a.responder.ForRequest(headers, host).ForRoute(path)
+	if scoped == a.responder {
+		return a
+	}
+	return &Adapter{responder: scoped}
+}
+
+// FieldsParameter is the query parameter selecting the fields of success
+// response data, e.g. "?fields=id,name,owner.email", when enabled with
+// core.WithFieldSelection or core.WithSelectableFields.
+const FieldsParameter = "fields"
+
+// build runs a response builder, applies the request's conditional headers and
+// the caller's response options and, when field selection is enabled, projects
+// success data onto the requested fields. An invalid field selection replaces
+// the response with a 422 validation error.
+func (a *Adapter) build(traceID string, statusCode int, method string, headers http.Header, fields string, build responseBuilder, opts []core.ResponseOption) (core.StandardResponse, int) {
+	resp := build(a, traceID)
+	core.WithConditions(method, headers)(&resp)
+	for _, opt := range opts {
+		opt(&resp)
+	}
+	if !a.responder.FieldSelectionEnabled(resp) {
+		return resp, statusCode
+	}
+	projected, err := a.responder.SelectFields(resp, fields)
+	if err != nil {
+		return a.responder.NewFieldSelectionErrorResponse(traceID, err), http.StatusUnprocessableEntity
+	}
+	return projected, statusCode
+}
+
+// prependOption returns opts preceded by opt, so the caller's options can
+// override it.
+func prependOption(opt core.ResponseOption, opts []core.ResponseOption) []core.ResponseOption {
+	return append([]core.ResponseOption{opt}, opts...)
+}
+
 // GetOrGenerateTraceID retrieves a trace ID from headers or generates a new one.
 func GetOrGenerateTraceID(headers http.Header) string {
+	return Default().GetOrGenerateTraceID(headers)
+}
+
+// GetOrGenerateTraceID retrieves a trace ID from headers or generates a new one.
+func (a *Adapter) GetOrGenerateTraceID(headers http.Header) string {
 	traceID := headers.Get("X-Trace-ID")
 	if traceID == "" {
-		traceID = utils.GenerateTraceID(16)
+		traceID = a.responder.NewTraceID()
 		headers.Set("X-Trace-ID", traceID)
 	}
 	return traceID
 }
 
+// sensitiveHeaders lists request headers whose values are never logged.
+var sensitiveHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-Api-Key"}
+
+// RedactHeaders returns a copy of headers with sensitive values masked.
+func RedactHeaders(headers http.Header) http.Header {
+	redacted := headers.Clone()
+	for _, name := range sensitiveHeaders {
+		if _, ok := redacted[name]; ok {
+			redacted[name] = []string{"[REDACTED]"}
+		}
+	}
+	return redacted
+}
+
 // LogRequest logs incoming request details.
 func LogRequest(method, path, traceID string, headers http.Header) {
-	log := logger.GetLogger()
+	Default().LogRequest(method, path, traceID, headers)
+}
+
+// LogRequest logs incoming request details.
+func (a *Adapter) LogRequest(method, path, traceID string, headers http.Header) {
+	log := a.responder.Logger()
+	if !log.Enabled(zap.InfoLevel) {
+		return
+	}
 	log.Info("Incoming request",
 		zap.String("trace_id", traceID),
 		zap.String("method", method),
 		zap.String("path", path),
-		zap.Any("headers", headers),
+		zap.Any("headers", RedactHeaders(headers)),
 	)
 }
 
 // LogResponse logs outgoing response details.
 func LogResponse(method, path, traceID string, statusCode int, duration time.Duration) {
-	log := logger.GetLogger()
+	Default().LogResponse(method, path, traceID, statusCode, duration)
+}
+
+// LogResponse logs outgoing response details.
+func (a *Adapter) LogResponse(method, path, traceID string, statusCode int, duration time.Duration) {
+	log := a.responder.Logger()
+	if !log.Enabled(zap.InfoLevel) {
+		return
+	}
 	log.Info("Outgoing response",
 		zap.String("trace_id", traceID),
 		zap.String("method", method),
@@ -43,22 +145,125 @@ func LogResponse(method, path, traceID string, statusCode int, duration time.Dur
 	)
 }
 
+// startSpan opens a tracing span covering the response write.
+func (a *Adapter) startSpan(ctx context.Context, method, path, traceID string) (context.Context, trace.Span) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return a.responder.Tracer().Start(ctx, "response "+method+" "+path, trace.WithAttributes(
+		attribute.String("http.method", method),
+		attribute.String("http.route", path),
+		attribute.String("trace_id", traceID),
+	))
+}
+
+// finishResponse logs the response, reports it to metrics and ends its span.
+func (a *Adapter) finishResponse(span trace.Span, method, path, host, protocol, traceID string, statusCode int, start time.Time) {
+	duration := time.Since(start)
+	a.LogResponse(method, path, traceID, statusCode, duration)
+	if metrics := a.responder.Metrics(); metrics != nil {
+		metrics.ObserveResponse(method, path, statusCode, host, protocol, duration)
+	}
+	span.SetAttributes(attribute.Int("http.status_code", statusCode))
+	span.End()
+}
+
 // GenerateSuccessResponse creates a standardized success response.
 func GenerateSuccessResponse(traceID, message string, data interface{}) core.StandardResponse {
-	return core.NewSuccessResponse(traceID, message, data)
+	return Default().GenerateSuccessResponse(traceID, message, data)
+}
+
+// GenerateSuccessResponse creates a standardized success response.
+func (a *Adapter) GenerateSuccessResponse(traceID, message string, data interface{}) core.StandardResponse {
+	return a.responder.NewSuccessResponse(traceID, message, data)
+}
+
+// GenerateSuccessResponseWithKey creates a success response from a catalog message.
+func GenerateSuccessResponseWithKey(traceID string, msg core.Message, data interface{}) core.StandardResponse {
+	return Default().GenerateSuccessResponseWithKey(traceID, msg, data)
+}
+
+// GenerateSuccessResponseWithKey creates a success response from a catalog message.
+func (a *Adapter) GenerateSuccessResponseWithKey(traceID string, msg core.Message, data interface{}) core.StandardResponse {
+	return a.responder.NewSuccessResponseWithKey(traceID, msg, data)
 }
 
 // GenerateErrorResponse creates a standardized error response.
 func GenerateErrorResponse(traceID, message, errorDetail string) core.StandardResponse {
-	return core.NewErrorResponse(traceID, message, errorDetail)
+	return Default().GenerateErrorResponse(traceID, message, errorDetail)
+}
+
+// GenerateErrorResponse creates a standardized error response.
+func (a *Adapter) GenerateErrorResponse(traceID, message, errorDetail string) core.StandardResponse {
+	return a.responder.NewErrorResponse(traceID, message, errorDetail)
+}
+
+// GenerateErrorResponseWithKey creates an error response from a catalog message.
+func GenerateErrorResponseWithKey(traceID string, msg core.Message, errorDetail string) core.StandardResponse {
+	return Default().GenerateErrorResponseWithKey(traceID, msg, errorDetail)
+}
+
+// GenerateErrorResponseWithKey creates an error response from a catalog message.
+func (a *Adapter) GenerateErrorResponseWithKey(traceID string, msg core.Message, errorDetail string) core.StandardResponse {
+	return a.responder.NewErrorResponseWithKey(traceID, msg, errorDetail)
 }
 
 // GenerateValidationErrorResponse creates a validation error response.
 func GenerateValidationErrorResponse(traceID, message string, fieldErrors map[string]interface{}) core.StandardResponse {
-	return core.NewValidationErrorResponse(traceID, message, fieldErrors)
+	return Default().GenerateValidationErrorResponse(traceID, message, fieldErrors)
 }
 
-// WriteJSONResponse writes the response to the client.
-func WriteJSONResponse(w http.ResponseWriter, statusCode int, response core.StandardResponse) {
-	core.WriteJSON(w, statusCode, response)
+// GenerateValidationErrorResponse creates a validation error response.
+func (a *Adapter) GenerateValidationErrorResponse(traceID, message string, fieldErrors map[string]interface{}) core.StandardResponse {
+	return a.responder.NewValidationErrorResponse(traceID, message, fieldErrors)
+}
+
+// WriteJSONResponse writes the response to the client. It returns an
+// *core.EncodingError when the response could not be encoded and a
+// ServerError envelope was written instead.
+func WriteJSONResponse(w http.ResponseWriter, statusCode int, response core.StandardResponse) error {
+	return Default().WriteJSONResponse(w, statusCode, response)
+}
+
+// WriteJSONResponse writes the response to the client. It returns an
+// *core.EncodingError when the response could not be encoded and a
+// ServerError envelope was written instead.
+func (a *Adapter) WriteJSONResponse(w http.ResponseWriter, statusCode int, response core.StandardResponse) error {
+	return a.responder.WriteJSON(w, statusCode, response)
+}
+
+// AuditErrorResponse records an error response in the audit trail when the
+// configured auditor's policy matches its category or route.
+func AuditErrorResponse(method, path, traceID, clientIP string, headers http.Header, statusCode int) {
+	Default().AuditErrorResponse(method, path, traceID, clientIP, headers, statusCode)
+}
+
+// AuditErrorResponse records an error response in the audit trail when the
+// configured auditor's policy matches its category or route.
+func (a *Adapter) AuditErrorResponse(method, path, traceID, clientIP string, headers http.Header, statusCode int) {
+	auditor := audit.Default()
+	if auditor == nil {
+		return
+	}
+	category := core.CategoryForStatus(statusCode)
+	if !auditor.Matches(category, path) {
+		return
+	}
+
+	event := audit.Event{
+		Actor:      headers.Get(auditor.Policy().ActorHeader),
+		Resource:   path,
+		Action:     method,
+		Outcome:    audit.OutcomeFor(category),
+		Category:   category,
+		StatusCode: statusCode,
+		TraceID:    traceID,
+		ClientIP:   clientIP,
+	}
+	if err := auditor.Record(event); err != nil {
+		a.responder.Logger().Error("Failed to record audit event",
+			zap.String("trace_id", traceID),
+			zap.Error(err),
+		)
+	}
 }
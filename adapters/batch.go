@@ -0,0 +1,89 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+
+	"github.com/andreascandle/FlexiResponseGo/batch"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+)
+
+// HTTPBatch returns a net/http handler running the items of the request body
+// through processor and reporting every item's result.
+func HTTPBatch(processor *batch.Processor) http.Handler {
+	return Default().HTTPBatch(processor)
+}
+
+// HTTPBatch returns a net/http handler running the items of the request body
+// through processor and reporting every item's result.
+func (a *Adapter) HTTPBatch(processor *batch.Processor) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statusCode, build := runBatch(r.Context(), processor, r.Body)
+		_ = a.httpRespond(w, r, statusCode, nil, build)
+	})
+}
+
+// GinBatch returns a Gin handler running the items of the request body
+// through processor and reporting every item's result.
+func GinBatch(processor *batch.Processor) gin.HandlerFunc {
+	return Default().GinBatch(processor)
+}
+
+// GinBatch returns a Gin handler running the items of the request body
+// through processor and reporting every item's result.
+func (a *Adapter) GinBatch(processor *batch.Processor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statusCode, build := runBatch(c.Request.Context(), processor, c.Request.Body)
+		_ = a.ginRespond(c, statusCode, nil, build)
+	}
+}
+
+// EchoBatch returns an Echo handler running the items of the request body
+// through processor and reporting every item's result.
+func EchoBatch(processor *batch.Processor) echo.HandlerFunc {
+	return Default().EchoBatch(processor)
+}
+
+// EchoBatch returns an Echo handler running the items of the request body
+// through processor and reporting every item's result.
+func (a *Adapter) EchoBatch(processor *batch.Processor) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		statusCode, build := runBatch(c.Request().Context(), processor, c.Request().Body)
+		return a.echoRespond(c, statusCode, nil, build)
+	}
+}
+
+// FiberBatch returns a Fiber handler running the items of the request body
+// through processor and reporting every item's result.
+func FiberBatch(processor *batch.Processor) fiber.Handler {
+	return Default().FiberBatch(processor)
+}
+
+// FiberBatch returns a Fiber handler running the items of the request body
+// through processor and reporting every item's result.
+func (a *Adapter) FiberBatch(processor *batch.Processor) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		statusCode, build := runBatch(c.UserContext(), processor, bytes.NewReader(c.Body()))
+		return a.fiberRespond(c, statusCode, nil, build)
+	}
+}
+
+// runBatch decodes and runs a batch and returns the status and builder of the
+// response reporting it.
+func runBatch(ctx context.Context, processor *batch.Processor, body io.Reader) (int, responseBuilder) {
+	items, statusCode, apiErr := processor.Decode(body)
+	if apiErr != nil {
+		return statusCode, func(scoped *Adapter, traceID string) core.StandardResponse {
+			return scoped.responder.NewAPIErrorResponse(statusCode, traceID, *apiErr)
+		}
+	}
+	report := processor.Run(ctx, items)
+	return report.StatusCode(), func(scoped *Adapter, traceID string) core.StandardResponse {
+		return batch.NewResponse(scoped.responder, traceID, report)
+	}
+}
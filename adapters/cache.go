@@ -0,0 +1,111 @@
+package adapters
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/andreascandle/FlexiResponseGo/cache"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+)
+
+// GinCache returns Gin middleware serving responses from store.
+func GinCache(store *cache.Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		entry, result := store.Lookup(c.Request.Method, c.Request.Host, c.Request.URL, c.Request.Header)
+		if entry != nil {
+			status, header, body := store.Respond(entry, result, c.Request.Method, c.Request.Header)
+			for key, values := range header {
+				c.Writer.Header()[key] = values
+			}
+			c.Status(status)
+			_, _ = c.Writer.Write(body)
+			c.Abort()
+			return
+		}
+		if result == cache.Bypass {
+			c.Next()
+			return
+		}
+
+		c.Header("X-Cache", "MISS")
+		rec := &ginRecorder{ResponseWriter: c.Writer, limit: store.MaxBodySize()}
+		c.Writer = rec
+		c.Next()
+		statusCode := rec.Status()
+		if rec.overflow {
+			statusCode = 0 // Too large to cache; still releases a pending refresh
+		}
+		store.Save(c.Request.Method, c.Request.Host, c.Request.URL, c.Request.Header, statusCode, rec.Header(), rec.body)
+	}
+}
+
+// ginRecorder copies the body written through a Gin response writer, up to
+// limit bytes.
+type ginRecorder struct {
+	gin.ResponseWriter
+	body     []byte
+	limit    int
+	overflow bool
+}
+
+func (rec *ginRecorder) Write(p []byte) (int, error) {
+	rec.record(p)
+	return rec.ResponseWriter.Write(p)
+}
+
+func (rec *ginRecorder) WriteString(s string) (int, error) {
+	rec.record([]byte(s))
+	return rec.ResponseWriter.WriteString(s)
+}
+
+func (rec *ginRecorder) record(p []byte) {
+	if rec.overflow {
+		return
+	}
+	if len(rec.body)+len(p) > rec.limit {
+		rec.overflow = true
+		rec.body = nil
+		return
+	}
+	rec.body = append(rec.body, p...)
+}
+
+// EchoCache returns Echo middleware serving responses from store.
+func EchoCache(store *cache.Store) echo.MiddlewareFunc {
+	return echo.WrapMiddleware(store.Middleware)
+}
+
+// FiberCache returns Fiber middleware serving responses from store.
+func FiberCache(store *cache.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		u := &url.URL{Path: c.Path(), RawQuery: string(c.Request().URI().QueryString())}
+		headers := http.Header(c.GetReqHeaders())
+		entry, result := store.Lookup(c.Method(), c.Hostname(), u, headers)
+		if entry != nil {
+			status, header, body := store.Respond(entry, result, c.Method(), headers)
+			for key, values := range header {
+				for _, value := range values {
+					c.Response().Header.Add(key, value)
+				}
+			}
+			return c.Status(status).Send(body)
+		}
+		if result == cache.Bypass {
+			return c.Next()
+		}
+
+		c.Set("X-Cache", "MISS")
+		if err := c.Next(); err != nil {
+			store.Save(c.Method(), c.Hostname(), u, headers, 0, nil, nil)
+			return err
+		}
+		header := make(http.Header)
+		c.Response().Header.VisitAll(func(key, value []byte) {
+			header.Add(string(key), string(value))
+		})
+		store.Save(c.Method(), c.Hostname(), u, headers, c.Response().StatusCode(), header, c.Response().Body())
+		return nil
+	}
+}
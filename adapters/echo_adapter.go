@@ -4,31 +4,133 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/andreascandle/FlexiResponseGo/core"
 	"github.com/labstack/echo/v4"
 )
 
 // EchoSuccessResponse sends a success response in Echo with logging.
-func EchoSuccessResponse(c echo.Context, message string, data interface{}) error {
-	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.Request().Header)
-	LogRequest(c.Request().Method, c.Request().URL.Path, traceID, c.Request().Header)
+func EchoSuccessResponse(c echo.Context, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().EchoSuccessResponse(c, message, data, opts...)
+}
+
+// EchoSuccessResponse sends a success response in Echo with logging.
+func (a *Adapter) EchoSuccessResponse(c echo.Context, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
 
-	resp := GenerateSuccessResponse(traceID, message, data)
-	err := c.JSON(http.StatusOK, resp)
+// EchoSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func EchoSuccessResponseWithKey(c echo.Context, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return Default().EchoSuccessResponseWithKey(c, msg, data, opts...)
+}
 
-	LogResponse(c.Request().Method, c.Request().URL.Path, traceID, http.StatusOK, time.Since(start))
-	return err
+// EchoSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func (a *Adapter) EchoSuccessResponseWithKey(c echo.Context, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponseWithKey(traceID, msg, data)
+	})
+}
+
+// EchoSuccessResponseWithStatus sends a success response in Echo with a
+// 2xx status other than 200.
+func EchoSuccessResponseWithStatus(c echo.Context, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().EchoSuccessResponseWithStatus(c, statusCode, message, data, opts...)
+}
+
+// EchoSuccessResponseWithStatus sends a success response in Echo with a
+// 2xx status other than 200.
+func (a *Adapter) EchoSuccessResponseWithStatus(c echo.Context, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// EchoCreatedResponse sends a 201 Created response in Echo whose Location
+// header is the URL of the created resource.
+func EchoCreatedResponse(c echo.Context, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().EchoCreatedResponse(c, location, message, data, opts...)
+}
+
+// EchoCreatedResponse sends a 201 Created response in Echo whose Location
+// header is the URL of the created resource.
+func (a *Adapter) EchoCreatedResponse(c echo.Context, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.EchoSuccessResponseWithStatus(c, http.StatusCreated, message, data, prependOption(core.WithLocation(location), opts)...)
+}
+
+// EchoAcceptedResponse sends a 202 Accepted response in Echo pointing at
+// the status monitor of the accepted request.
+func EchoAcceptedResponse(c echo.Context, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().EchoAcceptedResponse(c, statusURL, message, data, opts...)
+}
+
+// EchoAcceptedResponse sends a 202 Accepted response in Echo pointing at
+// the status monitor of the accepted request.
+func (a *Adapter) EchoAcceptedResponse(c echo.Context, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.EchoSuccessResponseWithStatus(c, http.StatusAccepted, message, data, prependOption(core.WithStatusMonitor(statusURL), opts)...)
+}
+
+// EchoNoContentResponse sends a 204 No Content response in Echo, without a
+// body or Content-Type.
+func EchoNoContentResponse(c echo.Context, opts ...core.ResponseOption) error {
+	return Default().EchoNoContentResponse(c, opts...)
+}
+
+// EchoNoContentResponse sends a 204 No Content response in Echo, without a
+// body or Content-Type.
+func (a *Adapter) EchoNoContentResponse(c echo.Context, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, http.StatusNoContent, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, "", nil)
+	})
+}
+
+// EchoErrorResponse sends an error response in Echo with logging.
+func EchoErrorResponse(c echo.Context, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().EchoErrorResponse(c, statusCode, message, errorDetail, opts...)
 }
 
 // EchoErrorResponse sends an error response in Echo with logging.
-func EchoErrorResponse(c echo.Context, statusCode int, message, errorDetail string) error {
+func (a *Adapter) EchoErrorResponse(c echo.Context, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponse(traceID, message, errorDetail)
+	})
+}
+
+// EchoErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func EchoErrorResponseWithKey(c echo.Context, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().EchoErrorResponseWithKey(c, statusCode, msg, errorDetail, opts...)
+}
+
+// EchoErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func (a *Adapter) EchoErrorResponseWithKey(c echo.Context, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.echoRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponseWithKey(traceID, msg, errorDetail)
+	})
+}
+
+// echoRespond logs, traces, writes, audits and measures an Echo response.
+func (a *Adapter) echoRespond(c echo.Context, statusCode int, opts []core.ResponseOption, build responseBuilder) error {
 	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.Request().Header)
-	LogRequest(c.Request().Method, c.Request().URL.Path, traceID, c.Request().Header)
+	req := c.Request()
+	traceID := a.GetOrGenerateTraceID(req.Header)
+	a.LogRequest(req.Method, req.URL.Path, traceID, req.Header)
+	_, span := a.startSpan(req.Context(), req.Method, req.URL.Path, traceID)
 
-	resp := GenerateErrorResponse(traceID, message, errorDetail)
-	err := c.JSON(statusCode, resp)
+	scoped := a.forRequest(req.Header, req.Host, req.URL.Path)
+	resp, statusCode := scoped.build(traceID, statusCode, req.Method, req.Header, c.QueryParam(FieldsParameter), build, opts)
+	prepared, err := scoped.responder.Prepare(statusCode, resp)
+	statusCode = prepared.StatusCode
+	if writeErr := prepared.Write(c.Response()); err == nil {
+		err = writeErr
+	}
 
-	LogResponse(c.Request().Method, c.Request().URL.Path, traceID, statusCode, time.Since(start))
+	if statusCode >= 400 {
+		a.AuditErrorResponse(req.Method, req.URL.Path, traceID, a.responder.ClientIP(req.RemoteAddr, req.Header), req.Header, statusCode)
+	}
+	a.finishResponse(span, req.Method, req.URL.Path, req.Host, req.Proto, traceID, statusCode, start)
 	return err
 }
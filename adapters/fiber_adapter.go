@@ -1,33 +1,139 @@
 package adapters
 
 import (
+	"strings"
 	"time"
 
+	"github.com/andreascandle/FlexiResponseGo/core"
 	"github.com/gofiber/fiber/v2"
 )
 
 // FiberSuccessResponse sends a success response in Fiber with logging.
-func FiberSuccessResponse(c *fiber.Ctx, message string, data interface{}) error {
-	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.GetReqHeaders())
-	LogRequest(c.Method(), c.Path(), traceID, c.GetReqHeaders())
+func FiberSuccessResponse(c *fiber.Ctx, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().FiberSuccessResponse(c, message, data, opts...)
+}
+
+// FiberSuccessResponse sends a success response in Fiber with logging.
+func (a *Adapter) FiberSuccessResponse(c *fiber.Ctx, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, fiber.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
 
-	resp := GenerateSuccessResponse(traceID, message, data)
-	err := c.Status(fiber.StatusOK).JSON(resp)
+// FiberSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func FiberSuccessResponseWithKey(c *fiber.Ctx, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return Default().FiberSuccessResponseWithKey(c, msg, data, opts...)
+}
 
-	LogResponse(c.Method(), c.Path(), traceID, fiber.StatusOK, time.Since(start))
-	return err
+// FiberSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func (a *Adapter) FiberSuccessResponseWithKey(c *fiber.Ctx, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, fiber.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponseWithKey(traceID, msg, data)
+	})
+}
+
+// FiberSuccessResponseWithStatus sends a success response in Fiber with a
+// 2xx status other than 200.
+func FiberSuccessResponseWithStatus(c *fiber.Ctx, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().FiberSuccessResponseWithStatus(c, statusCode, message, data, opts...)
+}
+
+// FiberSuccessResponseWithStatus sends a success response in Fiber with a
+// 2xx status other than 200.
+func (a *Adapter) FiberSuccessResponseWithStatus(c *fiber.Ctx, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// FiberCreatedResponse sends a 201 Created response in Fiber whose Location
+// header is the URL of the created resource.
+func FiberCreatedResponse(c *fiber.Ctx, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().FiberCreatedResponse(c, location, message, data, opts...)
+}
+
+// FiberCreatedResponse sends a 201 Created response in Fiber whose Location
+// header is the URL of the created resource.
+func (a *Adapter) FiberCreatedResponse(c *fiber.Ctx, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.FiberSuccessResponseWithStatus(c, fiber.StatusCreated, message, data, prependOption(core.WithLocation(location), opts)...)
+}
+
+// FiberAcceptedResponse sends a 202 Accepted response in Fiber pointing at
+// the status monitor of the accepted request.
+func FiberAcceptedResponse(c *fiber.Ctx, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().FiberAcceptedResponse(c, statusURL, message, data, opts...)
+}
+
+// FiberAcceptedResponse sends a 202 Accepted response in Fiber pointing at
+// the status monitor of the accepted request.
+func (a *Adapter) FiberAcceptedResponse(c *fiber.Ctx, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.FiberSuccessResponseWithStatus(c, fiber.StatusAccepted, message, data, prependOption(core.WithStatusMonitor(statusURL), opts)...)
+}
+
+// FiberNoContentResponse sends a 204 No Content response in Fiber, without a
+// body or Content-Type.
+func FiberNoContentResponse(c *fiber.Ctx, opts ...core.ResponseOption) error {
+	return Default().FiberNoContentResponse(c, opts...)
+}
+
+// FiberNoContentResponse sends a 204 No Content response in Fiber, without a
+// body or Content-Type.
+func (a *Adapter) FiberNoContentResponse(c *fiber.Ctx, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, fiber.StatusNoContent, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, "", nil)
+	})
+}
+
+// FiberErrorResponse sends an error response in Fiber with logging.
+func FiberErrorResponse(c *fiber.Ctx, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().FiberErrorResponse(c, statusCode, message, errorDetail, opts...)
 }
 
 // FiberErrorResponse sends an error response in Fiber with logging.
-func FiberErrorResponse(c *fiber.Ctx, statusCode int, message, errorDetail string) error {
+func (a *Adapter) FiberErrorResponse(c *fiber.Ctx, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponse(traceID, message, errorDetail)
+	})
+}
+
+// FiberErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func FiberErrorResponseWithKey(c *fiber.Ctx, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().FiberErrorResponseWithKey(c, statusCode, msg, errorDetail, opts...)
+}
+
+// FiberErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func (a *Adapter) FiberErrorResponseWithKey(c *fiber.Ctx, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.fiberRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponseWithKey(traceID, msg, errorDetail)
+	})
+}
+
+// fiberRespond logs, traces, writes, audits and measures a Fiber response.
+func (a *Adapter) fiberRespond(c *fiber.Ctx, statusCode int, opts []core.ResponseOption, build responseBuilder) error {
 	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.GetReqHeaders())
-	LogRequest(c.Method(), c.Path(), traceID, c.GetReqHeaders())
+	headers := c.GetReqHeaders()
+	traceID := a.GetOrGenerateTraceID(headers)
+	a.LogRequest(c.Method(), c.Path(), traceID, headers)
+	_, span := a.startSpan(c.UserContext(), c.Method(), c.Path(), traceID)
 
-	resp := GenerateErrorResponse(traceID, message, errorDetail)
-	err := c.Status(statusCode).JSON(resp)
+	scoped := a.forRequest(headers, c.Hostname(), c.Path())
+	resp, statusCode := scoped.build(traceID, statusCode, c.Method(), headers, c.Query(FieldsParameter), build, opts)
+	prepared, err := scoped.responder.Prepare(statusCode, resp)
+	statusCode = prepared.StatusCode
+	for key, values := range prepared.Header {
+		c.Set(key, strings.Join(values, ", "))
+	}
+	if sendErr := c.Status(statusCode).Send(prepared.Body); err == nil {
+		err = sendErr
+	}
 
-	LogResponse(c.Method(), c.Path(), traceID, statusCode, time.Since(start))
+	if statusCode >= 400 {
+		a.AuditErrorResponse(c.Method(), c.Path(), traceID, a.responder.ClientIP(c.Context().RemoteAddr().String(), headers), headers, statusCode)
+	}
+	a.finishResponse(span, c.Method(), c.Path(), c.Hostname(), c.Protocol(), traceID, statusCode, start)
 	return err
 }
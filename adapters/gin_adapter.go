@@ -4,29 +4,136 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/andreascandle/FlexiResponseGo/core"
 	"github.com/gin-gonic/gin"
 )
 
 // GinSuccessResponse sends a success response in Gin with logging.
-func GinSuccessResponse(c *gin.Context, message string, data interface{}) {
-	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.Request.Header)
-	LogRequest(c.Request.Method, c.Request.URL.Path, traceID, c.Request.Header)
+func GinSuccessResponse(c *gin.Context, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().GinSuccessResponse(c, message, data, opts...)
+}
+
+// GinSuccessResponse sends a success response in Gin with logging.
+func (a *Adapter) GinSuccessResponse(c *gin.Context, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// GinSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func GinSuccessResponseWithKey(c *gin.Context, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return Default().GinSuccessResponseWithKey(c, msg, data, opts...)
+}
+
+// GinSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func (a *Adapter) GinSuccessResponseWithKey(c *gin.Context, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponseWithKey(traceID, msg, data)
+	})
+}
+
+// GinSuccessResponseWithStatus sends a success response in Gin with a
+// 2xx status other than 200.
+func GinSuccessResponseWithStatus(c *gin.Context, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().GinSuccessResponseWithStatus(c, statusCode, message, data, opts...)
+}
+
+// GinSuccessResponseWithStatus sends a success response in Gin with a
+// 2xx status other than 200.
+func (a *Adapter) GinSuccessResponseWithStatus(c *gin.Context, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// GinCreatedResponse sends a 201 Created response in Gin whose Location
+// header is the URL of the created resource.
+func GinCreatedResponse(c *gin.Context, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().GinCreatedResponse(c, location, message, data, opts...)
+}
 
-	resp := GenerateSuccessResponse(traceID, message, data)
-	c.JSON(http.StatusOK, resp)
+// GinCreatedResponse sends a 201 Created response in Gin whose Location
+// header is the URL of the created resource.
+func (a *Adapter) GinCreatedResponse(c *gin.Context, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.GinSuccessResponseWithStatus(c, http.StatusCreated, message, data, prependOption(core.WithLocation(location), opts)...)
+}
 
-	LogResponse(c.Request.Method, c.Request.URL.Path, traceID, http.StatusOK, time.Since(start))
+// GinAcceptedResponse sends a 202 Accepted response in Gin pointing at
+// the status monitor of the accepted request.
+func GinAcceptedResponse(c *gin.Context, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().GinAcceptedResponse(c, statusURL, message, data, opts...)
+}
+
+// GinAcceptedResponse sends a 202 Accepted response in Gin pointing at
+// the status monitor of the accepted request.
+func (a *Adapter) GinAcceptedResponse(c *gin.Context, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.GinSuccessResponseWithStatus(c, http.StatusAccepted, message, data, prependOption(core.WithStatusMonitor(statusURL), opts)...)
+}
+
+// GinNoContentResponse sends a 204 No Content response in Gin, without a
+// body or Content-Type.
+func GinNoContentResponse(c *gin.Context, opts ...core.ResponseOption) error {
+	return Default().GinNoContentResponse(c, opts...)
+}
+
+// GinNoContentResponse sends a 204 No Content response in Gin, without a
+// body or Content-Type.
+func (a *Adapter) GinNoContentResponse(c *gin.Context, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, http.StatusNoContent, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, "", nil)
+	})
 }
 
 // GinErrorResponse sends an error response in Gin with logging.
-func GinErrorResponse(c *gin.Context, statusCode int, message, errorDetail string) {
+func GinErrorResponse(c *gin.Context, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().GinErrorResponse(c, statusCode, message, errorDetail, opts...)
+}
+
+// GinErrorResponse sends an error response in Gin with logging.
+func (a *Adapter) GinErrorResponse(c *gin.Context, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponse(traceID, message, errorDetail)
+	})
+}
+
+// GinErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func GinErrorResponseWithKey(c *gin.Context, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().GinErrorResponseWithKey(c, statusCode, msg, errorDetail, opts...)
+}
+
+// GinErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func (a *Adapter) GinErrorResponseWithKey(c *gin.Context, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.ginRespond(c, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponseWithKey(traceID, msg, errorDetail)
+	})
+}
+
+// ginRespond logs, traces, writes, audits and measures a Gin response.
+func (a *Adapter) ginRespond(c *gin.Context, statusCode int, opts []core.ResponseOption, build responseBuilder) error {
 	start := time.Now()
-	traceID := GetOrGenerateTraceID(c.Request.Header)
-	LogRequest(c.Request.Method, c.Request.URL.Path, traceID, c.Request.Header)
+	req := c.Request
+	traceID := a.GetOrGenerateTraceID(req.Header)
+	a.LogRequest(req.Method, req.URL.Path, traceID, req.Header)
+	_, span := a.startSpan(req.Context(), req.Method, req.URL.Path, traceID)
 
-	resp := GenerateErrorResponse(traceID, message, errorDetail)
-	c.JSON(statusCode, resp)
+	scoped := a.forRequest(req.Header, req.Host, req.URL.Path)
+	resp, statusCode := scoped.build(traceID, statusCode, req.Method, req.Header, c.Query(FieldsParameter), build, opts)
+	prepared, err := scoped.responder.Prepare(statusCode, resp)
+	statusCode = prepared.StatusCode
+	if writeErr := prepared.Write(c.Writer); err == nil {
+		err = writeErr
+	}
+	if err != nil {
+		_ = c.Error(err)
+	}
 
-	LogResponse(c.Request.Method, c.Request.URL.Path, traceID, statusCode, time.Since(start))
+	if statusCode >= 400 {
+		a.AuditErrorResponse(req.Method, req.URL.Path, traceID, a.responder.ClientIP(req.RemoteAddr, req.Header), req.Header, statusCode)
+	}
+	a.finishResponse(span, req.Method, req.URL.Path, req.Host, req.Proto, traceID, statusCode, start)
+	return err
 }
@@ -3,28 +3,132 @@ package adapters
 import (
 	"net/http"
 	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
 )
 
 // HTTPSuccessResponse sends a success response for net/http with logging.
-func HTTPSuccessResponse(w http.ResponseWriter, r *http.Request, message string, data interface{}) {
-	start := time.Now()
-	traceID := GetOrGenerateTraceID(r.Header)
-	LogRequest(r.Method, r.URL.Path, traceID, r.Header)
+func HTTPSuccessResponse(w http.ResponseWriter, r *http.Request, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().HTTPSuccessResponse(w, r, message, data, opts...)
+}
+
+// HTTPSuccessResponse sends a success response for net/http with logging.
+func (a *Adapter) HTTPSuccessResponse(w http.ResponseWriter, r *http.Request, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// HTTPSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func HTTPSuccessResponseWithKey(w http.ResponseWriter, r *http.Request, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return Default().HTTPSuccessResponseWithKey(w, r, msg, data, opts...)
+}
+
+// HTTPSuccessResponseWithKey sends a success response whose message is
+// rendered from a catalog key in the request's locale.
+func (a *Adapter) HTTPSuccessResponseWithKey(w http.ResponseWriter, r *http.Request, msg core.Message, data interface{}, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, http.StatusOK, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponseWithKey(traceID, msg, data)
+	})
+}
+
+// HTTPSuccessResponseWithStatus sends a success response for net/http with a
+// 2xx status other than 200.
+func HTTPSuccessResponseWithStatus(w http.ResponseWriter, r *http.Request, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().HTTPSuccessResponseWithStatus(w, r, statusCode, message, data, opts...)
+}
+
+// HTTPSuccessResponseWithStatus sends a success response for net/http with a
+// 2xx status other than 200.
+func (a *Adapter) HTTPSuccessResponseWithStatus(w http.ResponseWriter, r *http.Request, statusCode int, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, message, data)
+	})
+}
+
+// HTTPCreatedResponse sends a 201 Created response for net/http whose Location
+// header is the URL of the created resource.
+func HTTPCreatedResponse(w http.ResponseWriter, r *http.Request, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().HTTPCreatedResponse(w, r, location, message, data, opts...)
+}
 
-	resp := GenerateSuccessResponse(traceID, message, data)
-	WriteJSONResponse(w, http.StatusOK, resp)
+// HTTPCreatedResponse sends a 201 Created response for net/http whose Location
+// header is the URL of the created resource.
+func (a *Adapter) HTTPCreatedResponse(w http.ResponseWriter, r *http.Request, location, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.HTTPSuccessResponseWithStatus(w, r, http.StatusCreated, message, data, prependOption(core.WithLocation(location), opts)...)
+}
+
+// HTTPAcceptedResponse sends a 202 Accepted response for net/http pointing at
+// the status monitor of the accepted request.
+func HTTPAcceptedResponse(w http.ResponseWriter, r *http.Request, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return Default().HTTPAcceptedResponse(w, r, statusURL, message, data, opts...)
+}
+
+// HTTPAcceptedResponse sends a 202 Accepted response for net/http pointing at
+// the status monitor of the accepted request.
+func (a *Adapter) HTTPAcceptedResponse(w http.ResponseWriter, r *http.Request, statusURL, message string, data interface{}, opts ...core.ResponseOption) error {
+	return a.HTTPSuccessResponseWithStatus(w, r, http.StatusAccepted, message, data, prependOption(core.WithStatusMonitor(statusURL), opts)...)
+}
+
+// HTTPNoContentResponse sends a 204 No Content response for net/http, without a
+// body or Content-Type.
+func HTTPNoContentResponse(w http.ResponseWriter, r *http.Request, opts ...core.ResponseOption) error {
+	return Default().HTTPNoContentResponse(w, r, opts...)
+}
+
+// HTTPNoContentResponse sends a 204 No Content response for net/http, without a
+// body or Content-Type.
+func (a *Adapter) HTTPNoContentResponse(w http.ResponseWriter, r *http.Request, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, http.StatusNoContent, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateSuccessResponse(traceID, "", nil)
+	})
+}
 
-	LogResponse(r.Method, r.URL.Path, traceID, http.StatusOK, time.Since(start))
+// HTTPErrorResponse sends an error response for net/http with logging.
+func HTTPErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().HTTPErrorResponse(w, r, statusCode, message, errorDetail, opts...)
 }
 
 // HTTPErrorResponse sends an error response for net/http with logging.
-func HTTPErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message, errorDetail string) {
+func (a *Adapter) HTTPErrorResponse(w http.ResponseWriter, r *http.Request, statusCode int, message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponse(traceID, message, errorDetail)
+	})
+}
+
+// HTTPErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func HTTPErrorResponseWithKey(w http.ResponseWriter, r *http.Request, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return Default().HTTPErrorResponseWithKey(w, r, statusCode, msg, errorDetail, opts...)
+}
+
+// HTTPErrorResponseWithKey sends an error response whose message is rendered
+// from a catalog key in the request's locale.
+func (a *Adapter) HTTPErrorResponseWithKey(w http.ResponseWriter, r *http.Request, statusCode int, msg core.Message, errorDetail string, opts ...core.ResponseOption) error {
+	return a.httpRespond(w, r, statusCode, opts, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return scoped.GenerateErrorResponseWithKey(traceID, msg, errorDetail)
+	})
+}
+
+// httpRespond logs, traces, writes, audits and measures a net/http response.
+func (a *Adapter) httpRespond(w http.ResponseWriter, r *http.Request, statusCode int, opts []core.ResponseOption, build responseBuilder) error {
 	start := time.Now()
-	traceID := GetOrGenerateTraceID(r.Header)
-	LogRequest(r.Method, r.URL.Path, traceID, r.Header)
+	traceID := a.GetOrGenerateTraceID(r.Header)
+	a.LogRequest(r.Method, r.URL.Path, traceID, r.Header)
+	_, span := a.startSpan(r.Context(), r.Method, r.URL.Path, traceID)
 
-	resp := GenerateErrorResponse(traceID, message, errorDetail)
-	WriteJSONResponse(w, statusCode, resp)
+	scoped := a.forRequest(r.Header, r.Host, r.URL.Path)
+	resp, statusCode := scoped.build(traceID, statusCode, r.Method, r.Header, r.URL.Query().Get(FieldsParameter), build, opts)
+	prepared, err := scoped.responder.Prepare(statusCode, resp)
+	statusCode = prepared.StatusCode
+	if writeErr := prepared.Write(w); err == nil {
+		err = writeErr
+	}
 
-	LogResponse(r.Method, r.URL.Path, traceID, statusCode, time.Since(start))
+	if statusCode >= 400 {
+		a.AuditErrorResponse(r.Method, r.URL.Path, traceID, a.responder.ClientIP(r.RemoteAddr, r.Header), r.Header, statusCode)
+	}
+	a.finishResponse(span, r.Method, r.URL.Path, r.Host, r.Proto, traceID, statusCode, start)
+	return err
 }
@@ -0,0 +1,136 @@
+package adapters
+
+import (
+	"net/http"
+
+	"github.com/andreascandle/FlexiResponseGo/idempotency"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// GinIdempotency returns Gin middleware enforcing idempotency keys with guard.
+func GinIdempotency(guard *idempotency.Guard) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pending, prepared := guard.BeginRequest(c.Request)
+		if prepared != nil {
+			_ = prepared.Write(c.Writer)
+			c.Abort()
+			return
+		}
+		if pending == nil {
+			c.Next()
+			return
+		}
+
+		defer pending.Abandon()
+		rec := &ginRecorder{ResponseWriter: c.Writer, limit: guard.MaxBodySize()}
+		c.Writer = rec
+		c.Next()
+		if rec.overflow {
+			return // Too large to store; Abandon releases the key
+		}
+		finishIdempotent(guard, pending, rec.Status(), rec.Header(), rec.body)
+	}
+}
+
+// EchoIdempotency returns Echo middleware enforcing idempotency keys with
+// guard. Errors returned by the handler are sent through Echo's
+// HTTPErrorHandler before the response is stored, so client errors are
+// replayed and server errors release the key.
+func EchoIdempotency(guard *idempotency.Guard) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			pending, prepared := guard.BeginRequest(c.Request())
+			if prepared != nil {
+				return prepared.Write(c.Response())
+			}
+			if pending == nil {
+				return next(c)
+			}
+
+			defer pending.Abandon()
+			res := c.Response()
+			rec := &echoRecorder{ResponseWriter: res.Writer, limit: guard.MaxBodySize()}
+			res.Writer = rec
+			if err := next(c); err != nil {
+				c.Error(err)
+			}
+			if rec.overflow {
+				return nil // Too large to store; Abandon releases the key
+			}
+			finishIdempotent(guard, pending, res.Status, res.Header(), rec.body)
+			return nil
+		}
+	}
+}
+
+// echoRecorder copies the body written through an Echo response, up to limit
+// bytes.
+type echoRecorder struct {
+	http.ResponseWriter
+	body     []byte
+	limit    int
+	overflow bool
+}
+
+func (rec *echoRecorder) Write(p []byte) (int, error) {
+	if !rec.overflow {
+		if len(rec.body)+len(p) > rec.limit {
+			rec.overflow = true
+			rec.body = nil
+		} else {
+			rec.body = append(rec.body, p...)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rec *echoRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// FiberIdempotency returns Fiber middleware enforcing idempotency keys with
+// guard. Errors returned by the handler are sent through the app's
+// ErrorHandler before the response is stored, so client errors are replayed
+// and server errors release the key.
+func FiberIdempotency(guard *idempotency.Guard) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		headers := http.Header(c.GetReqHeaders())
+		pending, prepared := guard.Begin(c.Method(), c.Path(), headers, c.Body())
+		if prepared != nil {
+			for key, values := range prepared.Header {
+				for _, value := range values {
+					c.Response().Header.Add(key, value)
+				}
+			}
+			return c.Status(prepared.StatusCode).Send(prepared.Body)
+		}
+		if pending == nil {
+			return c.Next()
+		}
+
+		defer pending.Abandon()
+		if err := c.Next(); err != nil {
+			if err := c.App().Config().ErrorHandler(c, err); err != nil {
+				return err
+			}
+		}
+		header := make(http.Header)
+		c.Response().Header.VisitAll(func(key, value []byte) {
+			header.Add(string(key), string(value))
+		})
+		finishIdempotent(guard, pending, c.Response().StatusCode(), header, c.Response().Body())
+		return nil
+	}
+}
+
+// finishIdempotent stores the response of a request that reserved its
+// idempotency key.
+func finishIdempotent(guard *idempotency.Guard, pending *idempotency.Pending, statusCode int, header http.Header, body []byte) {
+	if err := pending.Finish(statusCode, header, body); err != nil {
+		guard.Responder().Logger().Error("Failed to store idempotent response", zap.Error(err))
+	}
+}
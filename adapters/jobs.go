@@ -0,0 +1,94 @@
+package adapters
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/jobs"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"go.uber.org/zap"
+)
+
+// HTTPJobStatus returns a net/http handler reporting the job whose status URL
+// is requested.
+func HTTPJobStatus(tracker *jobs.Tracker) http.Handler {
+	return Default().HTTPJobStatus(tracker)
+}
+
+// HTTPJobStatus returns a net/http handler reporting the job whose status URL
+// is requested.
+func (a *Adapter) HTTPJobStatus(tracker *jobs.Tracker) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		statusCode, build := a.jobStatus(tracker, r.URL.Path)
+		_ = a.httpRespond(w, r, statusCode, nil, build)
+	})
+}
+
+// GinJobStatus returns a Gin handler reporting the job whose status URL is
+// requested.
+func GinJobStatus(tracker *jobs.Tracker) gin.HandlerFunc {
+	return Default().GinJobStatus(tracker)
+}
+
+// GinJobStatus returns a Gin handler reporting the job whose status URL is
+// requested.
+func (a *Adapter) GinJobStatus(tracker *jobs.Tracker) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statusCode, build := a.jobStatus(tracker, c.Request.URL.Path)
+		_ = a.ginRespond(c, statusCode, nil, build)
+	}
+}
+
+// EchoJobStatus returns an Echo handler reporting the job whose status URL is
+// requested.
+func EchoJobStatus(tracker *jobs.Tracker) echo.HandlerFunc {
+	return Default().EchoJobStatus(tracker)
+}
+
+// EchoJobStatus returns an Echo handler reporting the job whose status URL is
+// requested.
+func (a *Adapter) EchoJobStatus(tracker *jobs.Tracker) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		statusCode, build := a.jobStatus(tracker, c.Request().URL.Path)
+		return a.echoRespond(c, statusCode, nil, build)
+	}
+}
+
+// FiberJobStatus returns a Fiber handler reporting the job whose status URL is
+// requested.
+func FiberJobStatus(tracker *jobs.Tracker) fiber.Handler {
+	return Default().FiberJobStatus(tracker)
+}
+
+// FiberJobStatus returns a Fiber handler reporting the job whose status URL is
+// requested.
+func (a *Adapter) FiberJobStatus(tracker *jobs.Tracker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		statusCode, build := a.jobStatus(tracker, c.Path())
+		return a.fiberRespond(c, statusCode, nil, build)
+	}
+}
+
+// jobStatus looks up the job whose status URL is path and returns the status
+// and builder of the response reporting it.
+func (a *Adapter) jobStatus(tracker *jobs.Tracker, path string) (int, responseBuilder) {
+	job, err := tracker.Get(tracker.JobID(path))
+	if err != nil {
+		if !errors.Is(err, jobs.ErrNotFound) {
+			a.responder.Logger().Error("Failed to read job status",
+				zap.String("path", path),
+				zap.Error(err),
+			)
+		}
+		statusCode, apiErr := jobs.LookupError(err)
+		return statusCode, func(scoped *Adapter, traceID string) core.StandardResponse {
+			return scoped.responder.NewAPIErrorResponse(statusCode, traceID, apiErr)
+		}
+	}
+	return http.StatusOK, func(scoped *Adapter, traceID string) core.StandardResponse {
+		return tracker.NewStatusResponse(scoped.responder, traceID, job)
+	}
+}
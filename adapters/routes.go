@@ -0,0 +1,95 @@
+package adapters
+
+import (
+	"strings"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+)
+
+// GinRoutes returns the URI templates of a Gin engine's routes for use with
+// core.NewLinkBuilder. Gin has no route names, so each route is keyed by its
+// handler name (e.g. "main.getOrder") and by "METHOD /path".
+func GinRoutes(engine *gin.Engine) core.RouteTemplates {
+	routes := make(core.RouteTemplates)
+	for _, route := range engine.Routes() {
+		template := pathTemplate(route.Path)
+		routes[route.Method+" "+route.Path] = template
+		if route.Handler != "" {
+			routes[route.Handler] = template
+		}
+	}
+	return routes
+}
+
+// EchoRoutes returns the URI templates of an Echo instance's routes, keyed by
+// route name and by "METHOD /path".
+func EchoRoutes(e *echo.Echo) core.RouteTemplates {
+	routes := make(core.RouteTemplates)
+	for _, route := range e.Routes() {
+		template := pathTemplate(route.Path)
+		routes[route.Method+" "+route.Path] = template
+		if route.Name != "" {
+			routes[route.Name] = template
+		}
+	}
+	return routes
+}
+
+// FiberRoutes returns the URI templates of a Fiber app's routes, keyed by the
+// names given with Route.Name and by "METHOD /path". Middleware is skipped.
+func FiberRoutes(app *fiber.App) core.RouteTemplates {
+	routes := make(core.RouteTemplates)
+	for _, route := range app.GetRoutes(true) {
+		template := pathTemplate(route.Path)
+		routes[route.Method+" "+route.Path] = template
+		if route.Name != "" {
+			routes[route.Name] = template
+		}
+	}
+	return routes
+}
+
+// HTTPRoutes converts net/http ServeMux patterns, keyed by route name, into
+// URI templates. Methods and hosts are dropped from patterns such as
+// "GET example.com/orders/{id}", and "{path...}" wildcards become "{+path}".
+func HTTPRoutes(patterns map[string]string) core.RouteTemplates {
+	routes := make(core.RouteTemplates, len(patterns))
+	for name, pattern := range patterns {
+		if i := strings.IndexByte(pattern, ' '); i >= 0 {
+			pattern = strings.TrimSpace(pattern[i+1:])
+		}
+		if i := strings.IndexByte(pattern, '/'); i > 0 {
+			pattern = pattern[i:]
+		}
+		pattern = strings.ReplaceAll(pattern, "{$}", "")
+		segments := strings.Split(pattern, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "...}") {
+				segments[i] = "{+" + strings.TrimSuffix(segment[1:], "...}") + "}"
+			}
+		}
+		routes[name] = strings.Join(segments, "/")
+	}
+	return routes
+}
+
+// pathTemplate converts a router path using ":param" and "*" wildcards into
+// an RFC 6570 template.
+func pathTemplate(path string) string {
+	segments := strings.Split(path, "/")
+	for i, segment := range segments {
+		switch {
+		case strings.HasPrefix(segment, ":"):
+			name := strings.TrimSuffix(segment[1:], "?")
+			segments[i] = "{" + name + "}"
+		case segment == "*" || segment == "+":
+			segments[i] = "{+wildcard}"
+		case strings.HasPrefix(segment, "*"):
+			segments[i] = "{+" + segment[1:] + "}"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+)
+
+// Outcome values recorded for audited responses.
+const (
+	OutcomeDenied  = "denied"
+	OutcomeFailure = "failure"
+	OutcomeSuccess = "success"
+)
+
+// Event describes a security-relevant response written by the library.
+type Event struct {
+	Actor      string                 `json:"actor"`
+	Resource   string                 `json:"resource"`
+	Action     string                 `json:"action"`
+	Outcome    string                 `json:"outcome"`
+	Category   core.ErrorCategory     `json:"category,omitempty"`
+	StatusCode int                    `json:"status_code"`
+	TraceID    string                 `json:"trace_id"`
+	ClientIP   string                 `json:"client_ip"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+// Entry is a single hash-chained record in the audit trail.
+type Entry struct {
+	Sequence  uint64    `json:"seq"`
+	Timestamp time.Time `json:"timestamp"`
+	Event
+	PrevHash string `json:"prev_hash"`
+	Hash     string `json:"hash"`
+}
+
+// computeHash returns the chain hash of an entry, ignoring its own Hash field.
+func computeHash(e Entry) (string, error) {
+	e.Hash = ""
+	payload, err := json.Marshal(e)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(append([]byte(e.PrevHash), payload...))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Sink stores audit entries in append-only fashion.
+type Sink interface {
+	Append(entry Entry) error
+	Last() (Entry, bool, error)
+	Close() error
+}
+
+// Policy selects which responses are audited.
+type Policy struct {
+	Categories  []core.ErrorCategory // Error categories that are always audited
+	Routes      []string             // Paths audited for any error response; "/admin/*" matches a prefix
+	ActorHeader string               // Request header identifying the caller
+}
+
+// DefaultPolicy audits authentication and authorization failures.
+func DefaultPolicy() Policy {
+	return Policy{
+		Categories:  []core.ErrorCategory{core.AuthenticationError, core.AuthorizationError},
+		ActorHeader: "X-User-ID",
+	}
+}
+
+// Auditor records events matching its policy into a sink.
+type Auditor struct {
+	sink     Sink
+	policy   Policy
+	mu       sync.Mutex
+	seq      uint64
+	lastHash string
+	now      func() time.Time
+}
+
+// New creates an Auditor that resumes the hash chain stored in sink.
+func New(sink Sink, policy Policy) (*Auditor, error) {
+	a := &Auditor{sink: sink, policy: policy, now: time.Now}
+	last, ok, err := sink.Last()
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		a.seq = last.Sequence
+		a.lastHash = last.Hash
+	}
+	return a, nil
+}
+
+// Policy returns the policy the auditor was configured with.
+func (a *Auditor) Policy() Policy {
+	return a.policy
+}
+
+// Matches reports whether a response with the given category and path is
+// audited. Policy routes ending in "*" match every path under their prefix.
+func (a *Auditor) Matches(category core.ErrorCategory, path string) bool {
+	if slices.Contains(a.policy.Categories, category) {
+		return true
+	}
+	return slices.ContainsFunc(a.policy.Routes, func(route string) bool {
+		return config.RouteMatches(route, path)
+	})
+}
+
+// Record appends an event to the trail, chaining it to the previous entry.
+func (a *Auditor) Record(event Event) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry := Entry{
+		Sequence:  a.seq + 1,
+		Timestamp: a.now().UTC(),
+		Event:     event,
+		PrevHash:  a.lastHash,
+	}
+	hash, err := computeHash(entry)
+	if err != nil {
+		return err
+	}
+	entry.Hash = hash
+
+	if err := a.sink.Append(entry); err != nil {
+		return err
+	}
+	a.seq = entry.Sequence
+	a.lastHash = entry.Hash
+	return nil
+}
+
+// Close closes the underlying sink.
+func (a *Auditor) Close() error {
+	return a.sink.Close()
+}
+
+var (
+	defaultAuditor *Auditor
+	defaultMu      sync.RWMutex
+)
+
+// SetDefault installs the auditor used by the adapters. Passing nil disables auditing.
+func SetDefault(a *Auditor) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultAuditor = a
+}
+
+// Default returns the auditor used by the adapters, or nil if none is set.
+func Default() *Auditor {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return defaultAuditor
+}
+
+// OutcomeFor maps an error category to an audit outcome.
+func OutcomeFor(category core.ErrorCategory) string {
+	if category == core.AuthenticationError || category == core.AuthorizationError {
+		return OutcomeDenied
+	}
+	return OutcomeFailure
+}
@@ -0,0 +1,40 @@
+package audit
+
+import (
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/logger"
+	"go.uber.org/zap"
+)
+
+// ConfigChangeRecorder returns a callback for config.WithChangeRecorder that
+// writes every attempted admin configuration change to the audit trail.
+func ConfigChangeRecorder(a *Auditor) func(config.Change) {
+	return func(change config.Change) {
+		outcome := OutcomeSuccess
+		details := map[string]interface{}{
+			"old_version": change.OldVersion,
+			"fields":      change.Fields,
+		}
+		if change.Succeeded {
+			details["new_version"] = change.NewVersion
+		} else {
+			outcome = OutcomeFailure
+			details["reason"] = change.FailureText
+		}
+
+		event := Event{
+			Actor:    change.Actor,
+			Resource: change.Path,
+			Action:   "config." + change.Method,
+			Outcome:  outcome,
+			ClientIP: change.ClientIP,
+			Details:  details,
+		}
+		if err := a.Record(event); err != nil {
+			logger.GetLogger().Error("Failed to record configuration change",
+				zap.String("path", change.Path),
+				zap.Error(err),
+			)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+)
+
+// ErrTampered is returned when an audit log fails chain verification.
+var ErrTampered = errors.New("audit log has been tampered with")
+
+// FileSink writes entries as JSON lines to an append-only file.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	path string
+}
+
+// NewFileSink opens (or creates) an append-only audit log at path.
+func NewFileSink(path string) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	return &FileSink{file: file, path: path}, nil
+}
+
+// Append writes a single entry and flushes it to disk.
+func (s *FileSink) Append(entry Entry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.file.Write(line); err != nil {
+		return err
+	}
+	return s.file.Sync()
+}
+
+// Last returns the most recent entry in the file, used to resume the chain.
+func (s *FileSink) Last() (Entry, bool, error) {
+	file, err := os.Open(s.path)
+	if err != nil {
+		return Entry{}, false, err
+	}
+	defer file.Close()
+
+	var last Entry
+	found := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &last); err != nil {
+			return Entry{}, false, err
+		}
+		found = true
+	}
+	return last, found, scanner.Err()
+}
+
+// Close closes the underlying file.
+func (s *FileSink) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.file.Close()
+}
+
+// VerifyFile checks the hash chain of the audit log at path.
+func VerifyFile(path string) (int, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+	return Verify(file)
+}
+
+// Verify checks the hash chain of an audit log and returns the number of valid
+// entries. A broken chain yields an error wrapping ErrTampered with the line number.
+func Verify(r io.Reader) (int, error) {
+	scanner := bufio.NewScanner(r)
+	prevHash := ""
+	var prevSeq uint64
+	count := 0
+	line := 0
+
+	for scanner.Scan() {
+		line++
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		var entry Entry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			return count, fmt.Errorf("%w: line %d is not a valid entry: %v", ErrTampered, line, err)
+		}
+		if entry.PrevHash != prevHash {
+			return count, fmt.Errorf("%w: line %d does not chain to the previous entry", ErrTampered, line)
+		}
+		if entry.Sequence != prevSeq+1 {
+			return count, fmt.Errorf("%w: line %d has sequence %d, expected %d", ErrTampered, line, entry.Sequence, prevSeq+1)
+		}
+		hash, err := computeHash(entry)
+		if err != nil {
+			return count, err
+		}
+		if hash != entry.Hash {
+			return count, fmt.Errorf("%w: line %d content does not match its hash", ErrTampered, line)
+		}
+
+		prevHash = entry.Hash
+		prevSeq = entry.Sequence
+		count++
+	}
+	return count, scanner.Err()
+}
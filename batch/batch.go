@@ -0,0 +1,292 @@
+// Package batch executes bulk requests item by item and reports the outcome of
+// every item in a single multi-status response.
+package batch
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"go.uber.org/zap"
+)
+
+// Item is one sub-request of a batch.
+type Item struct {
+	ID   string          `json:"id,omitempty"` // Chosen by the client, echoed in the item's result
+	Body json.RawMessage `json:"body"`
+}
+
+// Decode unmarshals the item's body into v.
+func (i Item) Decode(v interface{}) error {
+	return json.Unmarshal(i.Body, v)
+}
+
+// Result is the outcome of one item.
+type Result struct {
+	Index  int            `json:"index"`
+	ID     string         `json:"id,omitempty"`
+	Status int            `json:"status"`
+	Data   interface{}    `json:"data,omitempty"`
+	Error  *core.APIError `json:"error,omitempty"`
+}
+
+// Succeeded reports whether the item succeeded.
+func (r Result) Succeeded() bool {
+	return r.Status >= 200 && r.Status < 300
+}
+
+// Outcome is what a Handler reports for an item.
+type Outcome struct {
+	Status int
+	Data   interface{}
+	Error  *core.APIError
+}
+
+// Success reports an item that succeeded with statusCode and data.
+func Success(statusCode int, data interface{}) Outcome {
+	return Outcome{Status: statusCode, Data: data}
+}
+
+// Failure reports an item that failed with apiErr, using its code as the
+// item's status.
+func Failure(apiErr core.APIError) Outcome {
+	return Outcome{Status: apiErr.Code, Error: &apiErr}
+}
+
+// Handler processes one item. The context is cancelled when the batch is
+// abandoned, such as after a failure in all-or-nothing mode.
+type Handler func(ctx context.Context, item Item) Outcome
+
+// Rollback undoes an item that succeeded in an all-or-nothing batch that
+// failed.
+type Rollback func(ctx context.Context, item Item, result Result) error
+
+var (
+	// ErrItemFailed is reported for items whose handler panicked or returned
+	// neither a status nor an error.
+	ErrItemFailed = core.NewAPIError(core.ServerError, http.StatusInternalServerError,
+		"Internal Server Error", "The item could not be processed.")
+	// ErrNotApplied is reported for items that were not started, because the
+	// batch was abandoned, and for items of a failed all-or-nothing batch that
+	// were rolled back.
+	ErrNotApplied = core.NewAPIError(core.ClientError, http.StatusFailedDependency,
+		"Failed Dependency", "The item was not applied because the batch did not complete.")
+	// ErrRollbackFailed is reported for items of a failed all-or-nothing batch
+	// that could not be rolled back.
+	ErrRollbackFailed = core.NewAPIError(core.ServerError, http.StatusInternalServerError,
+		"Internal Server Error", "The item was applied and could not be rolled back.")
+)
+
+// Processor runs the items of batches through a handler.
+type Processor struct {
+	handler     Handler
+	concurrency int
+	maxItems    int
+	maxBodySize int64
+	atomic      bool
+	rollback    Rollback
+	responder   *core.Responder
+}
+
+// Option configures a Processor.
+type Option func(*Processor)
+
+// WithConcurrency processes up to n items at once (default 1, in order).
+func WithConcurrency(n int) Option {
+	return func(p *Processor) {
+		if n > 0 {
+			p.concurrency = n
+		}
+	}
+}
+
+// WithMaxItems rejects batches of more than n items (default 1000).
+func WithMaxItems(n int) Option {
+	return func(p *Processor) {
+		if n > 0 {
+			p.maxItems = n
+		}
+	}
+}
+
+// WithMaxBodySize rejects batch bodies larger than size bytes (default
+// 10 MiB).
+func WithMaxBodySize(size int64) Option {
+	return func(p *Processor) {
+		if size > 0 {
+			p.maxBodySize = size
+		}
+	}
+}
+
+// WithResponder logs recovered handler panics through responder's logger
+// instead of the default core Responder's.
+func WithResponder(responder *core.Responder) Option {
+	return func(p *Processor) {
+		p.responder = responder
+	}
+}
+
+// WithAllOrNothing applies a batch only if every item succeeds. After the
+// first failure no further item is started; rollback, when not nil, is called
+// for each item that had succeeded, in reverse order. Items that were not
+// applied are reported with ErrNotApplied. A nil rollback suits handlers that
+// only stage their changes, committed by the caller when Report.Applied.
+func WithAllOrNothing(rollback Rollback) Option {
+	return func(p *Processor) {
+		p.atomic = true
+		p.rollback = rollback
+	}
+}
+
+// New creates a Processor running items through handler.
+func New(handler Handler, opts ...Option) *Processor {
+	p := &Processor{handler: handler, concurrency: 1, maxItems: 1000, maxBodySize: 10 << 20}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Responder returns the Responder whose logger reports handler panics.
+func (p *Processor) Responder() *core.Responder {
+	if p.responder == nil {
+		return core.Default()
+	}
+	return p.responder
+}
+
+// Report is the outcome of a batch.
+type Report struct {
+	Results []Result
+	Counts  Counts
+	Atomic  bool // Processed in all-or-nothing mode
+}
+
+// Counts aggregates the results of a batch.
+type Counts struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	Skipped   int `json:"skipped"` // Reported with ErrNotApplied
+}
+
+// Applied reports whether every item of the batch was applied.
+func (r Report) Applied() bool {
+	return r.Counts.Succeeded == r.Counts.Total
+}
+
+// Run processes items and returns their results in the order of the items.
+func (p *Processor) Run(ctx context.Context, items []Item) Report {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(items))
+	started := make([]bool, len(items))
+	var (
+		mu     sync.Mutex
+		failed bool
+		wg     sync.WaitGroup
+	)
+	slots := make(chan struct{}, p.concurrency)
+	for i, item := range items {
+		slots <- struct{}{}
+		mu.Lock()
+		stop := p.atomic && failed
+		mu.Unlock()
+		if stop || ctx.Err() != nil {
+			<-slots
+			break
+		}
+		started[i] = true
+		wg.Add(1)
+		go func(i int, item Item) {
+			defer func() {
+				<-slots
+				wg.Done()
+			}()
+			results[i] = p.process(ctx, i, item)
+			if !results[i].Succeeded() {
+				mu.Lock()
+				failed = true
+				mu.Unlock()
+				if p.atomic {
+					cancel()
+				}
+			}
+		}(i, item)
+	}
+	wg.Wait()
+
+	for i, item := range items {
+		if !started[i] {
+			results[i] = result(i, item, Failure(ErrNotApplied))
+		}
+	}
+	if p.atomic && failed {
+		p.rollbackAll(context.WithoutCancel(ctx), items, results)
+	}
+	return Report{Results: results, Counts: count(results), Atomic: p.atomic}
+}
+
+// process runs the handler for one item, turning panics and empty outcomes
+// into ErrItemFailed. Panics are logged with their stack.
+func (p *Processor) process(ctx context.Context, i int, item Item) (res Result) {
+	defer func() {
+		if v := recover(); v != nil {
+			p.Responder().Logger().Error("Batch item handler panicked",
+				zap.Int("index", i),
+				zap.String("id", item.ID),
+				zap.Any("panic", v),
+				zap.Stack("stack"),
+			)
+			res = result(i, item, Failure(ErrItemFailed))
+		}
+	}()
+	outcome := p.handler(ctx, item)
+	if outcome.Status == 0 && outcome.Error == nil {
+		outcome = Failure(ErrItemFailed)
+	}
+	return result(i, item, outcome)
+}
+
+// rollbackAll undoes the items that succeeded, last first.
+func (p *Processor) rollbackAll(ctx context.Context, items []Item, results []Result) {
+	for i := len(results) - 1; i >= 0; i-- {
+		if !results[i].Succeeded() {
+			continue
+		}
+		if p.rollback != nil {
+			if err := p.rollback(ctx, items[i], results[i]); err != nil {
+				results[i] = result(i, items[i], Failure(ErrRollbackFailed))
+				continue
+			}
+		}
+		results[i] = result(i, items[i], Failure(ErrNotApplied))
+	}
+}
+
+func result(i int, item Item, outcome Outcome) Result {
+	status := outcome.Status
+	if status == 0 {
+		status = outcome.Error.Code
+	}
+	return Result{Index: i, ID: item.ID, Status: status, Data: outcome.Data, Error: outcome.Error}
+}
+
+func count(results []Result) Counts {
+	counts := Counts{Total: len(results)}
+	for _, res := range results {
+		switch {
+		case res.Succeeded():
+			counts.Succeeded++
+		case res.Error != nil && res.Error.Code == ErrNotApplied.Code && res.Error.Details == ErrNotApplied.Details:
+			counts.Skipped++
+		default:
+			counts.Failed++
+		}
+	}
+	return counts
+}
@@ -0,0 +1,103 @@
+package batch
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+)
+
+var (
+	// ErrInvalidBatch is the error sent for a body that is not a JSON array of
+	// items.
+	ErrInvalidBatch = core.NewAPIError(core.ClientError, http.StatusBadRequest,
+		"Bad Request", "The request body must be a non-empty JSON array of items.")
+	// ErrBatchTooLarge is the error sent for batches with too many items.
+	ErrBatchTooLarge = core.NewAPIError(core.ClientError, http.StatusRequestEntityTooLarge,
+		"Payload Too Large", "The batch has too many items.")
+	// ErrBatchFailed is the error sent when an all-or-nothing batch failed.
+	ErrBatchFailed = core.NewAPIError(core.ClientError, http.StatusUnprocessableEntity,
+		"Batch Failed", "No item was applied because at least one item failed.")
+)
+
+// MetadataKey is the response metadata key holding the batch's Counts.
+const MetadataKey = "batch"
+
+// Decode reads the items of a batch: a JSON array of objects with an optional
+// "id" and a "body". Items are decoded one at a time from at most the
+// processor's maximum body size, so oversized batches are rejected without
+// being read in full. It returns the status and error to send when the body is
+// invalid or too large.
+func (p *Processor) Decode(body io.Reader) ([]Item, int, *core.APIError) {
+	limited := &io.LimitedReader{R: body, N: p.maxBodySize + 1}
+	items, err := p.decodeItems(json.NewDecoder(limited))
+	switch {
+	case limited.N <= 0:
+		apiErr := ErrBatchTooLarge.WithDetails("The batch is larger than " + strconv.FormatInt(p.maxBodySize, 10) + " bytes.")
+		return nil, http.StatusRequestEntityTooLarge, &apiErr
+	case errors.Is(err, errTooManyItems):
+		apiErr := ErrBatchTooLarge.WithDetails("The batch has more than " + strconv.Itoa(p.maxItems) + " items.")
+		return nil, http.StatusRequestEntityTooLarge, &apiErr
+	case err != nil || len(items) == 0:
+		return nil, http.StatusBadRequest, &ErrInvalidBatch
+	}
+	return items, 0, nil
+}
+
+var (
+	// errNotArray is returned for bodies that are not a JSON array.
+	errNotArray = errors.New("batch is not a JSON array")
+	// errTooManyItems stops decoding a batch with more items than allowed.
+	errTooManyItems = errors.New("too many batch items")
+)
+
+// decodeItems decodes a JSON array of items, stopping at the first item past
+// the processor's limit.
+func (p *Processor) decodeItems(dec *json.Decoder) ([]Item, error) {
+	if token, err := dec.Token(); err != nil || token != json.Delim('[') {
+		return nil, errNotArray
+	}
+	var items []Item
+	for dec.More() {
+		if len(items) == p.maxItems {
+			return nil, errTooManyItems
+		}
+		var item Item
+		if err := dec.Decode(&item); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// StatusCode returns the status of the response reporting the batch: 207
+// Multi-Status, or 422 Unprocessable Entity for a failed all-or-nothing batch.
+func (r Report) StatusCode() int {
+	if r.Atomic && !r.Applied() {
+		return http.StatusUnprocessableEntity
+	}
+	return http.StatusMultiStatus
+}
+
+// NewResponse creates the response, sent with report.StatusCode(), reporting
+// a batch. Its data lists the result of every item and its metadata holds the
+// aggregate Counts. Failed all-or-nothing batches are error responses for
+// ErrBatchFailed.
+func NewResponse(r *core.Responder, traceID string, report Report) core.StandardResponse {
+	var resp core.StandardResponse
+	if statusCode := report.StatusCode(); statusCode != http.StatusMultiStatus {
+		resp = r.NewAPIErrorResponse(statusCode, traceID, ErrBatchFailed)
+	} else {
+		resp = r.NewSuccessResponse(traceID, "Batch processed", nil)
+	}
+	resp.Data = report.Results
+	resp.Metadata[MetadataKey] = report.Counts
+	return resp
+}
@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// Middleware serves cacheable GET and HEAD requests from the store and saves
+// the responses of requests that missed it.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		entry, result := s.Lookup(r.Method, r.Host, r.URL, r.Header)
+		if entry != nil {
+			status, header, body := s.Respond(entry, result, r.Method, r.Header)
+			for key, values := range header {
+				w.Header()[key] = values
+			}
+			w.WriteHeader(status)
+			_, _ = w.Write(body)
+			return
+		}
+		if result == Bypass {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("X-Cache", "MISS")
+		rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK, limit: s.maxBodySize}
+		next.ServeHTTP(rec, r)
+		statusCode := rec.statusCode
+		if rec.overflow {
+			statusCode = 0 // Too large to cache; still releases a pending refresh
+		}
+		s.Save(r.Method, r.Host, r.URL, r.Header, statusCode, w.Header(), rec.body.Bytes())
+	})
+}
+
+// recorder copies a response while it is written, up to limit bytes.
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int
+	overflow    bool
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.statusCode = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > rec.limit {
+			rec.overflow = true
+			rec.body.Reset()
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush lets streamed responses through the recorder.
+func (rec *recorder) Flush() {
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
@@ -0,0 +1,402 @@
+// Package cache provides an in-process LRU cache for encoded responses,
+// driven by the Cache-Control, Vary and Cache-Tag headers that core sets from
+// response cache policies.
+package cache
+
+import (
+	"container/list"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+)
+
+// Result is the outcome of a cache lookup.
+type Result string
+
+const (
+	Hit    Result = "hit"    // A fresh entry was found
+	Stale  Result = "stale"  // A stale entry is served while another request refreshes it
+	Miss   Result = "miss"   // The request must be handled and its response saved
+	Bypass Result = "bypass" // The request is not cacheable
+)
+
+// Entry is a cached response.
+type Entry struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+
+	key          string
+	base         string
+	tags         []string
+	stored       time.Time
+	expires      time.Time
+	staleUntil   time.Time
+	revalidating bool
+}
+
+// Store is a bounded LRU response cache for GET and HEAD requests. Entries are
+// keyed by host, path, query and the request headers named in the response's
+// Vary header.
+type Store struct {
+	capacity    int
+	defaultTTL  time.Duration
+	maxBodySize int
+	metrics     *observability.Metrics
+	now         func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+	vary    map[string]baseVary            // Base key -> Vary header names
+	tags    map[string]map[string]struct{} // Tag -> entry keys
+}
+
+// baseVary records the Vary header names last seen for a base key and how
+// many entries share it, so it is dropped with the last of them.
+type baseVary struct {
+	names   []string
+	entries int
+}
+
+// Option configures a Store.
+type Option func(*Store)
+
+// WithCapacity bounds the number of cached entries (default 1024).
+func WithCapacity(capacity int) Option {
+	return func(s *Store) {
+		if capacity > 0 {
+			s.capacity = capacity
+		}
+	}
+}
+
+// WithDefaultTTL caches responses without max-age for ttl. By default only
+// responses with an explicit max-age or s-maxage are cached.
+func WithDefaultTTL(ttl time.Duration) Option {
+	return func(s *Store) {
+		s.defaultTTL = ttl
+	}
+}
+
+// WithMaxBodySize skips responses larger than size bytes (default 1 MiB).
+func WithMaxBodySize(size int) Option {
+	return func(s *Store) {
+		if size > 0 {
+			s.maxBodySize = size
+		}
+	}
+}
+
+// WithMetrics records lookup results in metrics.
+func WithMetrics(metrics *observability.Metrics) Option {
+	return func(s *Store) {
+		s.metrics = metrics
+	}
+}
+
+// WithClock sets the time source used for expiry.
+func WithClock(now func() time.Time) Option {
+	return func(s *Store) {
+		s.now = now
+	}
+}
+
+// NewStore creates an empty response cache.
+func NewStore(opts ...Option) *Store {
+	s := &Store{
+		capacity:    1024,
+		maxBodySize: 1 << 20,
+		now:         time.Now,
+		entries:     make(map[string]*list.Element),
+		order:       list.New(),
+		vary:        make(map[string]baseVary),
+		tags:        make(map[string]map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Lookup finds the cached response for a request. A nil entry with Miss means
+// the request should be handled and its response passed to Save. Once an entry
+// is stale but within its stale-while-revalidate window, the first request
+// gets Miss to refresh it and concurrent requests are served the stale entry.
+func (s *Store) Lookup(method, host string, u *url.URL, headers http.Header) (*Entry, Result) {
+	if !cacheableRequest(method, headers) {
+		return nil, Bypass
+	}
+	entry, result := s.lookup(baseKey(host, u), headers)
+	if s.metrics != nil {
+		s.metrics.ObserveCacheLookup(string(result))
+	}
+	return entry, result
+}
+
+func (s *Store) lookup(base string, headers http.Header) (*Entry, Result) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	el, ok := s.entries[entryKey(base, s.vary[base].names, headers)]
+	if !ok {
+		return nil, Miss
+	}
+	entry := el.Value.(*Entry)
+	now := s.now()
+	switch {
+	case now.Before(entry.expires):
+		s.order.MoveToFront(el)
+		return entry, Hit
+	case now.Before(entry.staleUntil):
+		if !entry.revalidating {
+			entry.revalidating = true
+			return nil, Miss
+		}
+		return entry, Stale
+	default:
+		s.remove(el)
+		return nil, Miss
+	}
+}
+
+// Save stores a response to a request that missed the cache, when its status
+// and Cache-Control allow it. It reports whether the response was stored.
+func (s *Store) Save(method, host string, u *url.URL, reqHeaders http.Header, statusCode int, header http.Header, body []byte) bool {
+	if !cacheableRequest(method, reqHeaders) {
+		return false
+	}
+	base := baseKey(host, u)
+	ttl, swr, ok := s.freshness(statusCode, header, len(body))
+	if !ok || method != http.MethodGet {
+		s.release(base, reqHeaders)
+		return false
+	}
+
+	vary := varyNames(header)
+	now := s.now()
+	entry := &Entry{
+		StatusCode: statusCode,
+		Header:     header.Clone(),
+		Body:       append([]byte(nil), body...),
+		tags:       splitList(header.Get("Cache-Tag")),
+		stored:     now,
+		expires:    now.Add(ttl),
+		staleUntil: now.Add(ttl + swr),
+	}
+	for _, name := range perRequestHeaders {
+		entry.Header.Del(name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entry.key = entryKey(base, vary, reqHeaders)
+	entry.base = base
+	if el, ok := s.entries[entry.key]; ok {
+		s.remove(el)
+	}
+	s.vary[base] = baseVary{names: vary, entries: s.vary[base].entries + 1}
+	s.entries[entry.key] = s.order.PushFront(entry)
+	for _, tag := range entry.tags {
+		if s.tags[tag] == nil {
+			s.tags[tag] = make(map[string]struct{})
+		}
+		s.tags[tag][entry.key] = struct{}{}
+	}
+	for s.order.Len() > s.capacity {
+		s.remove(s.order.Back())
+	}
+	return true
+}
+
+// Respond returns the status, headers and body to send for a cached entry,
+// answering conditional requests with 304 Not Modified.
+func (s *Store) Respond(entry *Entry, result Result, method string, headers http.Header) (int, http.Header, []byte) {
+	header := entry.Header.Clone()
+	header.Set("Age", strconv.Itoa(int(s.now().Sub(entry.stored)/time.Second)))
+	header.Set("X-Cache", strings.ToUpper(string(result)))
+
+	var lastModified time.Time
+	if value := header.Get("Last-Modified"); value != "" {
+		lastModified, _ = http.ParseTime(value)
+	}
+	if core.EvaluatePreconditions(method, headers, header.Get("ETag"), lastModified) == http.StatusNotModified {
+		header.Del("Content-Type")
+		header.Del("Content-Length")
+		return http.StatusNotModified, header, nil
+	}
+	if method == http.MethodHead {
+		return entry.StatusCode, header, nil
+	}
+	return entry.StatusCode, header, entry.Body
+}
+
+// InvalidateTag removes the entries labelled with any of tags and returns how
+// many were removed.
+func (s *Store) InvalidateTag(tags ...string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	removed := 0
+	for _, tag := range tags {
+		for key := range s.tags[tag] {
+			if el, ok := s.entries[key]; ok {
+				s.remove(el)
+				removed++
+			}
+		}
+	}
+	return removed
+}
+
+// Purge removes every entry.
+func (s *Store) Purge() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+	s.vary = make(map[string]baseVary)
+	s.tags = make(map[string]map[string]struct{})
+}
+
+// MaxBodySize returns the size in bytes of the largest body the store caches.
+func (s *Store) MaxBodySize() int {
+	return s.maxBodySize
+}
+
+// Len returns the number of cached entries.
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+// remove drops an entry, its tag references and, with the last entry of its
+// base key, the base's Vary names. The caller holds s.mu.
+func (s *Store) remove(el *list.Element) {
+	entry := el.Value.(*Entry)
+	s.order.Remove(el)
+	delete(s.entries, entry.key)
+	if v := s.vary[entry.base]; v.entries > 1 {
+		v.entries--
+		s.vary[entry.base] = v
+	} else {
+		delete(s.vary, entry.base)
+	}
+	for _, tag := range entry.tags {
+		delete(s.tags[tag], entry.key)
+		if len(s.tags[tag]) == 0 {
+			delete(s.tags, tag)
+		}
+	}
+}
+
+// release lets another request refresh an entry whose refresh failed.
+func (s *Store) release(base string, headers http.Header) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[entryKey(base, s.vary[base].names, headers)]; ok {
+		el.Value.(*Entry).revalidating = false
+	}
+}
+
+// freshness returns how long a response stays fresh and may then be served
+// stale, or false when it must not be cached.
+func (s *Store) freshness(statusCode int, header http.Header, size int) (ttl, swr time.Duration, ok bool) {
+	if statusCode != http.StatusOK || size > s.maxBodySize || header.Get("Set-Cookie") != "" {
+		return 0, 0, false
+	}
+	for _, name := range varyNames(header) {
+		if name == "*" {
+			return 0, 0, false
+		}
+	}
+
+	ttl = s.defaultTTL
+	explicit := false
+	for _, directive := range splitList(header.Get("Cache-Control")) {
+		name, value, _ := strings.Cut(strings.ToLower(directive), "=")
+		seconds, _ := strconv.Atoi(strings.Trim(value, `"`))
+		switch name {
+		case "no-store", "no-cache", "private":
+			return 0, 0, false
+		case "s-maxage":
+			ttl, explicit = time.Duration(seconds)*time.Second, true
+		case "max-age":
+			if !explicit {
+				ttl = time.Duration(seconds) * time.Second
+			}
+		case "stale-while-revalidate":
+			swr = time.Duration(seconds) * time.Second
+		}
+	}
+	return ttl, swr, ttl > 0
+}
+
+// cacheableRequest reports whether a request may be answered from the cache.
+func cacheableRequest(method string, headers http.Header) bool {
+	if method != http.MethodGet && method != http.MethodHead {
+		return false
+	}
+	if headers.Get("Authorization") != "" {
+		return false
+	}
+	for _, directive := range splitList(headers.Get("Cache-Control")) {
+		if directive == "no-store" || directive == "no-cache" {
+			return false
+		}
+	}
+	return true
+}
+
+// perRequestHeaders lists response headers describing a single exchange,
+// which are never replayed to other clients.
+var perRequestHeaders = []string{"X-Cache", "Age", "X-Trace-ID"}
+
+// baseKey identifies a resource by host, path and normalized query, so
+// tenants served from different hosts never share entries. HEAD requests
+// share the entries of GET requests.
+func baseKey(host string, u *url.URL) string {
+	return strings.ToLower(host) + u.Path + "?" + u.Query().Encode()
+}
+
+// entryKey extends a base key with the request's values of the Vary headers.
+func entryKey(base string, vary []string, headers http.Header) string {
+	if len(vary) == 0 {
+		return base
+	}
+	var b strings.Builder
+	b.WriteString(base)
+	for _, name := range vary {
+		b.WriteString("\n")
+		b.WriteString(name)
+		b.WriteString(":")
+		b.WriteString(strings.Join(headers.Values(name), ","))
+	}
+	return b.String()
+}
+
+func varyNames(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range splitList(value) {
+			names = append(names, http.CanonicalHeaderKey(name))
+		}
+	}
+	return names
+}
+
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,272 @@
+// Package compression negotiates and applies gzip, brotli and zstd content
+// codings to responses, with pooled encoders, a size threshold and a
+// content-type allowlist.
+package compression
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Encoding is an HTTP content coding.
+type Encoding string
+
+const (
+	Identity Encoding = ""     // The response is sent uncompressed
+	Brotli   Encoding = "br"   // Brotli (RFC 7932)
+	Zstd     Encoding = "zstd" // Zstandard (RFC 8878)
+	Gzip     Encoding = "gzip" // Gzip (RFC 1952)
+)
+
+// DefaultEncodings are the supported codings in server preference order.
+var DefaultEncodings = []Encoding{Brotli, Zstd, Gzip}
+
+// DefaultContentTypes are the media types compressed by default. A trailing
+// "/*" matches every subtype.
+var DefaultContentTypes = []string{
+	"application/json",
+	"application/problem+json",
+	"application/vnd.api+json",
+	"application/hal+json",
+	"application/x-ndjson",
+	"text/*",
+}
+
+// DefaultMinSize is the smallest body compressed by default, in bytes.
+// Smaller bodies rarely shrink enough to be worth the CPU time.
+const DefaultMinSize = 1024
+
+// Config selects which responses are compressed and with which codings. The
+// zero value uses the defaults.
+type Config struct {
+	Encodings    []Encoding // Supported codings, most preferred first
+	MinSize      int        // Smallest body compressed, in bytes
+	ContentTypes []string   // Media types that may be compressed
+}
+
+func (c Config) encodings() []Encoding {
+	if len(c.Encodings) == 0 {
+		return DefaultEncodings
+	}
+	return c.Encodings
+}
+
+func (c Config) minSize() int {
+	if c.MinSize <= 0 {
+		return DefaultMinSize
+	}
+	return c.MinSize
+}
+
+// Negotiate picks the coding for a request's Accept-Encoding header: the
+// supported coding with the highest q-value, ties going to server
+// preference. It returns Identity when no supported coding is acceptable.
+func (c Config) Negotiate(acceptEncoding string) Encoding {
+	if strings.TrimSpace(acceptEncoding) == "" {
+		return Identity
+	}
+	weights := make(map[string]float64)
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		params := strings.Split(part, ";")
+		coding := strings.ToLower(strings.TrimSpace(params[0]))
+		if coding == "" {
+			continue
+		}
+		if coding == "x-gzip" {
+			coding = string(Gzip)
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		weights[coding] = q
+	}
+
+	best, bestQ := Identity, 0.0
+	for _, encoding := range c.encodings() {
+		q, ok := weights[string(encoding)]
+		if !ok {
+			q = weights["*"]
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// Compressible reports whether responses of contentType may be compressed.
+func (c Config) Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	allowed := c.ContentTypes
+	if len(allowed) == 0 {
+		allowed = DefaultContentTypes
+	}
+	for _, pattern := range allowed {
+		pattern = strings.ToLower(pattern)
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if strings.HasPrefix(mediaType, prefix+"/") {
+				return true
+			}
+		} else if mediaType == pattern {
+			return true
+		}
+	}
+	return false
+}
+
+// Eligible reports whether a body of size bytes and contentType is large
+// enough and of an allowed type to be compressed.
+func (c Config) Eligible(contentType string, size int) bool {
+	return size >= c.minSize() && c.Compressible(contentType)
+}
+
+// encoder is the common interface of the pooled gzip, brotli and zstd writers.
+type encoder interface {
+	io.WriteCloser
+	Flush() error
+	Reset(w io.Writer)
+}
+
+var pools = map[Encoding]*sync.Pool{
+	Gzip: {New: func() interface{} {
+		w, _ := gzip.NewWriterLevel(nil, gzip.DefaultCompression)
+		return w
+	}},
+	Brotli: {New: func() interface{} {
+		return brotli.NewWriterLevel(nil, 5)
+	}},
+	Zstd: {New: func() interface{} {
+		w, _ := zstd.NewWriter(nil, zstd.WithEncoderConcurrency(1))
+		return w
+	}},
+}
+
+// Writer compresses what is written to it onto an underlying writer using a
+// pooled encoder. Close must be called to finish the stream and release the
+// encoder.
+type Writer struct {
+	enc  encoder
+	pool *sync.Pool
+}
+
+// NewWriter returns a Writer compressing onto w, or false if encoding is not
+// supported.
+func NewWriter(w io.Writer, encoding Encoding) (*Writer, bool) {
+	pool, ok := pools[encoding]
+	if !ok {
+		return nil, false
+	}
+	enc := pool.Get().(encoder)
+	enc.Reset(w)
+	return &Writer{enc: enc, pool: pool}, true
+}
+
+// Write compresses p.
+func (w *Writer) Write(p []byte) (int, error) {
+	return w.enc.Write(p)
+}
+
+// Flush writes any buffered compressed data to the underlying writer.
+func (w *Writer) Flush() error {
+	return w.enc.Flush()
+}
+
+// Close finishes the compressed stream and returns the encoder to its pool.
+// Closing twice has no effect.
+func (w *Writer) Close() error {
+	if w.enc == nil {
+		return nil
+	}
+	err := w.enc.Close()
+	w.enc.Reset(nil)
+	w.pool.Put(w.enc)
+	w.enc = nil
+	return err
+}
+
+// Compress returns body compressed with encoding.
+func Compress(encoding Encoding, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.Grow(len(body) / 4)
+	w, ok := NewWriter(&buf, encoding)
+	if !ok {
+		return body, nil
+	}
+	if _, err := w.Write(body); err != nil {
+		_ = w.Close()
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// ETag returns the entity tag of a response after compression with encoding.
+// Strong tags identify exact bytes, so each coding gets its own tag; weak
+// tags are unchanged.
+func ETag(etag string, encoding Encoding) string {
+	if encoding == Identity || etag == "" || strings.HasPrefix(etag, "W/") || !strings.HasSuffix(etag, `"`) {
+		return etag
+	}
+	return etag[:len(etag)-1] + "-" + string(encoding) + `"`
+}
+
+// stripETags removes the coding suffix added by ETag for encoding from the
+// entity tags of a conditional header value. It returns the rewritten value
+// and the tags that had a suffix, without it.
+func stripETags(value string, encoding Encoding) (string, []string) {
+	suffix := "-" + string(encoding) + `"`
+	candidates := strings.Split(value, ",")
+	var stripped []string
+	for i, candidate := range candidates {
+		candidate = strings.TrimSpace(candidate)
+		if strings.HasPrefix(candidate, "W/") || !strings.HasSuffix(candidate, suffix) || len(candidate) <= len(suffix) {
+			continue
+		}
+		candidate = candidate[:len(candidate)-len(suffix)] + `"`
+		candidates[i] = candidate
+		stripped = append(stripped, candidate)
+	}
+	if len(stripped) == 0 {
+		return value, nil
+	}
+	for i := range candidates {
+		candidates[i] = strings.TrimSpace(candidates[i])
+	}
+	return strings.Join(candidates, ", "), stripped
+}
+
+// AddVary adds name to the Vary header unless it is already listed.
+func AddVary(h http.Header, name string) {
+	for _, value := range h.Values("Vary") {
+		for _, existing := range strings.Split(value, ",") {
+			existing = strings.TrimSpace(existing)
+			if existing == "*" || strings.EqualFold(existing, name) {
+				return
+			}
+		}
+	}
+	if current := h.Get("Vary"); current != "" && len(h.Values("Vary")) == 1 {
+		h.Set("Vary", current+", "+name)
+		return
+	}
+	h.Add("Vary", name)
+}
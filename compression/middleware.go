@@ -0,0 +1,203 @@
+package compression
+
+import (
+	"bytes"
+	"net/http"
+	"slices"
+	"strconv"
+)
+
+// Middleware compresses responses written by next, including streamed ones.
+// Writes are buffered until MinSize bytes are available or the handler
+// flushes; a flush then compresses the rest of the stream and flushes the
+// encoder, so streamed events reach the client promptly. Responses that
+// already carry a Content-Encoding, such as those prepared by a compressing
+// Responder, pass through unchanged.
+//
+// Strong ETags of compressed responses get a coding suffix, which is removed
+// from the If-None-Match and If-Match headers before next sees them, so
+// handlers revalidate against their own tags. HEAD requests get the headers
+// of the matching GET.
+func (c Config) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cw := &responseWriter{
+			ResponseWriter: w,
+			config:         c,
+			encoding:       c.Negotiate(r.Header.Get("Accept-Encoding")),
+			head:           r.Method == http.MethodHead,
+			statusCode:     http.StatusOK,
+		}
+		r = cw.stripConditions(r)
+		defer cw.close()
+		next.ServeHTTP(cw, r)
+	})
+}
+
+// stripConditions returns r with the coding suffix of its negotiated encoding
+// removed from the entity tags of its conditional headers, remembering the
+// tags it restored.
+func (cw *responseWriter) stripConditions(r *http.Request) *http.Request {
+	if cw.encoding == Identity {
+		return r
+	}
+	var header http.Header
+	for _, name := range []string{"If-None-Match", "If-Match"} {
+		value := r.Header.Get(name)
+		if value == "" {
+			continue
+		}
+		stripped, tags := stripETags(value, cw.encoding)
+		if len(tags) == 0 {
+			continue
+		}
+		if header == nil {
+			header = r.Header.Clone()
+		}
+		header.Set(name, stripped)
+		cw.stripped = append(cw.stripped, tags...)
+	}
+	if header == nil {
+		return r
+	}
+	r = r.WithContext(r.Context())
+	r.Header = header
+	return r
+}
+
+// responseWriter decides whether to compress once the response's headers and
+// enough of its body are known.
+type responseWriter struct {
+	http.ResponseWriter
+	config     Config
+	encoding   Encoding
+	head       bool
+	statusCode int
+	stripped   []string // Entity tags whose coding suffix was removed from the request
+
+	wroteHeader bool // The handler called WriteHeader
+	decided     bool // Headers were sent downstream
+	buf         bytes.Buffer
+	writer      *Writer
+}
+
+func (cw *responseWriter) WriteHeader(code int) {
+	if cw.wroteHeader || cw.decided {
+		return
+	}
+	if code >= 100 && code < 200 {
+		cw.ResponseWriter.WriteHeader(code)
+		return
+	}
+	cw.statusCode = code
+	cw.wroteHeader = true
+}
+
+func (cw *responseWriter) Write(p []byte) (int, error) {
+	if cw.head && cw.decided {
+		return len(p), nil // HEAD responses have no body
+	}
+	if !cw.decided {
+		cw.buf.Write(p)
+		if cw.buf.Len() < cw.config.minSize() {
+			return len(p), nil
+		}
+		if err := cw.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if cw.writer != nil {
+		return cw.writer.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what has been written so far. An undecided response is
+// compressed when eligible regardless of its size, since more is coming.
+func (cw *responseWriter) Flush() {
+	if !cw.decided {
+		if err := cw.decide(true); err != nil {
+			return
+		}
+	}
+	if cw.writer != nil {
+		if err := cw.writer.Flush(); err != nil {
+			return
+		}
+	}
+	if flusher, ok := cw.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (cw *responseWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// decide sends the headers, starting compression when the response is
+// eligible, and then the buffered body. large reports whether the size
+// threshold no longer applies. HEAD responses are sized by their
+// Content-Length when no body was written, and get the same headers as the
+// compressed GET without a body.
+func (cw *responseWriter) decide(large bool) error {
+	cw.decided = true
+	h := cw.Header()
+	if h.Get("Content-Type") == "" && cw.buf.Len() > 0 {
+		h.Set("Content-Type", http.DetectContentType(cw.buf.Bytes()))
+	}
+
+	size := cw.buf.Len()
+	if cw.head && size == 0 {
+		size, _ = strconv.Atoi(h.Get("Content-Length"))
+	}
+	bodyAllowed := cw.statusCode != http.StatusNoContent && cw.statusCode != http.StatusNotModified
+	candidate := bodyAllowed && h.Get("Content-Encoding") == "" && cw.config.Compressible(h.Get("Content-Type"))
+	if candidate && (large || size >= cw.config.minSize()) {
+		AddVary(h, "Accept-Encoding")
+		if cw.encoding != Identity {
+			compressed := cw.head
+			if !cw.head {
+				cw.writer, compressed = NewWriter(cw.ResponseWriter, cw.encoding)
+			}
+			if compressed {
+				h.Del("Content-Length")
+				h.Set("Content-Encoding", string(cw.encoding))
+				if etag := h.Get("ETag"); etag != "" {
+					h.Set("ETag", ETag(etag, cw.encoding))
+				}
+			}
+		}
+	}
+	if cw.statusCode == http.StatusNotModified && slices.Contains(cw.stripped, h.Get("ETag")) {
+		// The client revalidated the compressed representation
+		h.Set("ETag", ETag(h.Get("ETag"), cw.encoding))
+	}
+
+	cw.ResponseWriter.WriteHeader(cw.statusCode)
+	if cw.buf.Len() == 0 || cw.head {
+		cw.buf.Reset()
+		return nil
+	}
+	var err error
+	if cw.writer != nil {
+		_, err = cw.writer.Write(cw.buf.Bytes())
+	} else {
+		_, err = cw.ResponseWriter.Write(cw.buf.Bytes())
+	}
+	cw.buf.Reset()
+	return err
+}
+
+// close finishes the response once the handler returns.
+func (cw *responseWriter) close() {
+	if !cw.decided {
+		if !cw.wroteHeader && cw.buf.Len() == 0 && !cw.head {
+			return // Nothing was written; net/http sends its default response
+		}
+		_ = cw.decide(false)
+	}
+	if cw.writer != nil {
+		_ = cw.writer.Close()
+	}
+}
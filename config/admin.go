@@ -0,0 +1,446 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreascandle/FlexiResponseGo/utils"
+)
+
+// maskedValue replaces secret metadata values in admin responses.
+const maskedValue = "********"
+
+// DefaultSecretKeyPatterns are case-insensitive substrings marking metadata keys as secret.
+var DefaultSecretKeyPatterns = []string{"secret", "password", "token", "apikey", "api_key", "credential", "private"}
+
+// Change describes a configuration change made through the admin handler.
+type Change struct {
+	Actor       string
+	ClientIP    string
+	OldVersion  uint64
+	NewVersion  uint64
+	Fields      []string // Changed top-level fields and metadata keys
+	Method      string
+	Path        string
+	Succeeded   bool
+	FailureText string
+}
+
+// AdminOption configures the admin handler.
+type AdminOption func(*adminHandler)
+
+// WithSecretKeyPatterns replaces the patterns used to mask secret metadata.
+func WithSecretKeyPatterns(patterns ...string) AdminOption {
+	return func(h *adminHandler) {
+		h.secretPatterns = patterns
+	}
+}
+
+// WithChangeRecorder sets a callback invoked for every attempted change,
+// typically backed by the audit package.
+func WithChangeRecorder(fn func(Change)) AdminOption {
+	return func(h *adminHandler) {
+		h.recordChange = fn
+	}
+}
+
+// WithAuthorizer rejects requests for which fn returns false with 403 Forbidden.
+func WithAuthorizer(fn func(*http.Request) bool) AdminOption {
+	return func(h *adminHandler) {
+		h.authorize = fn
+	}
+}
+
+// WithTrustedProxies sets the reverse proxies whose forwarding headers are
+// believed when recording the client address of a change. Without it the
+// connection's address is recorded.
+func WithTrustedProxies(proxies utils.TrustedProxies) AdminOption {
+	return func(h *adminHandler) {
+		h.trustedProxies = proxies
+	}
+}
+
+// WithActorHeader sets the request header identifying the operator.
+func WithActorHeader(name string) AdminOption {
+	return func(h *adminHandler) {
+		h.actorHeader = name
+	}
+}
+
+type adminHandler struct {
+	config         *Config
+	secretPatterns []string
+	recordChange   func(Change)
+	authorize      func(*http.Request) bool
+	actorHeader    string
+	trustedProxies utils.TrustedProxies
+}
+
+// NewAdminHandler returns an http.Handler exposing the live configuration.
+//
+//	GET   returns the effective configuration with secret metadata masked and
+//	      an ETag carrying the configuration version.
+//	PATCH applies a JSON Merge Patch (RFC 7396) to GlobalMetadata, LogLevel
+//	      and Environment. The If-Match header must carry the current ETag,
+//	      or "*" to apply the patch to whatever version is current. Masked
+//	      secret values are ignored, so a GET response can be sent back.
+//
+// The handler performs no authentication of its own; mount it behind your
+// authentication middleware or supply WithAuthorizer.
+func NewAdminHandler(c *Config, opts ...AdminOption) http.Handler {
+	h := &adminHandler{
+		config:         c,
+		secretPatterns: DefaultSecretKeyPatterns,
+		actorHeader:    "X-User-ID",
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+// adminView is the JSON document served by the admin handler.
+type adminView struct {
+	Version        uint64                 `json:"version"`
+	GlobalMetadata map[string]interface{} `json:"GlobalMetadata"`
+	Settings       map[string]interface{} `json:"Settings"`
+	LogLevel       string                 `json:"LogLevel"`
+	Environment    string                 `json:"Environment"`
+	ServiceName    string                 `json:"ServiceName"`
+	Region         string                 `json:"Region"`
+}
+
+func (h *adminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.authorize != nil && !h.authorize(r) {
+		writeAdminError(w, http.StatusForbidden, "Access to configuration denied")
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		h.serveGet(w)
+	case http.MethodPatch:
+		h.servePatch(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, PATCH")
+		writeAdminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+	}
+}
+
+func (h *adminHandler) serveGet(w http.ResponseWriter) {
+	snap := h.config.Snapshot()
+	w.Header().Set("ETag", etagFor(snap.Version))
+	writeAdminJSON(w, http.StatusOK, adminView{
+		Version:        snap.Version,
+		GlobalMetadata: h.mask(snap.GlobalMetadata),
+		Settings:       h.mask(snap.Settings),
+		LogLevel:       snap.LogLevel,
+		Environment:    snap.Environment,
+		ServiceName:    snap.ServiceName,
+		Region:         snap.Region,
+	})
+}
+
+func (h *adminHandler) servePatch(w http.ResponseWriter, r *http.Request) {
+	change := Change{
+		Actor:    r.Header.Get(h.actorHeader),
+		ClientIP: h.trustedProxies.ClientIP(r.RemoteAddr, r.Header),
+		Method:   r.Method,
+		Path:     r.URL.Path,
+	}
+	fail := func(status int, message string) {
+		change.FailureText = message
+		h.record(change)
+		writeAdminError(w, status, message)
+	}
+
+	ifMatch := r.Header.Get("If-Match")
+	if ifMatch == "" {
+		fail(http.StatusPreconditionRequired, "If-Match header is required")
+		return
+	}
+	// "*" matches any current configuration, so the patch applies unconditionally.
+	anyVersion := strings.TrimSpace(ifMatch) == "*"
+	expected, ok := parseETag(ifMatch)
+	if !ok && !anyVersion {
+		fail(http.StatusPreconditionFailed, "If-Match does not match the current configuration version")
+		return
+	}
+
+	var patch map[string]json.RawMessage
+	body, err := io.ReadAll(io.LimitReader(r.Body, 1<<20))
+	if err != nil || json.Unmarshal(body, &patch) != nil || patch == nil {
+		fail(http.StatusBadRequest, "Body must be a JSON merge patch object")
+		return
+	}
+
+	update, err := parsePatch(patch)
+	if err == nil && update.metadata != nil {
+		update.metadata, err = h.unmask(update.metadata)
+	}
+	if err != nil {
+		fail(http.StatusUnprocessableEntity, err.Error())
+		return
+	}
+	change.Fields = update.fields()
+
+	mutate := func() error {
+		change.OldVersion = h.config.version
+		update.apply(h.config)
+		change.NewVersion = h.config.version + 1
+		return nil
+	}
+	if anyVersion {
+		err = h.config.update(mutate)
+	} else {
+		err = h.config.updateIfVersion(expected, mutate)
+	}
+	if errors.Is(err, ErrVersionMismatch) {
+		fail(http.StatusPreconditionFailed, "If-Match does not match the current configuration version")
+		return
+	}
+
+	change.Succeeded = true
+	h.record(change)
+	h.serveGet(w)
+}
+
+func (h *adminHandler) record(change Change) {
+	if h.recordChange != nil {
+		h.recordChange(change)
+	}
+}
+
+// mask copies values, replacing those under secret-looking keys at any depth.
+func (h *adminHandler) mask(values map[string]interface{}) map[string]interface{} {
+	masked := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if h.isSecret(k) {
+			masked[k] = maskedValue
+			continue
+		}
+		masked[k] = h.maskValue(v)
+	}
+	return masked
+}
+
+// maskValue masks the secrets nested in maps and slices.
+func (h *adminHandler) maskValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		return h.mask(v)
+	case map[string]string:
+		masked := make(map[string]interface{}, len(v))
+		for k, s := range v {
+			masked[k] = s
+		}
+		return h.mask(masked)
+	case []interface{}:
+		masked := make([]interface{}, len(v))
+		for i, item := range v {
+			masked[i] = h.maskValue(item)
+		}
+		return masked
+	default:
+		return v
+	}
+}
+
+// unmask drops masked values under secret keys from a metadata patch, so a
+// GET response sent back as a patch leaves the secrets untouched. Objects left
+// empty by the removal are dropped too. Masked values inside arrays cannot be
+// restored and are rejected, as arrays are replaced wholesale.
+func (h *adminHandler) unmask(values map[string]interface{}) (map[string]interface{}, error) {
+	unmasked := make(map[string]interface{}, len(values))
+	for k, v := range values {
+		if h.isSecret(k) && v == maskedValue {
+			continue
+		}
+		switch v := v.(type) {
+		case map[string]interface{}:
+			nested, err := h.unmask(v)
+			if err != nil {
+				return nil, err
+			}
+			if len(nested) == 0 && len(v) > 0 {
+				continue
+			}
+			unmasked[k] = nested
+		case []interface{}:
+			if h.containsMasked(v) {
+				return nil, fmt.Errorf("%s contains a masked secret; send the full value", k)
+			}
+			unmasked[k] = v
+		default:
+			unmasked[k] = v
+		}
+	}
+	return unmasked, nil
+}
+
+// containsMasked reports whether v holds a masked value under a secret key.
+func (h *adminHandler) containsMasked(v interface{}) bool {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, item := range v {
+			if h.isSecret(k) && item == maskedValue || h.containsMasked(item) {
+				return true
+			}
+		}
+	case []interface{}:
+		return slices.ContainsFunc(v, h.containsMasked)
+	}
+	return false
+}
+
+func (h *adminHandler) isSecret(key string) bool {
+	lower := strings.ToLower(key)
+	for _, pattern := range h.secretPatterns {
+		if strings.Contains(lower, strings.ToLower(pattern)) {
+			return true
+		}
+	}
+	return false
+}
+
+// configPatch is a validated merge patch.
+type configPatch struct {
+	metadata    map[string]interface{}
+	logLevel    *string
+	environment *string
+}
+
+func parsePatch(patch map[string]json.RawMessage) (configPatch, error) {
+	var p configPatch
+	for key, raw := range patch {
+		switch key {
+		case "GlobalMetadata":
+			if err := json.Unmarshal(raw, &p.metadata); err != nil || p.metadata == nil {
+				return p, errors.New("GlobalMetadata must be an object")
+			}
+		case KeyLogLevel:
+			value, err := patchString(key, raw, ValidLogLevels)
+			if err != nil {
+				return p, err
+			}
+			p.logLevel = &value
+		case KeyEnvironment:
+			value, err := patchString(key, raw, ValidEnvironments)
+			if err != nil {
+				return p, err
+			}
+			p.environment = &value
+		default:
+			return p, fmt.Errorf("%s cannot be changed at runtime", key)
+		}
+	}
+	return p, nil
+}
+
+func patchString(key string, raw json.RawMessage, allowed []string) (string, error) {
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		return "", fmt.Errorf("%s must be a string", key)
+	}
+	if !slices.Contains(allowed, value) {
+		return "", fmt.Errorf("%s: %q is not one of %s", key, value, strings.Join(allowed, ", "))
+	}
+	return value, nil
+}
+
+func (p configPatch) fields() []string {
+	var fields []string
+	if p.logLevel != nil {
+		fields = append(fields, KeyLogLevel)
+	}
+	if p.environment != nil {
+		fields = append(fields, KeyEnvironment)
+	}
+	keys := make([]string, 0, len(p.metadata))
+	for k := range p.metadata {
+		keys = append(keys, metadataKey(k))
+	}
+	sort.Strings(keys)
+	return append(fields, keys...)
+}
+
+// apply merges the patch into c. Callers must hold c.mu.
+func (p configPatch) apply(c *Config) {
+	if p.logLevel != nil {
+		c.LogLevel = *p.logLevel
+		c.setSource(KeyLogLevel, SourceRuntime)
+	}
+	if p.environment != nil {
+		c.Environment = *p.environment
+		c.setSource(KeyEnvironment, SourceRuntime)
+	}
+	if p.metadata != nil {
+		metadata := make(map[string]interface{}, len(c.GlobalMetadata))
+		for k, v := range c.GlobalMetadata {
+			metadata[k] = v
+		}
+		for k, v := range p.metadata {
+			if v == nil {
+				delete(metadata, k)
+				delete(c.sources, metadataKey(k))
+				continue
+			}
+			metadata[k] = mergePatchValue(metadata[k], v)
+			c.setSource(metadataKey(k), SourceRuntime)
+		}
+		c.GlobalMetadata = metadata
+	}
+}
+
+// mergePatchValue applies RFC 7396 semantics to nested objects.
+func mergePatchValue(target, patch interface{}) interface{} {
+	patchObj, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+	targetObj, ok := target.(map[string]interface{})
+	merged := make(map[string]interface{}, len(targetObj)+len(patchObj))
+	if ok {
+		for k, v := range targetObj {
+			merged[k] = v
+		}
+	}
+	for k, v := range patchObj {
+		if v == nil {
+			delete(merged, k)
+			continue
+		}
+		merged[k] = mergePatchValue(merged[k], v)
+	}
+	return merged
+}
+
+func etagFor(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+func parseETag(value string) (uint64, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "W/")
+	version, err := strconv.ParseUint(strings.Trim(value, `"`), 10, 64)
+	return version, err == nil
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, message string) {
+	writeAdminJSON(w, status, map[string]string{
+		"status":  "error",
+		"message": message,
+	})
+}
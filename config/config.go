@@ -1,118 +1,257 @@
 package config
 
 import (
-	"encoding/json"
 	"errors"
 	"os"
 	"sync"
+	"sync/atomic"
 )
 
-// Config holds the global configuration for the library.
+// Config holds the global configuration for the library. Writes go through the
+// Update*/Load* methods, which publish a new immutable Snapshot atomically;
+// readers on the hot path should use Snapshot instead of the exported fields.
+//
+// GlobalMetadata is public: it is copied into response envelopes subject to
+// MetadataPolicy. Settings are internal flags that never reach clients.
 type Config struct {
 	mu             sync.RWMutex
 	GlobalMetadata map[string]interface{}
+	Settings       map[string]interface{}
+	MetadataPolicy MetadataPolicy
+	Envelope       EnvelopeConfig
 	LogLevel       string
 	Environment    string
 	ServiceName    string
 	Region         string
+	sources        map[string]Source
+	subscribers    []subscriber
+	nextSubscriber uint64
+	version        uint64
+	current        atomic.Pointer[Snapshot]
 }
 
 // globalConfig is a singleton instance of Config.
 var globalConfig *Config
 var once sync.Once
 
+// New creates a Config populated with the library defaults.
+func New() *Config {
+	c := &Config{
+		GlobalMetadata: map[string]interface{}{
+			"version":     "1.0.0",
+			"serviceName": "FlexiResponseGo",
+			"region":      "default-region",
+		},
+		Settings:       map[string]interface{}{},
+		MetadataPolicy: MetadataPolicy{Fields: defaultMetadataVisibility()},
+		LogLevel:       "info",
+		Environment:    "production",
+		ServiceName:    "FlexiResponseGo",
+		Region:         "default-region",
+	}
+	snap := c.snapshotLocked()
+	c.current.Store(&snap)
+	return c
+}
+
+// defaultMetadataVisibility returns the built-in visibility rules, which keep
+// internal flags historically stored in GlobalMetadata hidden.
+func defaultMetadataVisibility() map[string]Visibility {
+	return map[string]Visibility{"enableLocalization": VisibilityNever}
+}
+
 // GetConfig initializes or returns the singleton Config instance.
 func GetConfig() *Config {
 	once.Do(func() {
-		globalConfig = &Config{
-			GlobalMetadata: map[string]interface{}{
-				"version":     "1.0.0",
-				"serviceName": "FlexiResponseGo",
-				"region":      "default-region",
-			},
-			LogLevel:    "info",
-			Environment: "production",
-			ServiceName: "FlexiResponseGo",
-			Region:      "default-region",
-		}
+		globalConfig = New()
 	})
 	return globalConfig
 }
 
 // UpdateMetadata updates global metadata key-value pairs dynamically.
 func (c *Config) UpdateMetadata(key string, value interface{}) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.GlobalMetadata[key] = value
+	_ = c.update(func() error {
+		if c.GlobalMetadata == nil {
+			c.GlobalMetadata = make(map[string]interface{})
+		}
+		c.GlobalMetadata[key] = value
+		c.setSource(metadataKey(key), SourceRuntime)
+		return nil
+	})
 }
 
 // GetMetadata retrieves the value for a metadata key.
 func (c *Config) GetMetadata(key string) (interface{}, bool) {
-	c.mu.RLock()
-	defer c.mu.RUnlock()
-	value, exists := c.GlobalMetadata[key]
-	return value, exists
+	return c.Snapshot().Metadata(key)
 }
 
 // UpdateLogLevel dynamically updates the logging level.
 func (c *Config) UpdateLogLevel(level string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.LogLevel = level
+	_ = c.update(func() error {
+		c.LogLevel = level
+		c.setSource(KeyLogLevel, SourceRuntime)
+		return nil
+	})
 }
 
 // UpdateEnvironment dynamically updates the environment.
 func (c *Config) UpdateEnvironment(env string) {
-	c.mu.Lock()
-	defer c.mu.Unlock()
-	c.Environment = env
+	_ = c.update(func() error {
+		c.Environment = env
+		c.setSource(KeyEnvironment, SourceRuntime)
+		return nil
+	})
 }
 
-// LoadFromFile loads configuration from a JSON file.
+// LoadFromFile loads configuration from a JSON, YAML or TOML file, detected by
+// extension. Unknown keys and invalid values are rejected with line-numbered
+// errors; keys absent from the file keep their current values. Values set from
+// the environment, flags or at runtime take precedence over the file and are
+// kept, so the file can be reloaded without undoing them.
 func (c *Config) LoadFromFile(filepath string) error {
-	file, err := os.Open(filepath)
+	format, err := FormatFromPath(filepath)
+	if err != nil {
+		return err
+	}
+	data, err := os.ReadFile(filepath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
 
-	var fileConfig Config
-	decoder := json.NewDecoder(file)
-	if err := decoder.Decode(&fileConfig); err != nil {
+	fileConfig, err := decodeFile(filepath, format, data)
+	if err != nil {
+		return err
+	}
+	if err := fileConfig.validate(filepath, data); err != nil {
 		return err
 	}
 
 	// Lock and update the current configuration
-	c.mu.Lock()
-	defer c.mu.Unlock()
+	return c.update(func() error {
+		c.clearSources(SourceFile)
+		if fileConfig.GlobalMetadata != nil {
+			c.GlobalMetadata = mergeFileValues(c, c.GlobalMetadata, fileConfig.GlobalMetadata, metadataKey)
+		}
+		if c.GlobalMetadata == nil {
+			c.GlobalMetadata = make(map[string]interface{})
+		}
+		if fileConfig.Settings != nil {
+			c.Settings = mergeFileValues(c, c.Settings, fileConfig.Settings, settingKey)
+		}
+		if fileConfig.MetadataVisibility != nil {
+			fields := mergeFileValues(c, c.MetadataPolicy.Fields, fileConfig.MetadataVisibility, visibilityKey)
+			// File rules refine the built-in ones rather than dropping them.
+			for k, v := range defaultMetadataVisibility() {
+				if _, ok := fields[k]; !ok {
+					fields[k] = v
+				}
+			}
+			c.MetadataPolicy.Fields = fields
+		}
+		if fileConfig.RouteMetadataVisibility != nil {
+			c.MetadataPolicy.Routes = c.mergeFileRoutes(c.MetadataPolicy.Routes, fileConfig.RouteMetadataVisibility)
+		}
+		if fileConfig.Envelope != nil && c.fileOwns(KeyEnvelope) {
+			c.Envelope = *fileConfig.Envelope
+			c.setSource(KeyEnvelope, SourceFile)
+		}
+		for key, field := range map[string]struct {
+			value  *string
+			target *string
+		}{
+			KeyLogLevel:    {fileConfig.LogLevel, &c.LogLevel},
+			KeyEnvironment: {fileConfig.Environment, &c.Environment},
+			KeyServiceName: {fileConfig.ServiceName, &c.ServiceName},
+			KeyRegion:      {fileConfig.Region, &c.Region},
+		} {
+			if field.value != nil && c.fileOwns(key) {
+				*field.target = *field.value
+				c.setSource(key, SourceFile)
+			}
+		}
+		return nil
+	})
+}
+
+// fileOwns reports whether a file may set key, which holds unless the key was
+// set by a source of higher precedence. Callers must hold c.mu.
+func (c *Config) fileOwns(key string) bool {
+	switch c.sources[key] {
+	case "", SourceDefault, SourceFile:
+		return true
+	}
+	return false
+}
 
-	c.GlobalMetadata = fileConfig.GlobalMetadata
-	c.LogLevel = fileConfig.LogLevel
-	c.Environment = fileConfig.Environment
-	c.ServiceName = fileConfig.ServiceName
-	c.Region = fileConfig.Region
+// mergeFileValues returns the values of a file map, keeping the current
+// values of keys set by a source of higher precedence than the file. Callers
+// must hold c.mu.
+func mergeFileValues[V any](c *Config, current, file map[string]V, sourceKey func(string) string) map[string]V {
+	merged := make(map[string]V, len(file))
+	for k, v := range file {
+		if c.fileOwns(sourceKey(k)) {
+			merged[k] = v
+			c.setSource(sourceKey(k), SourceFile)
+		}
+	}
+	for k, v := range current {
+		if !c.fileOwns(sourceKey(k)) {
+			merged[k] = v
+		}
+	}
+	return merged
+}
 
-	return nil
+// mergeFileRoutes merges per-route visibility rules like mergeFileValues,
+// tracking the source of each route and key pair. Callers must hold c.mu.
+func (c *Config) mergeFileRoutes(current, file map[string]map[string]Visibility) map[string]map[string]Visibility {
+	merged := make(map[string]map[string]Visibility, len(file))
+	for pattern, rules := range file {
+		sourceKey := func(key string) string { return routeVisibilityKey(pattern, key) }
+		if rules := mergeFileValues(c, current[pattern], rules, sourceKey); len(rules) > 0 {
+			merged[pattern] = rules
+		}
+	}
+	for pattern, rules := range current {
+		if _, ok := file[pattern]; ok {
+			continue
+		}
+		for key, v := range rules {
+			if !c.fileOwns(routeVisibilityKey(pattern, key)) {
+				if merged[pattern] == nil {
+					merged[pattern] = make(map[string]Visibility)
+				}
+				merged[pattern][key] = v
+			}
+		}
+	}
+	return merged
 }
 
-// SaveToFile saves the current configuration to a JSON file.
+// SaveToFile saves the current configuration in the format matching the file extension.
 func (c *Config) SaveToFile(filepath string) error {
-	file, err := os.Create(filepath)
+	format, err := FormatFromPath(filepath)
 	if err != nil {
 		return err
 	}
-	defer file.Close()
-
-	c.mu.RLock()
-	defer c.mu.RUnlock()
 
-	encoder := json.NewEncoder(file)
-	encoder.SetIndent("", "  ")
-	if err := encoder.Encode(c); err != nil {
+	snap := c.Snapshot()
+	data, err := encodeFile(format, fileSchema{
+		GlobalMetadata:          snap.GlobalMetadata,
+		Settings:                snap.Settings,
+		MetadataVisibility:      snap.MetadataPolicy.Fields,
+		RouteMetadataVisibility: snap.MetadataPolicy.Routes,
+		Envelope:                envelopeOrNil(snap.Envelope),
+		LogLevel:                &snap.LogLevel,
+		Environment:             &snap.Environment,
+		ServiceName:             &snap.ServiceName,
+		Region:                  &snap.Region,
+	})
+	if err != nil {
 		return err
 	}
 
-	return nil
+	return os.WriteFile(filepath, data, 0o644)
 }
 
 // ReloadFromFile reloads configuration from the given file dynamically.
@@ -122,3 +261,11 @@ func (c *Config) ReloadFromFile(filepath string) error {
 	}
 	return c.LoadFromFile(filepath)
 }
+
+// envelopeOrNil omits the default envelope from saved files.
+func envelopeOrNil(e EnvelopeConfig) *EnvelopeConfig {
+	if e.IsZero() {
+		return nil
+	}
+	return &e
+}
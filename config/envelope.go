@@ -0,0 +1,83 @@
+package config
+
+// NamingStrategy selects how envelope field names are spelled.
+type NamingStrategy string
+
+const (
+	NamingSnake NamingStrategy = "snake" // trace_id (default)
+	NamingCamel NamingStrategy = "camel" // traceId
+	NamingKebab NamingStrategy = "kebab" // trace-id
+)
+
+// ValidNamingStrategies lists the accepted NamingStrategy values.
+var ValidNamingStrategies = []string{string(NamingSnake), string(NamingCamel), string(NamingKebab)}
+
+// LinkStyle selects how hypermedia links are emitted.
+type LinkStyle string
+
+const (
+	LinkStyleSimple LinkStyle = "simple" // "links": {"self": "/orders/1"} (default)
+	LinkStyleHAL    LinkStyle = "hal"    // "_links": {"self": {"href": "/orders/1"}}
+)
+
+// ValidLinkStyles lists the accepted LinkStyle values.
+var ValidLinkStyles = []string{string(LinkStyleSimple), string(LinkStyleHAL)}
+
+// ValidEnvelopeTransforms lists the value transforms available to envelopes
+// defined in configuration: "success_bool" turns the status into a boolean and
+// "list" wraps a value in an array.
+var ValidEnvelopeTransforms = []string{"success_bool", "list"}
+
+// EnvelopeConfig describes the shape of response envelopes. Fields are
+// identified by their default names: status, message, message_key, data,
+// error, trace_id, field_errors, metadata and links.
+type EnvelopeConfig struct {
+	Naming     NamingStrategy         `json:"Naming,omitempty" yaml:"Naming,omitempty" toml:"Naming,omitempty"`
+	Rename     map[string]string      `json:"Rename,omitempty" yaml:"Rename,omitempty" toml:"Rename,omitempty"`
+	Omit       []string               `json:"Omit,omitempty" yaml:"Omit,omitempty" toml:"Omit,omitempty"`
+	Extra      map[string]interface{} `json:"Extra,omitempty" yaml:"Extra,omitempty" toml:"Extra,omitempty"`
+	Transforms map[string]string      `json:"Transforms,omitempty" yaml:"Transforms,omitempty" toml:"Transforms,omitempty"`
+	Links      LinkStyle              `json:"Links,omitempty" yaml:"Links,omitempty" toml:"Links,omitempty"`
+}
+
+// IsZero reports whether the envelope keeps the default shape.
+func (e EnvelopeConfig) IsZero() bool {
+	return (e.Naming == "" || e.Naming == NamingSnake) && (e.Links == "" || e.Links == LinkStyleSimple) &&
+		len(e.Rename) == 0 && len(e.Omit) == 0 && len(e.Extra) == 0 && len(e.Transforms) == 0
+}
+
+// UpdateEnvelope replaces the envelope shape used by responders.
+func (c *Config) UpdateEnvelope(envelope EnvelopeConfig) {
+	_ = c.update(func() error {
+		c.Envelope = envelope
+		c.setSource(KeyEnvelope, SourceRuntime)
+		return nil
+	})
+}
+
+// clone returns a deep copy of the envelope configuration.
+func (e EnvelopeConfig) clone() EnvelopeConfig {
+	out := EnvelopeConfig{Naming: e.Naming, Links: e.Links}
+	if e.Rename != nil {
+		out.Rename = make(map[string]string, len(e.Rename))
+		for k, v := range e.Rename {
+			out.Rename[k] = v
+		}
+	}
+	if e.Omit != nil {
+		out.Omit = append([]string(nil), e.Omit...)
+	}
+	if e.Extra != nil {
+		out.Extra = make(map[string]interface{}, len(e.Extra))
+		for k, v := range e.Extra {
+			out.Extra[k] = v
+		}
+	}
+	if e.Transforms != nil {
+		out.Transforms = make(map[string]string, len(e.Transforms))
+		for k, v := range e.Transforms {
+			out.Transforms[k] = v
+		}
+	}
+	return out
+}
@@ -0,0 +1,292 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+// Format identifies a configuration file encoding.
+type Format string
+
+const (
+	FormatJSON Format = "json"
+	FormatYAML Format = "yaml"
+	FormatTOML Format = "toml"
+)
+
+// Allowed values for enumerated configuration fields.
+var (
+	ValidLogLevels    = []string{"debug", "info", "warn", "error"}
+	ValidEnvironments = []string{"development", "test", "staging", "production"}
+)
+
+// FormatFromPath detects the configuration format from a file extension.
+func FormatFromPath(path string) (Format, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return FormatJSON, nil
+	case ".yaml", ".yml":
+		return FormatYAML, nil
+	case ".toml":
+		return FormatTOML, nil
+	default:
+		return "", fmt.Errorf("unsupported configuration file extension %q", filepath.Ext(path))
+	}
+}
+
+// FieldError describes a single problem found in a configuration file.
+type FieldError struct {
+	File    string
+	Line    int
+	Field   string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	var b strings.Builder
+	if e.File != "" {
+		b.WriteString(e.File)
+		if e.Line > 0 {
+			b.WriteString(":" + strconv.Itoa(e.Line))
+		}
+		b.WriteString(": ")
+	}
+	if e.Field != "" {
+		b.WriteString(e.Field + ": ")
+	}
+	b.WriteString(e.Message)
+	return b.String()
+}
+
+// ValidationErrors aggregates every problem found while loading a file.
+type ValidationErrors []FieldError
+
+func (e ValidationErrors) Error() string {
+	msgs := make([]string, len(e))
+	for i, fe := range e {
+		msgs[i] = fe.Error()
+	}
+	return strings.Join(msgs, "\n")
+}
+
+// fileSchema is the on-disk configuration layout. Pointer fields distinguish
+// keys that are absent from keys explicitly set to their zero value.
+type fileSchema struct {
+	GlobalMetadata          map[string]interface{}           `json:"GlobalMetadata,omitempty" yaml:"GlobalMetadata,omitempty" toml:"GlobalMetadata,omitempty"`
+	Settings                map[string]interface{}           `json:"Settings,omitempty" yaml:"Settings,omitempty" toml:"Settings,omitempty"`
+	MetadataVisibility      map[string]Visibility            `json:"MetadataVisibility,omitempty" yaml:"MetadataVisibility,omitempty" toml:"MetadataVisibility,omitempty"`
+	RouteMetadataVisibility map[string]map[string]Visibility `json:"RouteMetadataVisibility,omitempty" yaml:"RouteMetadataVisibility,omitempty" toml:"RouteMetadataVisibility,omitempty"`
+	Envelope                *EnvelopeConfig                  `json:"Envelope,omitempty" yaml:"Envelope,omitempty" toml:"Envelope,omitempty"`
+	LogLevel                *string                          `json:"LogLevel,omitempty" yaml:"LogLevel,omitempty" toml:"LogLevel,omitempty"`
+	Environment             *string                          `json:"Environment,omitempty" yaml:"Environment,omitempty" toml:"Environment,omitempty"`
+	ServiceName             *string                          `json:"ServiceName,omitempty" yaml:"ServiceName,omitempty" toml:"ServiceName,omitempty"`
+	Region                  *string                          `json:"Region,omitempty" yaml:"Region,omitempty" toml:"Region,omitempty"`
+}
+
+// decodeFile strictly decodes data in the given format, rejecting unknown keys.
+func decodeFile(path string, format Format, data []byte) (fileSchema, error) {
+	var schema fileSchema
+	switch format {
+	case FormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&schema); err != nil {
+			return schema, ValidationErrors{jsonFieldError(path, data, err)}
+		}
+	case FormatYAML:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&schema); err != nil && !errors.Is(err, io.EOF) {
+			return schema, yamlFieldErrors(path, err)
+		}
+	case FormatTOML:
+		decoder := toml.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err := decoder.Decode(&schema); err != nil {
+			return schema, tomlFieldErrors(path, err)
+		}
+	default:
+		return schema, fmt.Errorf("unsupported configuration format %q", format)
+	}
+	return schema, nil
+}
+
+var unknownJSONField = regexp.MustCompile(`unknown field "([^"]+)"`)
+
+func jsonFieldError(path string, data []byte, err error) FieldError {
+	fe := FieldError{File: path, Message: err.Error()}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntaxErr):
+		fe.Line = lineAtOffset(data, syntaxErr.Offset)
+	case errors.As(err, &typeErr):
+		fe.Line = lineAtOffset(data, typeErr.Offset)
+		fe.Field = typeErr.Field
+		fe.Message = fmt.Sprintf("cannot use %s as %s", typeErr.Value, typeErr.Type)
+	default:
+		if m := unknownJSONField.FindStringSubmatch(err.Error()); m != nil {
+			fe.Field = m[1]
+			fe.Line = lineOfKey(data, `"`+m[1]+`"`)
+			fe.Message = "unknown field"
+		}
+	}
+	return fe
+}
+
+var yamlLine = regexp.MustCompile(`line (\d+): (.*)`)
+
+func yamlFieldErrors(path string, err error) ValidationErrors {
+	messages := []string{err.Error()}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		messages = typeErr.Errors
+	}
+
+	var errs ValidationErrors
+	for _, msg := range messages {
+		fe := FieldError{File: path, Message: msg}
+		if m := yamlLine.FindStringSubmatch(msg); m != nil {
+			fe.Line, _ = strconv.Atoi(m[1])
+			fe.Message = strings.Replace(m[2], " in type config.fileSchema", "", 1)
+		}
+		errs = append(errs, fe)
+	}
+	return errs
+}
+
+func tomlFieldErrors(path string, err error) ValidationErrors {
+	var strictErr *toml.StrictMissingError
+	if errors.As(err, &strictErr) {
+		var errs ValidationErrors
+		for _, de := range strictErr.Errors {
+			row, _ := de.Position()
+			errs = append(errs, FieldError{
+				File:    path,
+				Line:    row,
+				Field:   strings.Join(de.Key(), "."),
+				Message: "unknown field",
+			})
+		}
+		return errs
+	}
+
+	fe := FieldError{File: path, Message: err.Error()}
+	var decodeErr *toml.DecodeError
+	if errors.As(err, &decodeErr) {
+		fe.Line, _ = decodeErr.Position()
+	}
+	return ValidationErrors{fe}
+}
+
+// validate checks enumerated fields and returns every violation.
+func (s fileSchema) validate(path string, data []byte) error {
+	var errs ValidationErrors
+	check := func(field string, value *string, allowed []string) {
+		if value == nil {
+			return
+		}
+		if !slices.Contains(allowed, *value) {
+			errs = append(errs, FieldError{
+				File:    path,
+				Line:    lineOfKey(data, field),
+				Field:   field,
+				Message: fmt.Sprintf("%q is not one of %s", *value, strings.Join(allowed, ", ")),
+			})
+		}
+	}
+	check(KeyLogLevel, s.LogLevel, ValidLogLevels)
+	check(KeyEnvironment, s.Environment, ValidEnvironments)
+
+	checkVisibility := func(field string, rules map[string]Visibility) {
+		for key, v := range rules {
+			value := string(v)
+			check(field+"."+key, &value, ValidVisibilities)
+		}
+	}
+	checkVisibility("MetadataVisibility", s.MetadataVisibility)
+	for route, rules := range s.RouteMetadataVisibility {
+		checkVisibility("RouteMetadataVisibility."+route, rules)
+	}
+	if s.Envelope != nil {
+		if s.Envelope.Naming != "" {
+			naming := string(s.Envelope.Naming)
+			check("Envelope.Naming", &naming, ValidNamingStrategies)
+		}
+		if s.Envelope.Links != "" {
+			style := string(s.Envelope.Links)
+			check("Envelope.Links", &style, ValidLinkStyles)
+		}
+		for field, transform := range s.Envelope.Transforms {
+			name := transform
+			check("Envelope.Transforms."+field, &name, ValidEnvelopeTransforms)
+		}
+	}
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Validate checks that enumerated fields hold supported values.
+func (c *Config) Validate() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	var errs ValidationErrors
+	if !slices.Contains(ValidLogLevels, c.LogLevel) {
+		errs = append(errs, FieldError{Field: KeyLogLevel,
+			Message: fmt.Sprintf("%q is not one of %s", c.LogLevel, strings.Join(ValidLogLevels, ", "))})
+	}
+	if !slices.Contains(ValidEnvironments, c.Environment) {
+		errs = append(errs, FieldError{Field: KeyEnvironment,
+			Message: fmt.Sprintf("%q is not one of %s", c.Environment, strings.Join(ValidEnvironments, ", "))})
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// lineAtOffset converts a byte offset into a 1-based line number.
+func lineAtOffset(data []byte, offset int64) int {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	return bytes.Count(data[:offset], []byte("\n")) + 1
+}
+
+// lineOfKey returns the first line mentioning key, or 0 if it is not found.
+func lineOfKey(data []byte, key string) int {
+	idx := bytes.Index(data, []byte(key))
+	if idx < 0 {
+		return 0
+	}
+	return lineAtOffset(data, int64(idx))
+}
+
+// encodeFile renders the schema in the given format.
+func encodeFile(format Format, schema fileSchema) ([]byte, error) {
+	switch format {
+	case FormatJSON:
+		return json.MarshalIndent(schema, "", "  ")
+	case FormatYAML:
+		return yaml.Marshal(schema)
+	case FormatTOML:
+		return toml.Marshal(schema)
+	default:
+		return nil, fmt.Errorf("unsupported configuration format %q", format)
+	}
+}
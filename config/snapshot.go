@@ -0,0 +1,144 @@
+package config
+
+import "errors"
+
+// Snapshot is an immutable copy of the configuration at a point in time.
+// Snapshots are shared between readers and must not be modified.
+type Snapshot struct {
+	Version        uint64 // Incremented on every change
+	GlobalMetadata map[string]interface{}
+	Settings       map[string]interface{}
+	MetadataPolicy MetadataPolicy
+	Envelope       EnvelopeConfig
+	LogLevel       string
+	Environment    string
+	ServiceName    string
+	Region         string
+}
+
+// Metadata returns the value for a metadata key in the snapshot.
+func (s Snapshot) Metadata(key string) (interface{}, bool) {
+	value, ok := s.GlobalMetadata[key]
+	return value, ok
+}
+
+// Setting returns the value for an internal setting in the snapshot.
+func (s Snapshot) Setting(key string) (interface{}, bool) {
+	value, ok := s.Settings[key]
+	return value, ok
+}
+
+// Debug reports whether debug-only metadata is visible.
+func (s Snapshot) Debug() bool {
+	return s.LogLevel == "debug"
+}
+
+// ChangeFunc is called after the configuration changes.
+type ChangeFunc func(old, new Snapshot)
+
+// subscriber is a registered ChangeFunc.
+type subscriber struct {
+	id uint64
+	fn ChangeFunc
+}
+
+// Snapshot returns the current configuration without taking any lock. It is
+// safe to call from hot paths concurrently with updates.
+func (c *Config) Snapshot() Snapshot {
+	if current := c.current.Load(); current != nil {
+		return *current
+	}
+
+	// Configs created as struct literals have not published a snapshot yet.
+	c.mu.RLock()
+	snap := c.snapshotLocked()
+	c.mu.RUnlock()
+	c.current.CompareAndSwap(nil, &snap)
+	return *c.current.Load()
+}
+
+// snapshotLocked copies the configuration. Callers must hold c.mu.
+func (c *Config) snapshotLocked() Snapshot {
+	metadata := make(map[string]interface{}, len(c.GlobalMetadata))
+	for k, v := range c.GlobalMetadata {
+		metadata[k] = v
+	}
+	settings := make(map[string]interface{}, len(c.Settings))
+	for k, v := range c.Settings {
+		settings[k] = v
+	}
+	return Snapshot{
+		Version:        c.version,
+		GlobalMetadata: metadata,
+		Settings:       settings,
+		MetadataPolicy: c.MetadataPolicy.clone(),
+		Envelope:       c.Envelope.clone(),
+		LogLevel:       c.LogLevel,
+		Environment:    c.Environment,
+		ServiceName:    c.ServiceName,
+		Region:         c.Region,
+	}
+}
+
+// OnChange registers fn to be called after every configuration change,
+// whether made at runtime, by a Load* call or by a file Watcher. The returned
+// function removes the subscription.
+func (c *Config) OnChange(fn ChangeFunc) func() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextSubscriber++
+	id := c.nextSubscriber
+	c.subscribers = append(c.subscribers, subscriber{id: id, fn: fn})
+
+	return func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		for i, sub := range c.subscribers {
+			if sub.id == id {
+				c.subscribers = append(c.subscribers[:i:i], c.subscribers[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+// update applies mutate under the write lock and notifies subscribers once the
+// lock is released. Subscribers are not notified when mutate fails.
+func (c *Config) update(mutate func() error) error {
+	c.mu.Lock()
+	old := c.snapshotLocked()
+	if err := mutate(); err != nil {
+		c.mu.Unlock()
+		return err
+	}
+	c.version++
+	current := c.snapshotLocked()
+	c.current.Store(&current)
+	subscribers := c.subscribers
+	c.mu.Unlock()
+
+	for _, sub := range subscribers {
+		sub.fn(old, current)
+	}
+	return nil
+}
+
+// ErrVersionMismatch is returned when a conditional update targets a stale version.
+var ErrVersionMismatch = errors.New("configuration version mismatch")
+
+// Version returns the current configuration version.
+func (c *Config) Version() uint64 {
+	return c.Snapshot().Version
+}
+
+// updateIfVersion behaves like update but fails with ErrVersionMismatch unless
+// the configuration is still at the expected version.
+func (c *Config) updateIfVersion(expected uint64, mutate func() error) error {
+	return c.update(func() error {
+		if c.version != expected {
+			return ErrVersionMismatch
+		}
+		return mutate()
+	})
+}
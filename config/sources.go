@@ -0,0 +1,289 @@
+package config
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// Source identifies where an effective configuration value came from.
+// Sources are applied in increasing precedence:
+// defaults < file < env < flags. Runtime updates always win.
+type Source string
+
+const (
+	SourceDefault Source = "default"
+	SourceFile    Source = "file"
+	SourceEnv     Source = "env"
+	SourceFlag    Source = "flag"
+	SourceRuntime Source = "runtime"
+)
+
+// Keys used in the source report for top-level fields. Metadata keys are
+// reported as "GlobalMetadata.<key>", visibility rules as
+// "MetadataVisibility.<key>" and "RouteMetadataVisibility.<route>.<key>".
+const (
+	KeyLogLevel    = "LogLevel"
+	KeyEnvironment = "Environment"
+	KeyServiceName = "ServiceName"
+	KeyRegion      = "Region"
+	KeyEnvelope    = "Envelope"
+)
+
+func metadataKey(key string) string {
+	return "GlobalMetadata." + key
+}
+
+func settingKey(key string) string {
+	return "Settings." + key
+}
+
+func visibilityKey(key string) string {
+	return "MetadataVisibility." + key
+}
+
+func routeVisibilityKey(pattern, key string) string {
+	return "RouteMetadataVisibility." + pattern + "." + key
+}
+
+// setSource records the origin of a value. Callers must hold c.mu.
+func (c *Config) setSource(key string, src Source) {
+	if c.sources == nil {
+		c.sources = make(map[string]Source)
+	}
+	c.sources[key] = src
+}
+
+// clearSources forgets the values recorded as coming from src, reporting
+// them as defaults until set again. Callers must hold c.mu.
+func (c *Config) clearSources(src Source) {
+	for key, recorded := range c.sources {
+		if recorded == src {
+			delete(c.sources, key)
+		}
+	}
+}
+
+// Sources reports where each effective value came from. Values never
+// overridden are reported as SourceDefault.
+func (c *Config) Sources() map[string]Source {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	report := map[string]Source{
+		KeyLogLevel:    SourceDefault,
+		KeyEnvironment: SourceDefault,
+		KeyServiceName: SourceDefault,
+		KeyRegion:      SourceDefault,
+	}
+	for k := range c.GlobalMetadata {
+		report[metadataKey(k)] = SourceDefault
+	}
+	for k := range c.Settings {
+		report[settingKey(k)] = SourceDefault
+	}
+	for k, v := range c.sources {
+		report[k] = v
+	}
+	return report
+}
+
+// SourceReport renders Sources as sorted "key=source" lines.
+func (c *Config) SourceReport() string {
+	sources := c.Sources()
+	keys := make([]string, 0, len(sources))
+	for k := range sources {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s=%s\n", k, sources[k])
+	}
+	return b.String()
+}
+
+// LoadFromEnv overrides configuration from environment variables named
+// <PREFIX>_LOG_LEVEL, <PREFIX>_ENVIRONMENT, <PREFIX>_SERVICE_NAME,
+// <PREFIX>_REGION and <PREFIX>_METADATA_<KEY>. Metadata keys are converted
+// from SNAKE_CASE to camelCase, so FLEXI_METADATA_API_VERSION sets "apiVersion".
+func (c *Config) LoadFromEnv(prefix string) error {
+	prefix = strings.TrimSuffix(strings.ToUpper(prefix), "_") + "_"
+	metadataPrefix := prefix + "METADATA_"
+
+	return c.update(func() error {
+		fields := map[string]struct {
+			key    string
+			target *string
+		}{
+			prefix + "LOG_LEVEL":    {KeyLogLevel, &c.LogLevel},
+			prefix + "ENVIRONMENT":  {KeyEnvironment, &c.Environment},
+			prefix + "SERVICE_NAME": {KeyServiceName, &c.ServiceName},
+			prefix + "REGION":       {KeyRegion, &c.Region},
+		}
+
+		for _, kv := range os.Environ() {
+			name, value, ok := strings.Cut(kv, "=")
+			if !ok {
+				continue
+			}
+			if field, ok := fields[name]; ok {
+				*field.target = value
+				c.setSource(field.key, SourceEnv)
+				continue
+			}
+			if strings.HasPrefix(name, metadataPrefix) && len(name) > len(metadataPrefix) {
+				key := snakeToCamel(strings.TrimPrefix(name, metadataPrefix))
+				if c.GlobalMetadata == nil {
+					c.GlobalMetadata = make(map[string]interface{})
+				}
+				c.GlobalMetadata[key] = parseValue(value)
+				c.setSource(metadataKey(key), SourceEnv)
+			}
+		}
+		return nil
+	})
+}
+
+// snakeToCamel converts API_VERSION to apiVersion.
+func snakeToCamel(s string) string {
+	parts := strings.Split(strings.ToLower(s), "_")
+	var b strings.Builder
+	for i, p := range parts {
+		if p == "" {
+			continue
+		}
+		if i > 0 && b.Len() > 0 {
+			b.WriteString(strings.ToUpper(p[:1]) + p[1:])
+			continue
+		}
+		b.WriteString(p)
+	}
+	return b.String()
+}
+
+// parseValue interprets booleans and numbers so env and flag metadata match
+// values decoded from JSON files. Anything else is kept as a string.
+func parseValue(raw string) interface{} {
+	var v interface{}
+	if err := json.Unmarshal([]byte(raw), &v); err == nil {
+		switch v.(type) {
+		case bool, float64:
+			return v
+		}
+	}
+	return raw
+}
+
+// FlagBinding holds command-line flags bound to a Config.
+type FlagBinding struct {
+	fs          *flag.FlagSet
+	config      *Config
+	logLevel    string
+	environment string
+	serviceName string
+	region      string
+	metadata    metadataFlag
+}
+
+// metadataFlag collects repeated -metadata key=value flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for k, v := range m {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (m metadataFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("metadata must be key=value, got %q", value)
+	}
+	m[key] = val
+	return nil
+}
+
+// BindFlags registers -log-level, -environment, -service-name, -region and a
+// repeatable -metadata key=value flag on fs. Call Apply on the returned
+// binding after fs.Parse to copy the flags that were set onto the Config.
+func (c *Config) BindFlags(fs *flag.FlagSet) *FlagBinding {
+	b := &FlagBinding{fs: fs, config: c, metadata: metadataFlag{}}
+	fs.StringVar(&b.logLevel, "log-level", "", "log level (debug, info, warn, error)")
+	fs.StringVar(&b.environment, "environment", "", "deployment environment")
+	fs.StringVar(&b.serviceName, "service-name", "", "service name")
+	fs.StringVar(&b.region, "region", "", "deployment region")
+	fs.Var(b.metadata, "metadata", "global response metadata as key=value (repeatable)")
+	return b
+}
+
+// Apply copies explicitly set flags onto the bound Config.
+func (b *FlagBinding) Apply() error {
+	if !b.fs.Parsed() {
+		return fmt.Errorf("flag set %q has not been parsed", b.fs.Name())
+	}
+
+	c := b.config
+	return c.update(func() error {
+		b.fs.Visit(func(f *flag.Flag) {
+			switch f.Name {
+			case "log-level":
+				c.LogLevel = b.logLevel
+				c.setSource(KeyLogLevel, SourceFlag)
+			case "environment":
+				c.Environment = b.environment
+				c.setSource(KeyEnvironment, SourceFlag)
+			case "service-name":
+				c.ServiceName = b.serviceName
+				c.setSource(KeyServiceName, SourceFlag)
+			case "region":
+				c.Region = b.region
+				c.setSource(KeyRegion, SourceFlag)
+			case "metadata":
+				if c.GlobalMetadata == nil {
+					c.GlobalMetadata = make(map[string]interface{})
+				}
+				for k, v := range b.metadata {
+					c.GlobalMetadata[k] = parseValue(v)
+					c.setSource(metadataKey(k), SourceFlag)
+				}
+			}
+		})
+		return nil
+	})
+}
+
+// LoadOptions lists the configuration sources to apply. Empty fields are skipped.
+type LoadOptions struct {
+	File      string       // JSON, YAML or TOML configuration file
+	EnvPrefix string       // Environment variable prefix, e.g. "FLEXI"
+	Flags     *FlagBinding // Parsed flag binding from BindFlags
+}
+
+// Load applies every configured source in precedence order:
+// defaults < file < env < flags, then validates the effective configuration.
+func (c *Config) Load(opts LoadOptions) error {
+	if opts.File != "" {
+		if err := c.LoadFromFile(opts.File); err != nil {
+			return err
+		}
+	}
+	if opts.EnvPrefix != "" {
+		if err := c.LoadFromEnv(opts.EnvPrefix); err != nil {
+			return err
+		}
+	}
+	if opts.Flags != nil {
+		if err := opts.Flags.Apply(); err != nil {
+			return err
+		}
+	}
+	return c.Validate()
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"container/list"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// ErrTenantNotFound is returned by loaders when a tenant has no overlay.
+var ErrTenantNotFound = errors.New("tenant overlay not found")
+
+// TenantResolver extracts a tenant ID from an incoming request. It returns an
+// empty string when the request does not identify a tenant.
+type TenantResolver interface {
+	ResolveTenant(headers http.Header, host string) string
+}
+
+// TenantHeaders is implemented by resolvers that read request headers, so
+// that cacheable responses can name them in Vary.
+type TenantHeaders interface {
+	TenantHeaders() []string
+}
+
+// TenantResolverFunc adapts a function to TenantResolver.
+type TenantResolverFunc func(headers http.Header, host string) string
+
+// ResolveTenant calls f(headers, host).
+func (f TenantResolverFunc) ResolveTenant(headers http.Header, host string) string {
+	return f(headers, host)
+}
+
+// HeaderTenantResolver reads the tenant ID from a request header.
+type HeaderTenantResolver struct {
+	Header string // Defaults to X-Tenant-ID
+}
+
+// ResolveTenant implements TenantResolver.
+func (r HeaderTenantResolver) ResolveTenant(headers http.Header, _ string) string {
+	return strings.TrimSpace(headers.Get(r.header()))
+}
+
+// TenantHeaders implements TenantHeaders.
+func (r HeaderTenantResolver) TenantHeaders() []string {
+	return []string{r.header()}
+}
+
+func (r HeaderTenantResolver) header() string {
+	if r.Header == "" {
+		return "X-Tenant-ID"
+	}
+	return r.Header
+}
+
+// SubdomainTenantResolver uses the left-most label of hosts under BaseDomain,
+// so acme.api.example.com resolves to "acme" for BaseDomain "api.example.com".
+type SubdomainTenantResolver struct {
+	BaseDomain string
+}
+
+// ResolveTenant implements TenantResolver.
+func (r SubdomainTenantResolver) ResolveTenant(_ http.Header, host string) string {
+	if i := strings.LastIndex(host, ":"); i >= 0 && !strings.Contains(host[i:], "]") {
+		host = host[:i]
+	}
+	suffix := "." + strings.TrimPrefix(r.BaseDomain, ".")
+	if !strings.HasSuffix(host, suffix) {
+		return ""
+	}
+	sub := strings.TrimSuffix(host, suffix)
+	if strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// JWTClaimTenantResolver reads a claim from a bearer token's payload.
+// The token signature is NOT verified; it must already have been
+// authenticated by middleware running before the response is written.
+type JWTClaimTenantResolver struct {
+	Header string // Defaults to Authorization
+	Claim  string // Defaults to tenant_id
+}
+
+// ResolveTenant implements TenantResolver.
+func (r JWTClaimTenantResolver) ResolveTenant(headers http.Header, _ string) string {
+	claim := r.Claim
+	if claim == "" {
+		claim = "tenant_id"
+	}
+
+	token := strings.TrimSpace(headers.Get(r.header()))
+	if len(token) > 7 && strings.EqualFold(token[:7], "bearer ") {
+		token = token[7:]
+	}
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims map[string]interface{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	value, _ := claims[claim].(string)
+	return value
+}
+
+// TenantHeaders implements TenantHeaders.
+func (r JWTClaimTenantResolver) TenantHeaders() []string {
+	return []string{r.header()}
+}
+
+func (r JWTClaimTenantResolver) header() string {
+	if r.Header == "" {
+		return "Authorization"
+	}
+	return r.Header
+}
+
+// TenantResolverChain returns the first tenant ID found by its resolvers.
+type TenantResolverChain []TenantResolver
+
+// ResolveTenant implements TenantResolver.
+func (c TenantResolverChain) ResolveTenant(headers http.Header, host string) string {
+	for _, r := range c {
+		if id := r.ResolveTenant(headers, host); id != "" {
+			return id
+		}
+	}
+	return ""
+}
+
+// TenantHeaders implements TenantHeaders, listing the headers read by every
+// resolver in the chain.
+func (c TenantResolverChain) TenantHeaders() []string {
+	var names []string
+	for _, r := range c {
+		if h, ok := r.(TenantHeaders); ok {
+			names = append(names, h.TenantHeaders()...)
+		}
+	}
+	return names
+}
+
+// Overlay holds tenant-specific values merged over the global configuration.
+type Overlay struct {
+	TenantID       string
+	GlobalMetadata map[string]interface{}
+}
+
+// OverlayLoader fetches a tenant overlay, returning ErrTenantNotFound when
+// the tenant has none.
+type OverlayLoader interface {
+	LoadOverlay(tenantID string) (*Overlay, error)
+}
+
+// validTenantID restricts tenant IDs used in file names.
+var validTenantID = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// DirectoryLoader loads overlays from <Dir>/<tenant>.json, .yaml, .yml or .toml
+// using the same strict schema as LoadFromFile.
+type DirectoryLoader struct {
+	Dir string
+}
+
+// LoadOverlay implements OverlayLoader.
+func (d DirectoryLoader) LoadOverlay(tenantID string) (*Overlay, error) {
+	if !validTenantID.MatchString(tenantID) {
+		return nil, fmt.Errorf("invalid tenant ID %q", tenantID)
+	}
+	for _, ext := range []string{".json", ".yaml", ".yml", ".toml"} {
+		path := filepath.Join(d.Dir, tenantID+ext)
+		data, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		format, _ := FormatFromPath(path)
+		schema, err := decodeFile(path, format, data)
+		if err != nil {
+			return nil, err
+		}
+		return &Overlay{TenantID: tenantID, GlobalMetadata: schema.GlobalMetadata}, nil
+	}
+	return nil, ErrTenantNotFound
+}
+
+// TenantStore caches tenant overlays in a bounded LRU cache in front of a loader.
+type TenantStore struct {
+	loader   OverlayLoader
+	capacity int
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type tenantEntry struct {
+	tenantID string
+	overlay  *Overlay // nil caches the absence of an overlay
+}
+
+// NewTenantStore creates a store holding at most capacity overlays. A nil
+// loader means overlays are only available through Set.
+func NewTenantStore(loader OverlayLoader, capacity int) *TenantStore {
+	if capacity <= 0 {
+		capacity = 1024
+	}
+	return &TenantStore{
+		loader:   loader,
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Overlay returns the overlay for tenantID, loading and caching it on a miss.
+// Tenants without an overlay yield nil and no error.
+func (s *TenantStore) Overlay(tenantID string) (*Overlay, error) {
+	s.mu.Lock()
+	if el, ok := s.entries[tenantID]; ok {
+		s.order.MoveToFront(el)
+		overlay := el.Value.(*tenantEntry).overlay
+		s.mu.Unlock()
+		return overlay, nil
+	}
+	s.mu.Unlock()
+
+	if s.loader == nil {
+		return nil, nil
+	}
+	overlay, err := s.loader.LoadOverlay(tenantID)
+	if errors.Is(err, ErrTenantNotFound) {
+		overlay, err = nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.put(tenantID, overlay)
+	return overlay, nil
+}
+
+// Set stores an overlay directly, replacing any cached value.
+func (s *TenantStore) Set(overlay *Overlay) {
+	s.put(overlay.TenantID, overlay)
+}
+
+// Invalidate drops a cached overlay so the next lookup reloads it.
+func (s *TenantStore) Invalidate(tenantID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if el, ok := s.entries[tenantID]; ok {
+		s.order.Remove(el)
+		delete(s.entries, tenantID)
+	}
+}
+
+// Len returns the number of cached tenants.
+func (s *TenantStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.order.Len()
+}
+
+func (s *TenantStore) put(tenantID string, overlay *Overlay) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.entries[tenantID]; ok {
+		el.Value.(*tenantEntry).overlay = overlay
+		s.order.MoveToFront(el)
+		return
+	}
+	s.entries[tenantID] = s.order.PushFront(&tenantEntry{tenantID: tenantID, overlay: overlay})
+	for s.order.Len() > s.capacity {
+		oldest := s.order.Back()
+		s.order.Remove(oldest)
+		delete(s.entries, oldest.Value.(*tenantEntry).tenantID)
+	}
+}
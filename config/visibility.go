@@ -0,0 +1,136 @@
+package config
+
+import "strings"
+
+// Visibility controls when a metadata key is included in response envelopes.
+type Visibility string
+
+const (
+	VisibilityAlways Visibility = "always" // Included in every response
+	VisibilityErrors Visibility = "errors" // Included in error responses only
+	VisibilityDebug  Visibility = "debug"  // Included only while LogLevel is debug
+	VisibilityNever  Visibility = "never"  // Never included
+)
+
+// ValidVisibilities lists the accepted Visibility values.
+var ValidVisibilities = []string{
+	string(VisibilityAlways),
+	string(VisibilityErrors),
+	string(VisibilityDebug),
+	string(VisibilityNever),
+}
+
+// MetadataPolicy decides which metadata keys appear in response envelopes.
+// Keys without a rule are always visible.
+type MetadataPolicy struct {
+	Fields map[string]Visibility            // Visibility per metadata key
+	Routes map[string]map[string]Visibility // Overrides per route; "/users/*" matches a path prefix
+}
+
+// VisibilityFor returns the visibility of key on route, preferring the most
+// specific route override over the field rule.
+func (p MetadataPolicy) VisibilityFor(route, key string) Visibility {
+	best, bestLen := Visibility(""), -1
+	for pattern, fields := range p.Routes {
+		v, ok := fields[key]
+		if !ok || !RouteMatches(pattern, route) || len(pattern) <= bestLen {
+			continue
+		}
+		best, bestLen = v, len(pattern)
+	}
+	if bestLen >= 0 {
+		return best
+	}
+	if v, ok := p.Fields[key]; ok {
+		return v
+	}
+	return VisibilityAlways
+}
+
+// Visible reports whether key is included in a response on route.
+func (p MetadataPolicy) Visible(route, key string, isError, debug bool) bool {
+	switch p.VisibilityFor(route, key) {
+	case VisibilityErrors:
+		return isError
+	case VisibilityDebug:
+		return debug
+	case VisibilityNever:
+		return false
+	default:
+		return true
+	}
+}
+
+// clone returns a deep copy of the policy.
+func (p MetadataPolicy) clone() MetadataPolicy {
+	out := MetadataPolicy{Fields: make(map[string]Visibility, len(p.Fields))}
+	for k, v := range p.Fields {
+		out.Fields[k] = v
+	}
+	if len(p.Routes) > 0 {
+		out.Routes = make(map[string]map[string]Visibility, len(p.Routes))
+		for route, fields := range p.Routes {
+			copied := make(map[string]Visibility, len(fields))
+			for k, v := range fields {
+				copied[k] = v
+			}
+			out.Routes[route] = copied
+		}
+	}
+	return out
+}
+
+// RouteMatches reports whether route matches pattern; a trailing "*" matches
+// a prefix, so "/users/*" matches every path under /users/.
+func RouteMatches(pattern, route string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "*"); ok {
+		return strings.HasPrefix(route, prefix)
+	}
+	return pattern == route
+}
+
+// UpdateSetting sets an internal setting. Settings are never copied into
+// response metadata.
+func (c *Config) UpdateSetting(key string, value interface{}) {
+	_ = c.update(func() error {
+		if c.Settings == nil {
+			c.Settings = make(map[string]interface{})
+		}
+		c.Settings[key] = value
+		c.setSource(settingKey(key), SourceRuntime)
+		return nil
+	})
+}
+
+// GetSetting retrieves the value of an internal setting.
+func (c *Config) GetSetting(key string) (interface{}, bool) {
+	return c.Snapshot().Setting(key)
+}
+
+// SetMetadataVisibility sets the visibility of a metadata key on every route.
+func (c *Config) SetMetadataVisibility(key string, v Visibility) {
+	_ = c.update(func() error {
+		if c.MetadataPolicy.Fields == nil {
+			c.MetadataPolicy.Fields = make(map[string]Visibility)
+		}
+		c.MetadataPolicy.Fields[key] = v
+		c.setSource(visibilityKey(key), SourceRuntime)
+		return nil
+	})
+}
+
+// SetRouteMetadataVisibility overrides the visibility of a metadata key on
+// routes matching pattern.
+func (c *Config) SetRouteMetadataVisibility(pattern, key string, v Visibility) {
+	_ = c.update(func() error {
+		if c.MetadataPolicy.Routes == nil {
+			c.MetadataPolicy.Routes = make(map[string]map[string]Visibility)
+		}
+		if c.MetadataPolicy.Routes[pattern] == nil {
+			c.MetadataPolicy.Routes[pattern] = make(map[string]Visibility)
+		}
+		c.MetadataPolicy.Routes[pattern][key] = v
+		c.setSource(routeVisibilityKey(pattern, key), SourceRuntime)
+		return nil
+	})
+}
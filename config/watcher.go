@@ -0,0 +1,232 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/logger"
+	"go.uber.org/zap"
+)
+
+// notifier emits a signal whenever the watched file may have changed. Events
+// is closed if the notifier fails, after which Err reports why.
+type notifier interface {
+	Events() <-chan struct{}
+	Err() error
+	Close() error
+}
+
+// Watcher reloads a Config whenever its backing file changes.
+type Watcher struct {
+	config       *Config
+	path         string
+	debounce     time.Duration
+	pollInterval time.Duration
+	forcePolling bool
+	onError      func(error)
+	onReload     func()
+
+	notifier  notifier
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// WatchOption configures a Watcher.
+type WatchOption func(*Watcher)
+
+// WithDebounce sets how long the watcher waits for writes to settle before reloading.
+func WithDebounce(d time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.debounce = d
+	}
+}
+
+// WithPollInterval sets the interval used by the polling fallback.
+func WithPollInterval(d time.Duration) WatchOption {
+	return func(w *Watcher) {
+		w.pollInterval = d
+	}
+}
+
+// WithPolling disables native file notifications and always polls.
+func WithPolling() WatchOption {
+	return func(w *Watcher) {
+		w.forcePolling = true
+	}
+}
+
+// WithErrorHandler sets the callback invoked when a reload fails. The current
+// configuration is kept whenever a reload fails.
+func WithErrorHandler(fn func(error)) WatchOption {
+	return func(w *Watcher) {
+		w.onError = fn
+	}
+}
+
+// WithReloadHandler sets a callback invoked after each successful reload.
+func WithReloadHandler(fn func()) WatchOption {
+	return func(w *Watcher) {
+		w.onReload = fn
+	}
+}
+
+// Watch starts watching path and reloads the configuration when it changes.
+// Files are decoded and validated before being applied, so an invalid file
+// never replaces a good configuration. Subscribers registered with OnChange
+// are notified after each successful reload.
+func (c *Config) Watch(path string, opts ...WatchOption) (*Watcher, error) {
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+
+	w := &Watcher{
+		config:       c,
+		path:         path,
+		debounce:     100 * time.Millisecond,
+		pollInterval: time.Second,
+		onError: func(err error) {
+			logger.GetLogger().Error("Failed to reload configuration",
+				zap.String("path", path),
+				zap.Error(err),
+			)
+		},
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	var err error
+	if !w.forcePolling {
+		w.notifier, err = newNativeNotifier(path)
+	}
+	if w.forcePolling || err != nil {
+		w.notifier = newPollNotifier(path, w.pollInterval)
+	}
+
+	go w.run()
+	return w, nil
+}
+
+// run debounces change events and reloads the configuration.
+func (w *Watcher) run() {
+	defer close(w.done)
+
+	timer := time.NewTimer(w.debounce)
+	if !timer.Stop() {
+		<-timer.C
+	}
+
+	for {
+		select {
+		case <-w.stop:
+			timer.Stop()
+			return
+		case _, ok := <-w.notifier.Events():
+			if !ok {
+				w.fallBackToPolling()
+				continue
+			}
+			timer.Reset(w.debounce)
+		case <-timer.C:
+			w.reload()
+		}
+	}
+}
+
+// fallBackToPolling reports a failed native notifier and replaces it with the
+// polling notifier, so hot reload keeps working.
+func (w *Watcher) fallBackToPolling() {
+	err := fmt.Errorf("file notifications for %s failed, polling instead: %w", w.path, w.notifier.Err())
+	_ = w.notifier.Close()
+	w.notifier = newPollNotifier(w.path, w.pollInterval)
+	if w.onError != nil {
+		w.onError(err)
+	}
+}
+
+// reload applies the file, reporting failures without touching the config.
+func (w *Watcher) reload() {
+	if err := w.config.LoadFromFile(w.path); err != nil {
+		if w.onError != nil {
+			w.onError(err)
+		}
+		return
+	}
+	if w.onReload != nil {
+		w.onReload()
+	}
+}
+
+// Close stops watching the file.
+func (w *Watcher) Close() error {
+	var err error
+	w.closeOnce.Do(func() {
+		close(w.stop)
+		<-w.done // run owns the notifier until it returns
+		err = w.notifier.Close()
+	})
+	return err
+}
+
+// pollNotifier detects changes by comparing file size and modification time.
+type pollNotifier struct {
+	events chan struct{}
+	stop   chan struct{}
+}
+
+func newPollNotifier(path string, interval time.Duration) *pollNotifier {
+	p := &pollNotifier{
+		events: make(chan struct{}, 1),
+		stop:   make(chan struct{}),
+	}
+	go p.run(path, interval)
+	return p
+}
+
+func (p *pollNotifier) run(path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last, _ := os.Stat(path)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			current, err := os.Stat(path)
+			if err != nil {
+				// The file may be briefly missing while an editor replaces it.
+				continue
+			}
+			if last == nil || current.ModTime() != last.ModTime() || current.Size() != last.Size() {
+				last = current
+				p.signal()
+			}
+		}
+	}
+}
+
+func (p *pollNotifier) signal() {
+	select {
+	case p.events <- struct{}{}:
+	default:
+	}
+}
+
+func (p *pollNotifier) Events() <-chan struct{} {
+	return p.events
+}
+
+func (p *pollNotifier) Err() error {
+	return nil
+}
+
+func (p *pollNotifier) Close() error {
+	close(p.stop)
+	return nil
+}
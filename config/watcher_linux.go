@@ -0,0 +1,173 @@
+//go:build linux
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// inotifyMask selects the directory events that may change a watched file.
+const inotifyMask = uint32(unix.IN_CLOSE_WRITE | unix.IN_MODIFY | unix.IN_MOVED_TO | unix.IN_CREATE)
+
+// inotifyNotifier watches the directories holding the file and every symlink
+// it resolves through, so atomic replacements made by editors and config
+// management tools (write to temp file, then rename) and symlink swaps such as
+// the "..data" link of a Kubernetes ConfigMap volume are seen. Watches follow
+// the symlinks again after every change.
+type inotifyNotifier struct {
+	fd      int
+	file    *os.File
+	path    string
+	watches map[int32]map[string]bool // Watch descriptor -> relevant entry names
+	events  chan struct{}
+	err     error
+}
+
+func newNativeNotifier(path string) (notifier, error) {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	n := &inotifyNotifier{
+		fd:      fd,
+		path:    filepath.Clean(path),
+		watches: make(map[int32]map[string]bool),
+		events:  make(chan struct{}, 1),
+	}
+	if err := n.watch(); err != nil {
+		unix.Close(fd)
+		return nil, err
+	}
+	// A non-blocking descriptor is registered with the runtime poller, so
+	// closing the file unblocks the pending Read.
+	n.file = os.NewFile(uintptr(fd), "inotify")
+	go n.run()
+	return n, nil
+}
+
+// watch watches the directories returned by watchTargets, dropping watches of
+// directories no longer involved. Only the file's own directory is required.
+func (n *inotifyNotifier) watch() error {
+	watches := make(map[int32]map[string]bool)
+	for dir, names := range watchTargets(n.path) {
+		wd, err := unix.InotifyAddWatch(n.fd, dir, inotifyMask)
+		if err != nil {
+			if dir == filepath.Dir(n.path) {
+				return err
+			}
+			continue
+		}
+		watches[int32(wd)] = names
+	}
+	for wd := range n.watches {
+		if _, ok := watches[wd]; !ok {
+			_, _ = unix.InotifyRmWatch(n.fd, uint32(wd))
+		}
+	}
+	n.watches = watches
+	return nil
+}
+
+// watchTargets maps each directory to watch to the names of its entries whose
+// changes may change the contents of path: the file itself, each symlink it
+// resolves through, the first entry of relative symlink targets, and the
+// resolved file.
+func watchTargets(path string) map[string]map[string]bool {
+	targets := make(map[string]map[string]bool)
+	add := func(p string) {
+		dir := filepath.Dir(p)
+		if targets[dir] == nil {
+			targets[dir] = make(map[string]bool)
+		}
+		targets[dir][filepath.Base(p)] = true
+	}
+
+	p := path
+	for hops := 0; hops < 32; hops++ {
+		add(p)
+		target, err := os.Readlink(p)
+		if err != nil {
+			break // Not a symlink
+		}
+		dir := filepath.Dir(p)
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		rel, err := filepath.Rel(dir, target)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			first, _, _ := strings.Cut(rel, string(filepath.Separator))
+			add(filepath.Join(dir, first))
+		}
+		p = target
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		add(resolved)
+	}
+	return targets
+}
+
+func (n *inotifyNotifier) run() {
+	defer close(n.events)
+
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		count, err := n.file.Read(buf)
+		if err != nil {
+			n.err = err
+			return
+		}
+		changed := false
+		for offset := 0; offset+unix.SizeofInotifyEvent <= count; {
+			event := (*unix.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			start := offset + unix.SizeofInotifyEvent
+			end := start + int(event.Len)
+			offset = end
+			if end > count {
+				break
+			}
+			if event.Mask&unix.IN_IGNORED != 0 {
+				delete(n.watches, event.Wd) // The directory was removed
+				continue
+			}
+			if n.watches[event.Wd][cString(buf[start:end])] {
+				changed = true
+			}
+		}
+		if !changed {
+			continue
+		}
+		_ = n.watch()
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// cString trims the NUL padding from an inotify event name.
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+func (n *inotifyNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+// Err returns the error that stopped the notifier once Events is closed.
+func (n *inotifyNotifier) Err() error {
+	return n.err
+}
+
+func (n *inotifyNotifier) Close() error {
+	return n.file.Close()
+}
@@ -0,0 +1,137 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+)
+
+// CachePolicy declares how clients and shared caches may store a successful
+// response. It is rendered as Cache-Control and Vary headers.
+type CachePolicy struct {
+	Public               bool          // Shared caches may store the response
+	Private              bool          // Only the client may store the response
+	NoCache              bool          // Caches must revalidate before reuse
+	NoStore              bool          // Nothing may store the response
+	MaxAge               time.Duration // Freshness lifetime
+	SharedMaxAge         time.Duration // Freshness lifetime in shared caches (s-maxage)
+	StaleWhileRevalidate time.Duration // Serve stale while refreshing in the background
+	StaleIfError         time.Duration // Serve stale when the origin fails
+	MustRevalidate       bool
+	Immutable            bool
+	Vary                 []string // Request headers that select the representation
+}
+
+// PublicCache returns a policy letting any cache store a response for maxAge.
+func PublicCache(maxAge time.Duration) CachePolicy {
+	return CachePolicy{Public: true, MaxAge: maxAge}
+}
+
+// PrivateCache returns a policy letting only the client store a response.
+func PrivateCache(maxAge time.Duration) CachePolicy {
+	return CachePolicy{Private: true, MaxAge: maxAge}
+}
+
+// NoStoreCache returns a policy forbidding storage of a response.
+func NoStoreCache() CachePolicy {
+	return CachePolicy{NoStore: true}
+}
+
+// WithStaleWhileRevalidate returns a copy of p allowing stale reuse for d
+// while the response is refreshed.
+func (p CachePolicy) WithStaleWhileRevalidate(d time.Duration) CachePolicy {
+	p.StaleWhileRevalidate = d
+	return p
+}
+
+// WithVary returns a copy of p that also varies on the given request headers.
+func (p CachePolicy) WithVary(headers ...string) CachePolicy {
+	p.Vary = append(append([]string(nil), p.Vary...), headers...)
+	return p
+}
+
+// CacheControl renders the Cache-Control header value.
+func (p CachePolicy) CacheControl() string {
+	var directives []string
+	add := func(directive string, enabled bool) {
+		if enabled {
+			directives = append(directives, directive)
+		}
+	}
+	seconds := func(directive string, d time.Duration) {
+		if d > 0 {
+			directives = append(directives, directive+"="+strconv.FormatInt(int64(d/time.Second), 10))
+		}
+	}
+	add("public", p.Public && !p.Private)
+	add("private", p.Private)
+	add("no-cache", p.NoCache)
+	add("no-store", p.NoStore)
+	if !p.NoStore {
+		seconds("max-age", p.MaxAge)
+		seconds("s-maxage", p.SharedMaxAge)
+		seconds("stale-while-revalidate", p.StaleWhileRevalidate)
+		seconds("stale-if-error", p.StaleIfError)
+	}
+	add("must-revalidate", p.MustRevalidate)
+	add("immutable", p.Immutable)
+	return strings.Join(directives, ", ")
+}
+
+// WithCachePolicy attaches a cache policy to a successful response.
+func WithCachePolicy(policy CachePolicy) ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.cachePolicy = &policy
+	}
+}
+
+// WithCacheTags labels a response for invalidation in response caches. Tags
+// are sent in the Cache-Tag header.
+func WithCacheTags(tags ...string) ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.cacheTags = append(append([]string(nil), resp.cacheTags...), tags...)
+	}
+}
+
+// setCacheHeaders sets the Cache-Control, Vary and Cache-Tag headers of resp.
+// Responses negotiated by locale or renderer also vary on the request headers
+// used for negotiation, and responses of tenant-scoped responders on the
+// headers their tenant resolver reads.
+func (r *Responder) setCacheHeaders(h http.Header, resp StandardResponse) {
+	if len(resp.cacheTags) > 0 {
+		h.Set("Cache-Tag", strings.Join(resp.cacheTags, ","))
+	}
+	policy := resp.cachePolicy
+	if policy == nil {
+		return
+	}
+	if value := policy.CacheControl(); value != "" {
+		h.Set("Cache-Control", value)
+	}
+
+	vary := append([]string(nil), policy.Vary...)
+	if len(r.renderers) > 0 {
+		vary = append(vary, "Accept")
+	}
+	if r.localizationEnabled() {
+		vary = append(vary, "Accept-Language")
+	}
+	if tenantHeaders, ok := r.tenantResolver.(config.TenantHeaders); ok {
+		vary = append(vary, tenantHeaders.TenantHeaders()...)
+	}
+	seen := make(map[string]bool, len(vary))
+	var names []string
+	for _, name := range vary {
+		name = http.CanonicalHeaderKey(strings.TrimSpace(name))
+		if name != "" && !seen[name] {
+			seen[name] = true
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		h.Set("Vary", strings.Join(names, ", "))
+	}
+}
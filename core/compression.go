@@ -0,0 +1,34 @@
+package core
+
+import (
+	"github.com/andreascandle/FlexiResponseGo/compression"
+)
+
+// contentCoding returns the coding a body of size bytes and contentType is
+// sent with, and whether the body is eligible for compression at all, in
+// which case the response varies on Accept-Encoding.
+func (r *Responder) contentCoding(contentType string, size int) (compression.Encoding, bool) {
+	if r.compression == nil || !r.compression.Eligible(contentType, size) {
+		return compression.Identity, false
+	}
+	return r.encoding, true
+}
+
+// compress replaces the prepared body with its encoding and sets the
+// Content-Encoding and Vary headers.
+func (r *Responder) compress(p *Prepared, encoding compression.Encoding, eligible bool) error {
+	if !eligible {
+		return nil
+	}
+	compression.AddVary(p.Header, "Accept-Encoding")
+	if encoding == compression.Identity || len(p.Body) == 0 {
+		return nil
+	}
+	body, err := compression.Compress(encoding, p.Body)
+	if err != nil {
+		return err
+	}
+	p.Body = body
+	p.Header.Set("Content-Encoding", string(encoding))
+	return nil
+}
@@ -0,0 +1,296 @@
+package core
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/compression"
+	"golang.org/x/text/language"
+)
+
+// validators holds the cache validators of a response and the conditional
+// headers of the request it answers.
+type validators struct {
+	version      string    // Weak ETag source
+	weak         bool      // Compute a weak ETag from the encoded payload
+	strong       bool      // Compute a strong ETag from the exact body sent
+	lastModified time.Time // Last-Modified value
+
+	method  string      // Request method, when conditions were recorded
+	headers http.Header // Request headers holding the conditions
+}
+
+// validatorsFor returns a copy of resp's validators for modification, so
+// responses copied from one another never share them.
+func validatorsFor(resp *StandardResponse) *validators {
+	v := &validators{}
+	if resp.validators != nil {
+		*v = *resp.validators
+	}
+	resp.validators = v
+	return v
+}
+
+// WithETag sends a weak ETag computed from the encoded body without its
+// per-request trace ID and timestamp, so identical payloads share a tag and
+// revalidate across content codings.
+func WithETag() ResponseOption {
+	return func(resp *StandardResponse) {
+		validatorsFor(resp).weak = true
+	}
+}
+
+// WithStrongETag sends a strong ETag computed from the exact bytes sent,
+// specific to their content coding. The body includes the trace ID and
+// timestamp, so the tag only matches byte-identical responses, such as those
+// replayed from a cache.
+func WithStrongETag() ResponseOption {
+	return func(resp *StandardResponse) {
+		validatorsFor(resp).strong = true
+	}
+}
+
+// WithVersion sends a weak ETag derived from version, such as a row version
+// or revision number, instead of hashing the body.
+func WithVersion(version string) ResponseOption {
+	return func(resp *StandardResponse) {
+		validatorsFor(resp).version = version
+	}
+}
+
+// WithLastModified sends a Last-Modified header and answers If-Modified-Since.
+func WithLastModified(t time.Time) ResponseOption {
+	return func(resp *StandardResponse) {
+		validatorsFor(resp).lastModified = t
+	}
+}
+
+// WithConditions evaluates the If-Match, If-None-Match and If-Modified-Since
+// headers of GET and HEAD requests against the response's validators. It
+// computes no ETag itself; responses opt in with WithETag, WithStrongETag or
+// WithVersion. Handlers of unsafe methods check preconditions with EvaluatePreconditions
+// before changing any state.
+func WithConditions(method string, headers http.Header) ResponseOption {
+	return func(resp *StandardResponse) {
+		v := validatorsFor(resp)
+		v.method = method
+		v.headers = headers
+	}
+}
+
+// ErrPreconditionFailed is the error sent when a request precondition fails.
+var ErrPreconditionFailed = NewAPIError(ClientError, http.StatusPreconditionFailed,
+	"Precondition Failed", "The resource has changed since it was last retrieved.")
+
+// Prepared is an encoded response ready to be written by any framework.
+type Prepared struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte // Empty for 304 Not Modified
+}
+
+// Prepare encodes resp and applies its validators, cache policy and content
+// coding: ETag, Last-Modified and Cache-Control are set on successful
+// responses, request conditions may turn the response into a 304 Not Modified
+// or a 412 Precondition Failed error envelope, and eligible bodies are
+// compressed with the responder's negotiated coding. Responses whose status
+// forbids content, such as 204 No Content, are prepared without a body or
+// Content-Type.
+//
+// Nothing is written, so a failure never leaves a half-sent response. When
+// resp cannot be encoded, Prepare logs and counts the failure and returns a
+// 500 ServerError envelope carrying resp's trace ID, together with an
+// *EncodingError; the envelope is ready to be written in place of resp.
+func (r *Responder) Prepare(statusCode int, resp StandardResponse) (*Prepared, error) {
+	p, err := r.prepare(statusCode, resp)
+	if err != nil {
+		return r.prepareFailure(statusCode, resp.TraceID, err), &EncodingError{StatusCode: statusCode, TraceID: resp.TraceID, Err: err}
+	}
+	return p, nil
+}
+
+func (r *Responder) prepare(statusCode int, resp StandardResponse) (*Prepared, error) {
+	if bodyless(statusCode) {
+		return r.prepareBodyless(statusCode, resp), nil
+	}
+	p := &Prepared{StatusCode: statusCode, Header: make(http.Header)}
+	contentType := r.ContentType()
+	p.Header.Set("Content-Type", contentType)
+	p.Header.Set("X-Trace-ID", resp.TraceID)
+	setStatusHeaders(p.Header, resp)
+	if locale := r.Locale(); locale != language.Und {
+		p.Header.Set("Content-Language", locale.String())
+	}
+
+	body, err := r.encode(statusCode, resp)
+	if err != nil {
+		return nil, err
+	}
+	p.Body = body.body
+	encoding, eligible := r.contentCoding(contentType, len(p.Body))
+
+	if statusCode >= 200 && statusCode < 300 {
+		r.setCacheHeaders(p.Header, resp)
+		if err := r.applyValidators(p, resp, body, encoding); err != nil {
+			return nil, err
+		}
+	}
+	switch p.StatusCode {
+	case statusCode:
+	case http.StatusNotModified:
+		encoding = compression.Identity // No body, but the same Vary as the 200
+	default:
+		encoding, eligible = r.contentCoding(contentType, len(p.Body))
+	}
+	if err := r.compress(p, encoding, eligible); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// applyValidators sets the ETag and Last-Modified headers of a successful
+// response and evaluates the request's conditions against them. Strong ETags
+// are specific to the content coding the body is sent with; weak ones are not.
+func (r *Responder) applyValidators(p *Prepared, resp StandardResponse, body encoded, encoding compression.Encoding) error {
+	v := resp.validators
+	if v == nil {
+		return nil
+	}
+
+	conditional := v.method == http.MethodGet || v.method == http.MethodHead
+	etag := ""
+	switch {
+	case v.version != "":
+		etag = WeakETag(v.version)
+	case v.weak:
+		hash, err := r.payloadETag(p.StatusCode, resp, body)
+		if err != nil {
+			return err
+		}
+		etag = "W/" + hash
+	case v.strong:
+		etag = compression.ETag(hashETag(body.body), encoding)
+	}
+	if etag != "" {
+		p.Header.Set("ETag", etag)
+	}
+	if !v.lastModified.IsZero() {
+		p.Header.Set("Last-Modified", v.lastModified.UTC().Format(http.TimeFormat))
+	}
+	if !conditional {
+		return nil
+	}
+
+	switch EvaluatePreconditions(v.method, v.headers, etag, v.lastModified) {
+	case http.StatusNotModified:
+		p.StatusCode = http.StatusNotModified
+		p.Header.Del("Content-Type")
+		p.Header.Del("Content-Language")
+		p.Body = nil
+	case http.StatusPreconditionFailed:
+		failed := r.NewAPIErrorResponse(http.StatusPreconditionFailed, resp.TraceID, ErrPreconditionFailed)
+		body, err := r.Encode(http.StatusPreconditionFailed, failed)
+		if err != nil {
+			return err
+		}
+		p.StatusCode, p.Body = http.StatusPreconditionFailed, body
+		for _, key := range []string{"ETag", "Last-Modified", "Cache-Control", "Vary", "Cache-Tag", "Location", "Retry-After"} {
+			p.Header.Del(key)
+		}
+	}
+	return nil
+}
+
+// Write sends the prepared response.
+func (p *Prepared) Write(w http.ResponseWriter) error {
+	for key, values := range p.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(p.StatusCode)
+	if len(p.Body) == 0 {
+		return nil
+	}
+	_, err := w.Write(p.Body)
+	return err
+}
+
+// payloadETag hashes the encoded response without its per-request trace ID
+// and timestamp, so that identical payloads share an ETag. Bodies written by
+// the envelope fast path are hashed directly; others are encoded again
+// without those members.
+func (r *Responder) payloadETag(statusCode int, resp StandardResponse, body encoded) (string, error) {
+	if body.hashable {
+		return body.etag(), nil
+	}
+	resp.TraceID = ""
+	if _, ok := resp.Metadata["timestamp"]; ok {
+		metadata := make(map[string]interface{}, len(resp.Metadata))
+		for k, v := range resp.Metadata {
+			if k != "timestamp" {
+				metadata[k] = v
+			}
+		}
+		resp.Metadata = metadata
+	}
+	stripped, err := r.encode(statusCode, resp)
+	if err != nil {
+		return "", err
+	}
+	return stripped.etag(), nil
+}
+
+// WeakETag returns the weak entity tag for a version.
+func WeakETag(version string) string {
+	return `W/"` + strings.ReplaceAll(version, `"`, "") + `"`
+}
+
+// EvaluatePreconditions applies RFC 9110 precondition evaluation of a request
+// against the current validators of the target resource. It returns 304 or
+// 412 when the request must not proceed, or 0 otherwise.
+func EvaluatePreconditions(method string, headers http.Header, etag string, lastModified time.Time) int {
+	safe := method == http.MethodGet || method == http.MethodHead
+	if ifMatch := headers.Get("If-Match"); ifMatch != "" && !matchETag(ifMatch, etag, true) {
+		return http.StatusPreconditionFailed
+	}
+	if ifNoneMatch := headers.Get("If-None-Match"); ifNoneMatch != "" {
+		if matchETag(ifNoneMatch, etag, false) {
+			if safe {
+				return http.StatusNotModified
+			}
+			return http.StatusPreconditionFailed
+		}
+		return 0
+	}
+	if ims := headers.Get("If-Modified-Since"); ims != "" && safe && !lastModified.IsZero() {
+		since, err := http.ParseTime(ims)
+		if err == nil && !lastModified.Truncate(time.Second).After(since) {
+			return http.StatusNotModified
+		}
+	}
+	return 0
+}
+
+// matchETag reports whether etag matches a list of entity tags. Strong
+// comparison never matches weak tags.
+func matchETag(header, etag string, strong bool) bool {
+	if strings.TrimSpace(header) == "*" {
+		return true
+	}
+	if etag == "" {
+		return false
+	}
+	if strong && strings.HasPrefix(etag, "W/") {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if strong && strings.HasPrefix(candidate, "W/") {
+			continue
+		}
+		if strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
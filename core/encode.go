@@ -0,0 +1,280 @@
+package core
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"sync"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// maxPooledBufferSize caps the capacity of buffers returned to the pools, so
+// a single very large response does not pin its memory for the life of the
+// process.
+const maxPooledBufferSize = 64 << 10
+
+var streamPool = sync.Pool{
+	New: func() interface{} {
+		return jsoniter.NewStream(json, nil, 1024)
+	},
+}
+
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return new(bytes.Buffer)
+	},
+}
+
+func getStream() *jsoniter.Stream {
+	return streamPool.Get().(*jsoniter.Stream)
+}
+
+func putStream(stream *jsoniter.Stream) {
+	if cap(stream.Buffer()) > maxPooledBufferSize {
+		return
+	}
+	stream.Reset(nil)
+	stream.Error = nil
+	stream.Attachment = nil
+	streamPool.Put(stream)
+}
+
+// jsonEncoder is the type of JSONEncoder.
+type jsonEncoder struct{}
+
+// Encode writes v as JSON followed by a newline.
+func (jsonEncoder) Encode(w io.Writer, v interface{}) error {
+	stream := getStream()
+	defer putStream(stream)
+	stream.WriteVal(v)
+	stream.WriteRaw("\n")
+	if stream.Error != nil {
+		return stream.Error
+	}
+	_, err := w.Write(stream.Buffer())
+	return err
+}
+
+// span is a byte range of an encoded body.
+type span struct{ start, end int }
+
+// encoded is an encoded response body. When the envelope fast path wrote it,
+// the spans of its per-request trace ID and timestamp are known, so a payload
+// ETag can be computed without encoding the response again.
+type encoded struct {
+	body     []byte
+	volatile [2]span
+	n        int  // Number of volatile spans
+	hashable bool // volatile lists every per-request member
+}
+
+func (e *encoded) addVolatile(start, end int) {
+	if e.n == len(e.volatile) {
+		e.hashable = false
+		return
+	}
+	e.volatile[e.n] = span{start, end}
+	e.n++
+}
+
+// etag returns the quoted hash of the body without its volatile spans.
+func (e *encoded) etag() string {
+	h := sha256.New()
+	last := 0
+	for _, s := range e.volatile[:e.n] {
+		h.Write(e.body[last:s.start])
+		last = s.end
+	}
+	h.Write(e.body[last:])
+	var sum [sha256.Size]byte
+	return quoteSum(h.Sum(sum[:0]))
+}
+
+// hashETag returns the quoted hash of body.
+func hashETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return quoteSum(sum[:])
+}
+
+// quoteSum formats the first 16 bytes of a hash as an entity tag.
+func quoteSum(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// encode renders and encodes resp. The standard envelope written with the
+// default JSONEncoder takes a fast path that copies pre-encoded static
+// metadata; other shapes, renderers and encoders encode the document into a
+// pooled buffer.
+func (r *Responder) encode(statusCode int, resp StandardResponse) (encoded, error) {
+	resp = r.Render(resp)
+	_, defaultEncoder := r.encoder.(jsonEncoder)
+	if defaultEncoder && r.renderer == nil {
+		if _, shaped := r.envelopeSchema(); !shaped {
+			return encodeEnvelope(resp)
+		}
+	}
+
+	doc := r.Shape(resp)
+	if r.renderer != nil {
+		var err error
+		if doc, err = r.renderer.Render(statusCode, resp); err != nil {
+			return encoded{}, err
+		}
+	}
+	if defaultEncoder {
+		stream := getStream()
+		defer putStream(stream)
+		stream.WriteVal(doc)
+		stream.WriteRaw("\n")
+		if stream.Error != nil {
+			return encoded{}, stream.Error
+		}
+		return encoded{body: copyBytes(stream.Buffer())}, nil
+	}
+
+	buf := bufferPool.Get().(*bytes.Buffer)
+	defer func() {
+		if buf.Cap() <= maxPooledBufferSize {
+			buf.Reset()
+			bufferPool.Put(buf)
+		}
+	}()
+	if err := r.encoder.Encode(buf, doc); err != nil {
+		return encoded{}, err
+	}
+	return encoded{body: copyBytes(buf.Bytes())}, nil
+}
+
+// encodeEnvelope writes the standard envelope field by field, producing the
+// same bytes as encoding the StandardResponse struct.
+func encodeEnvelope(resp StandardResponse) (encoded, error) {
+	stream := getStream()
+	defer putStream(stream)
+	e := encoded{hashable: true}
+
+	stream.WriteRaw(`{"status":`)
+	stream.WriteStringWithHTMLEscaped(resp.Status)
+	stream.WriteRaw(`,"message":`)
+	stream.WriteStringWithHTMLEscaped(resp.Message)
+	if resp.MessageKey != "" {
+		stream.WriteRaw(`,"message_key":`)
+		stream.WriteStringWithHTMLEscaped(resp.MessageKey)
+	}
+	if resp.Data != nil {
+		stream.WriteRaw(`,"data":`)
+		stream.WriteVal(resp.Data)
+	}
+	if resp.Error != "" {
+		stream.WriteRaw(`,"error":`)
+		stream.WriteStringWithHTMLEscaped(resp.Error)
+	}
+	if resp.TraceID != "" {
+		start := stream.Buffered()
+		stream.WriteRaw(`,"trace_id":`)
+		stream.WriteStringWithHTMLEscaped(resp.TraceID)
+		e.addVolatile(start, stream.Buffered())
+	}
+	if len(resp.FieldErrors) > 0 {
+		stream.WriteRaw(`,"field_errors":`)
+		stream.WriteVal(resp.FieldErrors)
+	}
+	if len(resp.Metadata) > 0 {
+		stream.WriteRaw(`,"metadata":`)
+		writeMetadata(stream, resp.Metadata, resp.static, &e)
+	}
+	if len(resp.Links) > 0 {
+		stream.WriteRaw(`,"links":`)
+		stream.WriteVal(resp.Links)
+	}
+	stream.WriteRaw("}\n")
+	if stream.Error != nil {
+		return encoded{}, stream.Error
+	}
+	e.body = copyBytes(stream.Buffer())
+	return e, nil
+}
+
+// writeMetadata writes metadata in sorted key order. When it still holds the
+// static metadata it was built from, unchanged, the static members are copied
+// pre-encoded and only the few per-response entries are encoded.
+func writeMetadata(stream *jsoniter.Stream, metadata map[string]interface{}, static *staticMetadata, e *encoded) {
+	var local [4]string
+	n := 0
+	fast := static != nil && static.members != nil
+	if fast {
+		matched := 0
+		for k, v := range metadata {
+			if value, ok := static.values[k]; ok {
+				if v != value {
+					fast = false
+					break
+				}
+				matched++
+				continue
+			}
+			if n == len(local) || !plainKey(k) {
+				fast = false
+				break
+			}
+			local[n] = k
+			n++
+		}
+		fast = fast && matched == len(static.keys)
+	}
+	if !fast {
+		e.hashable = false
+		stream.WriteVal(metadata)
+		return
+	}
+
+	for i := 1; i < n; i++ {
+		for j := i; j > 0 && local[j] < local[j-1]; j-- {
+			local[j], local[j-1] = local[j-1], local[j]
+		}
+	}
+	stream.WriteRaw("{")
+	i, j := 0, 0
+	for i < len(static.keys) || j < n {
+		if i+j > 0 {
+			stream.WriteRaw(",")
+		}
+		if j == n || (i < len(static.keys) && static.keys[i] < local[j]) {
+			_, _ = stream.Write(static.members[i])
+			i++
+			continue
+		}
+		start := stream.Buffered()
+		stream.WriteRaw(`"`)
+		stream.WriteRaw(local[j])
+		stream.WriteRaw(`":`)
+		stream.WriteVal(metadata[local[j]])
+		if local[j] == "timestamp" {
+			e.addVolatile(start, stream.Buffered())
+		}
+		j++
+	}
+	stream.WriteRaw("}")
+}
+
+// plainKey reports whether key is encoded as itself between quotes.
+func plainKey(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// copyBytes returns a copy of b that does not share pooled memory.
+func copyBytes(b []byte) []byte {
+	out := make([]byte, len(b))
+	copy(out, b)
+	return out
+}
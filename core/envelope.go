@@ -0,0 +1,212 @@
+package core
+
+import (
+	"bytes"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+)
+
+// FieldTransform rewrites the value of an envelope field.
+type FieldTransform func(value interface{}) interface{}
+
+// envelopeTransforms holds the transforms usable from configuration files.
+var envelopeTransforms = map[string]FieldTransform{
+	// success_bool turns a "success"/"error" status into true/false.
+	"success_bool": func(value interface{}) interface{} {
+		return value == "success"
+	},
+	// list wraps a value in an array, as expected by clients reading errors[].
+	"list": func(value interface{}) interface{} {
+		if list, ok := value.([]interface{}); ok {
+			return list
+		}
+		return []interface{}{value}
+	},
+}
+
+// EnvelopeSchema maps StandardResponse onto the JSON shape expected by clients.
+// Fields are identified by their default names: status, message, message_key,
+// data, error, trace_id, field_errors, metadata and links.
+type EnvelopeSchema struct {
+	Naming     config.NamingStrategy     // Spelling of field names that are not renamed
+	Rename     map[string]string         // Default name -> emitted name
+	Omit       []string                  // Default names never emitted
+	Extra      map[string]interface{}    // Static fields appended to every envelope
+	Transforms map[string]FieldTransform // Default name -> value rewrite
+	Links      config.LinkStyle          // Simple "links" map or HAL "_links"
+}
+
+// EnvelopeSchemaFromConfig builds a schema from its configuration form.
+// Unknown transform names are ignored; config validation rejects them.
+func EnvelopeSchemaFromConfig(c config.EnvelopeConfig) EnvelopeSchema {
+	schema := EnvelopeSchema{
+		Naming: c.Naming,
+		Rename: c.Rename,
+		Omit:   c.Omit,
+		Extra:  c.Extra,
+		Links:  c.Links,
+	}
+	if len(c.Transforms) > 0 {
+		schema.Transforms = make(map[string]FieldTransform, len(c.Transforms))
+		for field, name := range c.Transforms {
+			if fn, ok := envelopeTransforms[name]; ok {
+				schema.Transforms[field] = fn
+			}
+		}
+	}
+	return schema
+}
+
+// WithEnvelopeSchema sets the envelope shape, overriding the Envelope section
+// of the configuration.
+func WithEnvelopeSchema(schema EnvelopeSchema) Option {
+	return func(r *Responder) {
+		r.envelope = &schema
+	}
+}
+
+// envelopeField is one key/value pair of a shaped envelope.
+type envelopeField struct {
+	name  string
+	value interface{}
+}
+
+// shapedEnvelope is a JSON object that preserves field order.
+type shapedEnvelope []envelopeField
+
+// MarshalJSON encodes the fields in order.
+func (e shapedEnvelope) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range e {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		name, err := json.Marshal(field.name)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// Shape returns the value to encode for resp: resp itself when the default
+// envelope is in use, or an ordered object following the envelope schema.
+func (r *Responder) Shape(resp StandardResponse) interface{} {
+	schema, ok := r.envelopeSchema()
+	if !ok {
+		return resp
+	}
+	return schema.Apply(resp)
+}
+
+// envelopeSchema returns the schema set on the responder or in configuration.
+func (r *Responder) envelopeSchema() (EnvelopeSchema, bool) {
+	if r.envelope != nil {
+		return *r.envelope, true
+	}
+	envelope := r.config.Snapshot().Envelope
+	if envelope.IsZero() {
+		return EnvelopeSchema{}, false
+	}
+	return EnvelopeSchemaFromConfig(envelope), true
+}
+
+// Apply shapes resp according to the schema. Empty optional fields are left
+// out exactly as in the default envelope.
+func (s EnvelopeSchema) Apply(resp StandardResponse) interface{} {
+	fields := []envelopeField{
+		{"status", resp.Status},
+		{"message", resp.Message},
+	}
+	if resp.MessageKey != "" {
+		fields = append(fields, envelopeField{"message_key", resp.MessageKey})
+	}
+	if resp.Data != nil {
+		fields = append(fields, envelopeField{"data", resp.Data})
+	}
+	if resp.Error != "" {
+		fields = append(fields, envelopeField{"error", resp.Error})
+	}
+	if resp.TraceID != "" {
+		fields = append(fields, envelopeField{"trace_id", resp.TraceID})
+	}
+	if len(resp.FieldErrors) > 0 {
+		fields = append(fields, envelopeField{"field_errors", resp.FieldErrors})
+	}
+	if len(resp.Metadata) > 0 {
+		fields = append(fields, envelopeField{"metadata", resp.Metadata})
+	}
+	hal := s.Links == config.LinkStyleHAL
+	if len(resp.Links) > 0 {
+		if hal {
+			fields = append(fields, envelopeField{"links", resp.Links.HAL()})
+		} else {
+			fields = append(fields, envelopeField{"links", resp.Links})
+		}
+	}
+
+	shaped := make(shapedEnvelope, 0, len(fields)+len(s.Extra))
+	seen := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		if slices.Contains(s.Omit, field.name) {
+			continue
+		}
+		if transform, ok := s.Transforms[field.name]; ok {
+			field.value = transform(field.value)
+		}
+		if renamed, ok := s.Rename[field.name]; ok {
+			field.name = renamed
+		} else if hal && field.name == "links" {
+			field.name = "_links"
+		} else {
+			field.name = applyNaming(s.Naming, field.name)
+		}
+		seen[field.name] = true
+		shaped = append(shaped, field)
+	}
+	for _, name := range sortedKeys(s.Extra) {
+		if !seen[name] {
+			shaped = append(shaped, envelopeField{name, s.Extra[name]})
+		}
+	}
+	return shaped
+}
+
+// applyNaming respells a snake_case name.
+func applyNaming(naming config.NamingStrategy, name string) string {
+	switch naming {
+	case config.NamingCamel:
+		parts := strings.Split(name, "_")
+		for i := 1; i < len(parts); i++ {
+			if parts[i] != "" {
+				parts[i] = strings.ToUpper(parts[i][:1]) + parts[i][1:]
+			}
+		}
+		return strings.Join(parts, "")
+	case config.NamingKebab:
+		return strings.ReplaceAll(name, "_", "-")
+	default:
+		return name
+	}
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -72,3 +72,23 @@ func sanitizeError(details string) string {
 	}
 	return details
 }
+
+// CategoryForStatus maps an HTTP status code to the closest error category.
+func CategoryForStatus(statusCode int) ErrorCategory {
+	switch {
+	case statusCode == 401:
+		return AuthenticationError
+	case statusCode == 403:
+		return AuthorizationError
+	case statusCode == 422:
+		return ValidationError
+	case statusCode == 429:
+		return RateLimitError
+	case statusCode == 502 || statusCode == 503 || statusCode == 504:
+		return ExternalServiceError
+	case statusCode >= 500:
+		return ServerError
+	default:
+		return ClientError
+	}
+}
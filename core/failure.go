@@ -0,0 +1,60 @@
+package core
+
+import (
+	"net/http"
+
+	"go.uber.org/zap"
+)
+
+// ErrEncodingFailed is the error sent when a response cannot be encoded.
+var ErrEncodingFailed = NewAPIError(ServerError, http.StatusInternalServerError,
+	"Internal Server Error", "The response could not be encoded.")
+
+// EncodingError reports a response that could not be encoded. A ServerError
+// envelope with the same trace ID was prepared in its place.
+type EncodingError struct {
+	StatusCode int    // Status of the response that failed to encode
+	TraceID    string // Trace ID of that response
+	Err        error
+}
+
+func (e *EncodingError) Error() string {
+	return "encode response: " + e.Err.Error()
+}
+
+func (e *EncodingError) Unwrap() error {
+	return e.Err
+}
+
+// prepareFailure logs and counts an encoding failure and prepares the
+// ServerError envelope sent instead. The envelope is encoded by the
+// responder's renderer and encoder when they can, and as plain JSON when
+// they are what failed.
+func (r *Responder) prepareFailure(statusCode int, traceID string, cause error) *Prepared {
+	r.Logger().Error("Failed to encode response",
+		zap.String("trace_id", traceID),
+		zap.Int("status_code", statusCode),
+		zap.Error(cause),
+	)
+	if r.metrics != nil {
+		r.metrics.ObserveEncodingError()
+	}
+
+	failed := r.NewAPIErrorResponse(http.StatusInternalServerError, traceID, ErrEncodingFailed)
+	p := &Prepared{StatusCode: http.StatusInternalServerError, Header: make(http.Header)}
+	p.Header.Set("Content-Type", r.ContentType())
+	if body, err := r.encode(http.StatusInternalServerError, failed); err == nil {
+		p.Body = body.body
+	} else if body, err := encodeEnvelope(failed); err == nil {
+		p.Header.Set("Content-Type", "application/json")
+		p.Body = body.body
+	} else {
+		failed.Metadata = nil
+		body, _ := json.Marshal(failed)
+		p.Header.Set("Content-Type", "application/json")
+		p.Body = append(body, '\n')
+	}
+	p.Header.Set("X-Trace-ID", traceID)
+	p.Header.Set("Cache-Control", "no-store")
+	return p
+}
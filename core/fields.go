@@ -0,0 +1,399 @@
+package core
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// FieldSelector projects response data onto a set of field paths, as used by
+// sparse fieldsets such as "?fields=id,name,owner.email". Paths prefixed with
+// "-" are excluded instead: "?fields=-owner.password". When a level has
+// included fields only those are kept; otherwise everything but the excluded
+// fields is kept.
+type FieldSelector struct {
+	root *fieldNode
+}
+
+// fieldNode is one level of a field selector tree.
+type fieldNode struct {
+	children map[string]*fieldNode
+	whole    bool // The path was included as a whole
+	exclude  bool // The path was excluded
+	path     string
+}
+
+// UnknownFieldsError reports selected fields that do not exist in the data.
+type UnknownFieldsError struct {
+	Fields []string
+}
+
+func (e *UnknownFieldsError) Error() string {
+	return "unknown fields: " + strings.Join(e.Fields, ", ")
+}
+
+// ParseFieldSelector parses a comma-separated list of dotted field paths.
+// An empty spec yields a selector that keeps everything.
+func ParseFieldSelector(spec string) (FieldSelector, error) {
+	root := &fieldNode{}
+	for _, raw := range strings.Split(spec, ",") {
+		path := strings.TrimSpace(raw)
+		if path == "" {
+			continue
+		}
+		exclude := strings.HasPrefix(path, "-")
+		path = strings.TrimPrefix(path, "-")
+
+		node := root
+		for _, name := range strings.Split(path, ".") {
+			if name == "" {
+				return FieldSelector{}, fmt.Errorf("invalid field path %q", raw)
+			}
+			child, ok := node.children[name]
+			if !ok {
+				if node.children == nil {
+					node.children = make(map[string]*fieldNode)
+				}
+				child = &fieldNode{path: joinPath(node.path, name)}
+				node.children[name] = child
+			}
+			node = child
+		}
+		if exclude {
+			node.exclude = true
+		} else {
+			node.whole = true
+		}
+	}
+	if len(root.children) == 0 {
+		return FieldSelector{}, nil
+	}
+	return FieldSelector{root: root}, nil
+}
+
+// IsZero reports whether the selector keeps everything.
+func (s FieldSelector) IsZero() bool {
+	return s.root == nil
+}
+
+// Project returns data reduced to the selected fields. Structs are read
+// through their JSON tags and become maps; slices are projected element by
+// element. Selected paths that match no field return an *UnknownFieldsError.
+func (s FieldSelector) Project(data interface{}) (interface{}, error) {
+	if s.root == nil {
+		return data, nil
+	}
+	p := projector{seen: make(map[string]bool)}
+	out := p.value(reflect.ValueOf(data), s.root)
+
+	var unknown []string
+	s.root.walk(func(n *fieldNode) {
+		if !p.seen[n.path] {
+			unknown = append(unknown, n.path)
+		}
+	})
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, &UnknownFieldsError{Fields: unknown}
+	}
+	return out, nil
+}
+
+// walk calls fn for every selected path below n.
+func (n *fieldNode) walk(fn func(*fieldNode)) {
+	for _, child := range n.children {
+		fn(child)
+		child.walk(fn)
+	}
+}
+
+// includes reports whether n or a descendant is explicitly included.
+func (n *fieldNode) includes() bool {
+	if n.whole {
+		return true
+	}
+	for _, child := range n.children {
+		if child.includes() {
+			return true
+		}
+	}
+	return false
+}
+
+// projector applies a selector tree, recording the paths found in the data.
+type projector struct {
+	seen map[string]bool
+}
+
+func (p *projector) value(v reflect.Value, node *fieldNode) interface{} {
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			p.markType(v.Type(), node)
+			return nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		p.markAll(node)
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		return p.object(node, structFields(v))
+	case reflect.Map:
+		if v.Type().Key().Kind() != reflect.String {
+			return v.Interface()
+		}
+		if v.IsNil() {
+			p.markAll(node)
+			return nil
+		}
+		fields := make([]objectField, 0, v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			fields = append(fields, objectField{name: iter.Key().String(), value: iter.Value()})
+		}
+		return p.object(node, fields)
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+			return v.Interface()
+		}
+		if v.Len() == 0 {
+			p.markType(v.Type().Elem(), node)
+			if v.Kind() == reflect.Slice && v.IsNil() {
+				return nil
+			}
+			return []interface{}{}
+		}
+		out := make([]interface{}, v.Len())
+		for i := range out {
+			out[i] = p.value(v.Index(i), node)
+		}
+		return out
+	default:
+		return v.Interface()
+	}
+}
+
+// objectField is a named member of a struct or map.
+type objectField struct {
+	name      string
+	value     reflect.Value
+	omitEmpty bool
+}
+
+// object keeps the fields of a struct or map selected by node.
+func (p *projector) object(node *fieldNode, fields []objectField) map[string]interface{} {
+	includeMode := !node.whole && node.includes()
+	out := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		child := node.children[field.name]
+		if child != nil {
+			p.seen[child.path] = true
+		}
+		if (child != nil && child.exclude) ||
+			(includeMode && (child == nil || !child.includes())) ||
+			(field.omitEmpty && isEmptyValue(field.value)) {
+			if child != nil {
+				p.markType(field.value.Type(), child)
+			}
+			continue
+		}
+		if child == nil || len(child.children) == 0 {
+			out[field.name] = field.value.Interface()
+		} else {
+			out[field.name] = p.value(field.value, child)
+		}
+	}
+	return out
+}
+
+// markType records the paths below node that exist in type t, for values
+// such as nil pointers and empty slices that carry no data to inspect.
+func (p *projector) markType(t reflect.Type, node *fieldNode) {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		p.markAll(node)
+		return
+	}
+	names := make(map[string]reflect.Type)
+	collectFieldTypes(t, names)
+	for name, child := range node.children {
+		if ft, ok := names[name]; ok {
+			p.seen[child.path] = true
+			p.markType(ft, child)
+		}
+	}
+}
+
+// markAll records every path below node, which cannot be validated.
+func (p *projector) markAll(node *fieldNode) {
+	node.walk(func(n *fieldNode) { p.seen[n.path] = true })
+}
+
+// structFields lists the JSON-visible fields of a struct, following embedded
+// structs the way encoding/json does.
+func structFields(v reflect.Value) []objectField {
+	var fields []objectField
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, omitEmpty, ok := jsonFieldName(sf)
+		if !ok {
+			continue
+		}
+		fv := v.Field(i)
+		if name == "" {
+			if fv.Kind() == reflect.Pointer {
+				if fv.IsNil() {
+					continue
+				}
+				fv = fv.Elem()
+			}
+			fields = append(fields, structFields(fv)...)
+			continue
+		}
+		fields = append(fields, objectField{name: name, value: fv, omitEmpty: omitEmpty})
+	}
+	return fields
+}
+
+// collectFieldTypes maps the JSON names of t's fields to their types.
+func collectFieldTypes(t reflect.Type, names map[string]reflect.Type) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		name, _, ok := jsonFieldName(sf)
+		if !ok {
+			continue
+		}
+		if name == "" {
+			ft := sf.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			collectFieldTypes(ft, names)
+			continue
+		}
+		names[name] = sf.Type
+	}
+}
+
+// jsonFieldName returns the JSON name of a struct field, or an empty name for
+// embedded structs whose fields are promoted.
+func jsonFieldName(sf reflect.StructField) (name string, omitEmpty, ok bool) {
+	tag := sf.Tag.Get("json")
+	if tag == "-" {
+		return "", false, false
+	}
+	parts := strings.Split(tag, ",")
+	name = parts[0]
+	for _, opt := range parts[1:] {
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	if name == "" && sf.Anonymous {
+		ft := sf.Type
+		if ft.Kind() == reflect.Pointer {
+			ft = ft.Elem()
+		}
+		if ft.Kind() == reflect.Struct {
+			return "", false, true
+		}
+	}
+	if !sf.IsExported() {
+		return "", false, false
+	}
+	if name == "" {
+		name = sf.Name
+	}
+	return name, omitEmpty, true
+}
+
+// isEmptyValue mirrors the omitempty rules of encoding/json.
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+func joinPath(parent, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+// WithFieldSelection lets clients of every adapter success response select
+// the fields of its data, e.g. with "?fields=id,name". It is off by default,
+// as endpoints may already use a fields parameter of their own.
+func WithFieldSelection() Option {
+	return func(r *Responder) {
+		r.fieldSelection = true
+	}
+}
+
+// WithSelectableFields lets clients select the fields of the response's
+// data, for responders without WithFieldSelection.
+func WithSelectableFields() ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.selectable = true
+	}
+}
+
+// FieldSelectionEnabled reports whether adapters project the data of resp
+// onto the fields requested by the client.
+func (r *Responder) FieldSelectionEnabled(resp StandardResponse) bool {
+	return r.fieldSelection || resp.selectable
+}
+
+// SelectFields projects the data of a success response onto the fields named
+// by spec. Responses rendered by a custom Renderer are returned unchanged, as
+// formats such as JSON:API define their own sparse fieldsets.
+func (r *Responder) SelectFields(resp StandardResponse, spec string) (StandardResponse, error) {
+	if spec == "" || resp.Status != "success" || r.renderer != nil {
+		return resp, nil
+	}
+	selector, err := ParseFieldSelector(spec)
+	if err != nil {
+		return resp, err
+	}
+	data, err := selector.Project(resp.Data)
+	if err != nil {
+		return resp, err
+	}
+	resp.Data = data
+	return resp, nil
+}
+
+// NewFieldSelectionErrorResponse creates the validation error response sent
+// when a field selection cannot be applied.
+func (r *Responder) NewFieldSelectionErrorResponse(traceID string, err error) StandardResponse {
+	var details interface{} = err.Error()
+	if unknown, ok := err.(*UnknownFieldsError); ok {
+		messages := make([]string, len(unknown.Fields))
+		for i, field := range unknown.Fields {
+			messages[i] = fmt.Sprintf("unknown field %q", field)
+		}
+		details = messages
+	}
+	return r.NewValidationErrorResponse(traceID, "Invalid field selection", map[string]interface{}{
+		"fields": details,
+	})
+}
@@ -0,0 +1,172 @@
+package core
+
+import (
+	"fmt"
+
+	jsoniter "github.com/json-iterator/go"
+)
+
+// Link is a hypermedia link to a related resource or action.
+type Link struct {
+	Href      string `json:"href"`
+	Templated bool   `json:"templated,omitempty"` // Href is an RFC 6570 URI template
+	Method    string `json:"method,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Type      string `json:"type,omitempty"`
+}
+
+// Links maps relation names such as "self" or "cancel" to links. An empty
+// Links section is left out of the response.
+type Links map[string]Link
+
+// MarshalJSON emits the simple form, mapping each relation to its href.
+func (l Links) MarshalJSON() ([]byte, error) {
+	simple := make(map[string]string, len(l))
+	for rel, link := range l {
+		simple[rel] = link.Href
+	}
+	return json.Marshal(simple)
+}
+
+// UnmarshalJSON accepts both the simple form and HAL link objects.
+func (l *Links) UnmarshalJSON(data []byte) error {
+	var raw map[string]jsoniter.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	links := make(Links, len(raw))
+	for rel, value := range raw {
+		var link Link
+		if err := json.Unmarshal(value, &link.Href); err != nil {
+			if err := json.Unmarshal(value, &link); err != nil {
+				return fmt.Errorf("link %q: %w", rel, err)
+			}
+		}
+		links[rel] = link
+	}
+	*l = links
+	return nil
+}
+
+// HAL returns the links as HAL link objects.
+func (l Links) HAL() map[string]Link {
+	return map[string]Link(l)
+}
+
+// ResponseOption adjusts a response built by an adapter helper.
+type ResponseOption func(*StandardResponse)
+
+// WithLinks adds links to a response, replacing links with the same relation.
+func WithLinks(links Links) ResponseOption {
+	return func(resp *StandardResponse) {
+		if len(links) == 0 {
+			return
+		}
+		if resp.Links == nil {
+			resp.Links = make(Links, len(links))
+		}
+		for rel, link := range links {
+			resp.Links[rel] = link
+		}
+	}
+}
+
+// RouteResolver looks up the URI template of a named route.
+type RouteResolver interface {
+	RouteTemplate(name string) (string, bool)
+}
+
+// RouteTemplates is a RouteResolver mapping route names to RFC 6570 templates.
+type RouteTemplates map[string]string
+
+// RouteTemplate implements RouteResolver.
+func (t RouteTemplates) RouteTemplate(name string) (string, bool) {
+	template, ok := t[name]
+	return template, ok
+}
+
+// LinkOption sets optional attributes of a link.
+type LinkOption func(*Link)
+
+// LinkMethod sets the HTTP method used to follow a link.
+func LinkMethod(method string) LinkOption {
+	return func(l *Link) { l.Method = method }
+}
+
+// LinkTitle sets a human-readable title for a link.
+func LinkTitle(title string) LinkOption {
+	return func(l *Link) { l.Title = title }
+}
+
+// LinkType sets the media type of the link target.
+func LinkType(mediaType string) LinkOption {
+	return func(l *Link) { l.Type = mediaType }
+}
+
+// LinkBuilder assembles a Links section, resolving templates of named routes.
+// The first error is kept and reported by Build.
+type LinkBuilder struct {
+	routes RouteResolver
+	links  Links
+	err    error
+}
+
+// NewLinkBuilder creates a builder resolving route names through routes,
+// which may be nil when only literal hrefs and templates are used.
+func NewLinkBuilder(routes RouteResolver) *LinkBuilder {
+	return &LinkBuilder{routes: routes}
+}
+
+// Href adds a link to a literal URI.
+func (b *LinkBuilder) Href(rel, href string, opts ...LinkOption) *LinkBuilder {
+	return b.add(rel, Link{Href: href}, opts)
+}
+
+// Template adds a link from an RFC 6570 URI template. Expressions whose
+// variables are all found in params are expanded; the others are kept and the
+// link is marked as templated.
+func (b *LinkBuilder) Template(rel, template string, params Params, opts ...LinkOption) *LinkBuilder {
+	href, templated, err := expandURITemplate(template, params, true)
+	if err != nil {
+		return b.fail(fmt.Errorf("link %q: %w", rel, err))
+	}
+	return b.add(rel, Link{Href: href, Templated: templated}, opts)
+}
+
+// Route adds a link to a named route, expanding its template with params.
+func (b *LinkBuilder) Route(rel, name string, params Params, opts ...LinkOption) *LinkBuilder {
+	if b.routes == nil {
+		return b.fail(fmt.Errorf("link %q: no route resolver", rel))
+	}
+	template, ok := b.routes.RouteTemplate(name)
+	if !ok {
+		return b.fail(fmt.Errorf("link %q: unknown route %q", rel, name))
+	}
+	return b.Template(rel, template, params, opts...)
+}
+
+// Build returns the links, or nil when none were added.
+func (b *LinkBuilder) Build() (Links, error) {
+	if b.err != nil {
+		return nil, b.err
+	}
+	return b.links, nil
+}
+
+func (b *LinkBuilder) add(rel string, link Link, opts []LinkOption) *LinkBuilder {
+	for _, opt := range opts {
+		opt(&link)
+	}
+	if b.links == nil {
+		b.links = make(Links)
+	}
+	b.links[rel] = link
+	return b
+}
+
+func (b *LinkBuilder) fail(err error) *LinkBuilder {
+	if b.err == nil {
+		b.err = err
+	}
+	return b
+}
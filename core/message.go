@@ -0,0 +1,112 @@
+package core
+
+import (
+	"github.com/andreascandle/FlexiResponseGo/i18n"
+	"golang.org/x/text/language"
+)
+
+// Params holds named values substituted into catalog messages.
+type Params map[string]interface{}
+
+// Message is a catalog key with parameters. It is rendered when the response
+// is written, once the locale of the request is known.
+type Message struct {
+	Key      string
+	Params   Params
+	Fallback string // Pattern used when no catalog defines Key
+}
+
+// NewMessage creates a Message for key with optional parameters.
+func NewMessage(key string, params Params) Message {
+	return Message{Key: key, Params: params}
+}
+
+// WithFallback sets the pattern rendered when no catalog defines the key.
+func (m Message) WithFallback(pattern string) Message {
+	m.Fallback = pattern
+	return m
+}
+
+// NewSuccessResponseWithKey creates a success response whose message is rendered from a catalog key.
+func NewSuccessResponseWithKey(traceID string, msg Message, data interface{}) StandardResponse {
+	return Default().NewSuccessResponseWithKey(traceID, msg, data)
+}
+
+// NewErrorResponseWithKey creates an error response whose message is rendered from a catalog key.
+func NewErrorResponseWithKey(traceID string, msg Message, errorDetail string) StandardResponse {
+	return Default().NewErrorResponseWithKey(traceID, msg, errorDetail)
+}
+
+// NewValidationErrorResponseWithKey creates a validation error response whose
+// message is rendered from a catalog key. Field errors may also hold Messages.
+func NewValidationErrorResponseWithKey(traceID string, msg Message, fieldErrors map[string]interface{}) StandardResponse {
+	return Default().NewValidationErrorResponseWithKey(traceID, msg, fieldErrors)
+}
+
+// NewSuccessResponseWithKey creates a success response whose message is rendered from a catalog key.
+func (r *Responder) NewSuccessResponseWithKey(traceID string, msg Message, data interface{}) StandardResponse {
+	return r.withMessage(r.NewSuccessResponse(traceID, "", data), msg)
+}
+
+// NewErrorResponseWithKey creates an error response whose message is rendered from a catalog key.
+func (r *Responder) NewErrorResponseWithKey(traceID string, msg Message, errorDetail string) StandardResponse {
+	return r.withMessage(r.NewErrorResponse(traceID, "", errorDetail), msg)
+}
+
+// NewValidationErrorResponseWithKey creates a validation error response whose
+// message is rendered from a catalog key. Field errors may also hold Messages.
+func (r *Responder) NewValidationErrorResponseWithKey(traceID string, msg Message, fieldErrors map[string]interface{}) StandardResponse {
+	return r.withMessage(r.NewValidationErrorResponse(traceID, "", fieldErrors), msg)
+}
+
+// withMessage attaches msg to resp and renders it with the responder's locale.
+func (r *Responder) withMessage(resp StandardResponse, msg Message) StandardResponse {
+	resp.pending = &msg
+	return r.Render(resp)
+}
+
+// Render renders a response's keyed message and Message field errors in the
+// responder's locale. It is applied again when the response is written, so a
+// response built before the locale was negotiated is still localized. The
+// message key is kept in the envelope when the includeMessageKey setting is true.
+func (r *Responder) Render(resp StandardResponse) StandardResponse {
+	if resp.pending != nil {
+		resp.Message = r.renderMessage(*resp.pending)
+		resp.MessageKey = ""
+		if include, _ := r.config.Snapshot().Setting("includeMessageKey"); include == true {
+			resp.MessageKey = resp.pending.Key
+		}
+	}
+	if resp.FieldErrors != nil {
+		for _, value := range resp.FieldErrors {
+			if _, ok := value.(Message); ok {
+				resp.FieldErrors = r.localizeFieldErrors(resp.FieldErrors)
+				break
+			}
+		}
+	}
+	return resp
+}
+
+// renderMessage looks msg up in the catalogs, falling back to its pattern and
+// finally to the bare key.
+func (r *Responder) renderMessage(msg Message) string {
+	if r.localizationEnabled() {
+		if text, ok := r.localizer.Localize(r.Locale(), msg.Key, msg.Params); ok {
+			return text
+		}
+	}
+	pattern := msg.Fallback
+	if pattern == "" {
+		pattern = msg.Key
+	}
+	locale := r.Locale()
+	if locale == language.Und {
+		locale = language.English
+	}
+	text, err := i18n.Format(locale, pattern, msg.Params)
+	if err != nil {
+		return pattern
+	}
+	return text
+}
@@ -0,0 +1,210 @@
+package core
+
+import (
+	"container/list"
+	"reflect"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+)
+
+// responderCaches holds state derived from a responder's configuration. It is
+// shared by the scoped copies of a responder.
+type responderCaches struct {
+	timestamp atomic.Pointer[cachedTimestamp]
+	metadata  metadataCache
+}
+
+// metadataCacheSize bounds the number of responder scopes whose static
+// metadata is cached. Routes are raw request paths, so the scopes are not
+// bounded by the application.
+const metadataCacheSize = 1024
+
+// metadataCache is a bounded LRU cache of static metadata by scope.
+type metadataCache struct {
+	mu      sync.Mutex
+	entries map[metadataKey]*list.Element // Values are *staticMetadata
+	order   *list.List
+}
+
+func (c *metadataCache) load(key metadataKey) (*staticMetadata, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(el)
+	return el.Value.(*staticMetadata), true
+}
+
+func (c *metadataCache) store(static *staticMetadata) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.entries == nil {
+		c.entries = make(map[metadataKey]*list.Element)
+		c.order = list.New()
+	}
+	if el, ok := c.entries[static.key]; ok {
+		el.Value = static
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[static.key] = c.order.PushFront(static)
+	for c.order.Len() > metadataCacheSize {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*staticMetadata).key)
+	}
+}
+
+// cachedTimestamp is the formatted timestamp of one second.
+type cachedTimestamp struct {
+	unix     int64
+	location *time.Location
+	value    interface{} // RFC 3339 string, boxed once
+}
+
+// timestamp returns the RFC 3339 timestamp of the current second. The
+// formatted value is reused for every response within the same second.
+func (r *Responder) timestamp() interface{} {
+	now := r.Now()
+	if r.caches == nil {
+		return now.Format(time.RFC3339)
+	}
+	unix, location := now.Unix(), now.Location()
+	if cached := r.caches.timestamp.Load(); cached != nil && cached.unix == unix && cached.location == location {
+		return cached.value
+	}
+	cached := &cachedTimestamp{unix: unix, location: location, value: now.Format(time.RFC3339)}
+	r.caches.timestamp.Store(cached)
+	return cached.value
+}
+
+// metadataKey identifies the static metadata of a responder scope.
+type metadataKey struct {
+	tenantID string
+	route    string
+	isError  bool
+}
+
+// staticMetadata is the visible global and tenant metadata of a responder
+// scope, with each member pre-encoded. It is rebuilt whenever the
+// configuration version or the tenant overlay changes.
+type staticMetadata struct {
+	key     metadataKey
+	config  *config.Config
+	version uint64
+	overlay *config.Overlay
+
+	values  map[string]interface{}
+	keys    []string // Sorted keys of values
+	members [][]byte // Encoded "key":value of each key; nil unless every value is a scalar
+}
+
+// metadataField is a per-response metadata entry such as the timestamp.
+type metadataField struct {
+	key   string
+	value interface{}
+}
+
+// newMetadata returns the metadata of a new response: the static metadata of
+// the responder's scope overlaid on the timestamp and fields. Keys hidden by
+// the metadata policy for the responder's route are left out.
+func (r *Responder) newMetadata(isError bool, fields ...metadataField) (map[string]interface{}, *staticMetadata) {
+	conf := r.config.Snapshot()
+	static := r.staticMetadata(conf, isError)
+	metadata := make(map[string]interface{}, len(static.values)+len(fields)+1)
+	debug := conf.Debug()
+	if conf.MetadataPolicy.Visible(r.route, "timestamp", isError, debug) {
+		metadata["timestamp"] = r.timestamp()
+	}
+	for _, field := range fields {
+		if conf.MetadataPolicy.Visible(r.route, field.key, isError, debug) {
+			metadata[field.key] = field.value
+		}
+	}
+	for k, v := range static.values {
+		metadata[k] = v
+	}
+	return metadata, static
+}
+
+// staticMetadata returns the cached static metadata of the responder's scope,
+// rebuilding it after configuration or tenant changes.
+func (r *Responder) staticMetadata(conf config.Snapshot, isError bool) *staticMetadata {
+	overlay := r.tenantOverlay()
+	key := metadataKey{route: r.route, isError: isError}
+	if overlay != nil {
+		// Unknown tenants share the scope without an overlay.
+		key.tenantID = r.tenantID
+	}
+	if r.caches != nil {
+		if static, ok := r.caches.metadata.load(key); ok {
+			if static.config == r.config && static.version == conf.Version && static.overlay == overlay {
+				return static
+			}
+		}
+	}
+
+	static := &staticMetadata{key: key, config: r.config, version: conf.Version, overlay: overlay, values: make(map[string]interface{})}
+	debug := conf.Debug()
+	add := func(values map[string]interface{}) {
+		for k, v := range values {
+			if conf.MetadataPolicy.Visible(r.route, k, isError, debug) {
+				static.values[k] = v
+			}
+		}
+	}
+	add(conf.GlobalMetadata)
+	if overlay != nil {
+		add(overlay.GlobalMetadata)
+	}
+	static.keys = make([]string, 0, len(static.values))
+	for k := range static.values {
+		static.keys = append(static.keys, k)
+	}
+	sort.Strings(static.keys)
+	static.members = encodeMembers(static.keys, static.values)
+
+	if r.caches != nil {
+		r.caches.metadata.store(static)
+	}
+	return static
+}
+
+// encodeMembers encodes each key/value pair exactly as it appears in an
+// encoded map, or returns nil when a value is not a scalar and so cannot be
+// compared cheaply when checking a response's metadata is unchanged.
+func encodeMembers(keys []string, values map[string]interface{}) [][]byte {
+	members := make([][]byte, len(keys))
+	for i, k := range keys {
+		if !isScalar(values[k]) {
+			return nil
+		}
+		encoded, err := json.Marshal(map[string]interface{}{k: values[k]})
+		if err != nil {
+			return nil
+		}
+		members[i] = encoded[1 : len(encoded)-1]
+	}
+	return members
+}
+
+// isScalar reports whether v is nil or a boolean, number or string.
+func isScalar(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	switch reflect.TypeOf(v).Kind() {
+	case reflect.Bool, reflect.String,
+		reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
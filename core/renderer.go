@@ -0,0 +1,135 @@
+package core
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+)
+
+// Renderer converts a response into an alternative document format, such as
+// JSON:API. The document it returns is serialized by the responder's encoder.
+type Renderer interface {
+	// ContentType is the media type of rendered documents. It is also the
+	// media type matched against Accept headers.
+	ContentType() string
+	// Render builds the document for resp written with statusCode.
+	Render(statusCode int, resp StandardResponse) (interface{}, error)
+}
+
+// WithRenderer registers a renderer chosen when a request's Accept header
+// prefers its content type over application/json.
+func WithRenderer(renderer Renderer) Option {
+	return func(r *Responder) {
+		r.renderers = append(r.renderers, renderer)
+	}
+}
+
+// WithRouteRenderer always uses renderer on routes matching pattern.
+// A trailing "*" matches a path prefix.
+func WithRouteRenderer(pattern string, renderer Renderer) Option {
+	return func(r *Responder) {
+		if r.routeRenderers == nil {
+			r.routeRenderers = make(map[string]Renderer)
+		}
+		r.routeRenderers[pattern] = renderer
+	}
+}
+
+// ForRenderer returns a copy of the responder writing documents with renderer.
+// A nil renderer selects the standard envelope.
+func (r *Responder) ForRenderer(renderer Renderer) *Responder {
+	if renderer == r.renderer {
+		return r
+	}
+	scoped := *r
+	scoped.renderer = renderer
+	return &scoped
+}
+
+// ContentType returns the media type of the documents the responder writes.
+func (r *Responder) ContentType() string {
+	if r.renderer != nil {
+		return r.renderer.ContentType()
+	}
+	return "application/json"
+}
+
+// Encode renders and serializes resp as written with statusCode.
+func (r *Responder) Encode(statusCode int, resp StandardResponse) ([]byte, error) {
+	encoded, err := r.encode(statusCode, resp)
+	return encoded.body, err
+}
+
+// routeRenderer returns the renderer configured for route, preferring the
+// longest matching pattern.
+func (r *Responder) routeRenderer(route string) Renderer {
+	var best Renderer
+	bestLen := -1
+	for pattern, renderer := range r.routeRenderers {
+		if config.RouteMatches(pattern, route) && len(pattern) > bestLen {
+			best, bestLen = renderer, len(pattern)
+		}
+	}
+	return best
+}
+
+// negotiateRenderer picks a registered renderer whose content type the Accept
+// header prefers to JSON, or nil for the standard envelope.
+func (r *Responder) negotiateRenderer(headers http.Header) Renderer {
+	if len(r.renderers) == 0 {
+		return nil
+	}
+	accept := headers.Get("Accept")
+	if accept == "" {
+		return nil
+	}
+	for _, mediaType := range parseAccept(accept) {
+		for _, renderer := range r.renderers {
+			if strings.EqualFold(renderer.ContentType(), mediaType) {
+				return renderer
+			}
+		}
+		if mediaType == "application/json" || mediaType == "*/*" || mediaType == "application/*" {
+			return nil
+		}
+	}
+	return nil
+}
+
+// parseAccept returns the acceptable media types of an Accept header, most
+// preferred first. Types with q=0 are dropped.
+func parseAccept(accept string) []string {
+	type candidate struct {
+		mediaType string
+		q         float64
+	}
+	var candidates []candidate
+	for _, part := range strings.Split(accept, ",") {
+		params := strings.Split(part, ";")
+		mediaType := strings.ToLower(strings.TrimSpace(params[0]))
+		if mediaType == "" {
+			continue
+		}
+		q := 1.0
+		for _, param := range params[1:] {
+			if value, ok := strings.CutPrefix(strings.TrimSpace(param), "q="); ok {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{mediaType, q})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+
+	types := make([]string, len(candidates))
+	for i, c := range candidates {
+		types[i] = c.mediaType
+	}
+	return types
+}
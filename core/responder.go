@@ -0,0 +1,370 @@
+package core
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/compression"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/i18n"
+	"github.com/andreascandle/FlexiResponseGo/logger"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+	"github.com/andreascandle/FlexiResponseGo/utils"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/text/language"
+)
+
+// Encoder serializes response envelopes onto a writer.
+type Encoder interface {
+	Encode(w io.Writer, v interface{}) error
+}
+
+// EncoderFunc adapts an ordinary function to the Encoder interface.
+type EncoderFunc func(w io.Writer, v interface{}) error
+
+// Encode calls f(w, v).
+func (f EncoderFunc) Encode(w io.Writer, v interface{}) error {
+	return f(w, v)
+}
+
+// JSONEncoder is the default encoder backed by json-iterator. Responders
+// using it encode the standard envelope through a pooled, allocation-lean
+// fast path.
+var JSONEncoder Encoder = jsonEncoder{}
+
+// Responder builds and writes standardized responses using its own
+// configuration, logger, encoder and observability hooks.
+type Responder struct {
+	config  *config.Config
+	logger  *logger.Logger
+	encoder Encoder
+	metrics *observability.Metrics
+	tracer  trace.Tracer
+	clock   func() time.Time
+	newID   func() string
+
+	tenants        *config.TenantStore
+	tenantResolver config.TenantResolver
+	tenantID       string
+	route          string
+
+	localizer *i18n.Bundle
+	locale    language.Tag
+
+	envelope *EnvelopeSchema
+
+	renderers      []Renderer
+	routeRenderers map[string]Renderer
+	renderer       Renderer
+
+	compression *compression.Config
+	encoding    compression.Encoding
+
+	caches *responderCaches
+
+	trustedProxies utils.TrustedProxies
+	fieldSelection bool
+
+	unfollow func() // Removes the configuration subscription of followConfig
+}
+
+// Option configures a Responder.
+type Option func(*Responder)
+
+// WithConfig sets the configuration used for metadata and localization.
+func WithConfig(c *config.Config) Option {
+	return func(r *Responder) {
+		r.config = c
+	}
+}
+
+// WithLogger sets the logger used by adapter bindings.
+func WithLogger(l *logger.Logger) Option {
+	return func(r *Responder) {
+		r.logger = l
+	}
+}
+
+// WithEncoder sets the encoder used by WriteJSON.
+func WithEncoder(e Encoder) Option {
+	return func(r *Responder) {
+		r.encoder = e
+	}
+}
+
+// WithMetrics sets the collectors adapter bindings report responses to.
+func WithMetrics(m *observability.Metrics) Option {
+	return func(r *Responder) {
+		r.metrics = m
+	}
+}
+
+// WithTracer sets the tracer used for response spans.
+func WithTracer(t trace.Tracer) Option {
+	return func(r *Responder) {
+		r.tracer = t
+	}
+}
+
+// WithClock sets the time source used for response timestamps.
+func WithClock(clock func() time.Time) Option {
+	return func(r *Responder) {
+		r.clock = clock
+	}
+}
+
+// WithIDGenerator sets the function generating trace IDs.
+func WithIDGenerator(gen func() string) Option {
+	return func(r *Responder) {
+		r.newID = gen
+	}
+}
+
+// WithTenantStore sets the store providing per-tenant metadata overlays.
+func WithTenantStore(s *config.TenantStore) Option {
+	return func(r *Responder) {
+		r.tenants = s
+	}
+}
+
+// WithTenantResolver sets how adapters identify the tenant of a request.
+func WithTenantResolver(resolver config.TenantResolver) Option {
+	return func(r *Responder) {
+		r.tenantResolver = resolver
+	}
+}
+
+// WithLocalizer sets the message catalogs used to localize response messages.
+// Adapters negotiate the locale from Accept-Language.
+func WithLocalizer(b *i18n.Bundle) Option {
+	return func(r *Responder) {
+		r.localizer = b
+	}
+}
+
+// WithCompression compresses encoded responses with the coding negotiated
+// from each request's Accept-Encoding header.
+func WithCompression(c compression.Config) Option {
+	return func(r *Responder) {
+		r.compression = &c
+	}
+}
+
+// WithTrustedProxies sets the reverse proxies whose X-Forwarded-For and
+// X-Real-IP headers are believed when adapters determine the client address
+// recorded in the audit trail. Without it the connection's address is used.
+func WithTrustedProxies(proxies utils.TrustedProxies) Option {
+	return func(r *Responder) {
+		r.trustedProxies = proxies
+	}
+}
+
+// NewResponder creates a Responder with its own default configuration.
+// The logger and tracer fall back to the global instances unless overridden.
+func NewResponder(opts ...Option) *Responder {
+	r := &Responder{
+		config:  config.New(),
+		encoder: JSONEncoder,
+		clock:   time.Now,
+		newID: func() string {
+			return utils.GenerateTraceID(16)
+		},
+		caches: &responderCaches{},
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	if r.logger != nil {
+		r.followConfig()
+	}
+	return r
+}
+
+// followConfig keeps the responder's logger level and environment in sync
+// with configuration changes, including reloads by a config.Watcher. The
+// subscription holds the logger rather than the responder, and is removed by
+// Close.
+func (r *Responder) followConfig() {
+	explicit := r.logger
+	r.unfollow = r.config.OnChange(func(old, new config.Snapshot) {
+		if old.LogLevel == new.LogLevel && old.Environment == new.Environment {
+			return
+		}
+		log := explicit
+		if log == nil {
+			log = logger.GetLogger()
+		}
+		log.UpdateConfig(logger.Config{
+			Level:       new.LogLevel,
+			Environment: new.Environment,
+		})
+	})
+}
+
+// Close stops the responder's logger from following configuration changes,
+// releasing its subscription to a shared config.Config. Responders scoped
+// from it share the subscription. The responder remains usable; Close only
+// needs to be called for responders created with WithLogger that are
+// discarded before their configuration.
+func (r *Responder) Close() error {
+	if r.unfollow != nil {
+		r.unfollow()
+	}
+	return nil
+}
+
+var (
+	defaultResponder atomic.Pointer[Responder]
+	defaultOnce      sync.Once
+)
+
+// Default returns the Responder backing the package-level functions.
+func Default() *Responder {
+	defaultOnce.Do(func() {
+		r := NewResponder(WithConfig(config.GetConfig()))
+		r.followConfig()
+		defaultResponder.Store(r)
+	})
+	return defaultResponder.Load()
+}
+
+// SetDefault replaces the Responder backing the package-level functions.
+func SetDefault(r *Responder) {
+	Default()
+	defaultResponder.Store(r)
+}
+
+// Config returns the responder's configuration.
+func (r *Responder) Config() *config.Config {
+	return r.config
+}
+
+// Logger returns the responder's logger, or the global logger if none was set.
+func (r *Responder) Logger() *logger.Logger {
+	if r.logger == nil {
+		return logger.GetLogger()
+	}
+	return r.logger
+}
+
+// Metrics returns the responder's collectors, or nil if metrics are disabled.
+func (r *Responder) Metrics() *observability.Metrics {
+	return r.metrics
+}
+
+// Tracer returns the responder's tracer, or the global tracer if none was set.
+func (r *Responder) Tracer() trace.Tracer {
+	if r.tracer == nil {
+		return observability.Tracer()
+	}
+	return r.tracer
+}
+
+// Now returns the current time according to the responder's clock.
+func (r *Responder) Now() time.Time {
+	return r.clock()
+}
+
+// ClientIP returns the address of the client of a request received over the
+// connection from remoteAddr, following forwarding headers only through the
+// responder's trusted proxies.
+func (r *Responder) ClientIP(remoteAddr string, headers http.Header) string {
+	return r.trustedProxies.ClientIP(remoteAddr, headers)
+}
+
+// NewTraceID generates a new trace ID.
+func (r *Responder) NewTraceID() string {
+	return r.newID()
+}
+
+// ForTenant returns a copy of the responder whose metadata is overlaid with
+// the given tenant's overlay. An empty tenantID returns r unchanged.
+func (r *Responder) ForTenant(tenantID string) *Responder {
+	if tenantID == "" || tenantID == r.tenantID {
+		return r
+	}
+	scoped := *r
+	scoped.tenantID = tenantID
+	return &scoped
+}
+
+// ForRequest resolves the tenant, locale, renderer and content coding of a
+// request and returns the matching scoped responder.
+func (r *Responder) ForRequest(headers http.Header, host string) *Responder {
+	scoped := r
+	if r.tenantResolver != nil {
+		scoped = scoped.ForTenant(r.tenantResolver.ResolveTenant(headers, host))
+	}
+	if r.localizer != nil {
+		scoped = scoped.ForLocale(r.localizer.Negotiate(headers.Get("Accept-Language")))
+	}
+	if renderer := r.negotiateRenderer(headers); renderer != nil {
+		scoped = scoped.ForRenderer(renderer)
+	}
+	if r.compression != nil {
+		scoped = scoped.ForEncoding(r.compression.Negotiate(headers.Get("Accept-Encoding")))
+	}
+	return scoped
+}
+
+// ForEncoding returns a copy of the responder compressing eligible responses
+// with encoding. It has no effect unless compression is configured.
+func (r *Responder) ForEncoding(encoding compression.Encoding) *Responder {
+	if encoding == r.encoding {
+		return r
+	}
+	scoped := *r
+	scoped.encoding = encoding
+	return &scoped
+}
+
+// ForLocale returns a copy of the responder localizing messages into locale.
+func (r *Responder) ForLocale(locale language.Tag) *Responder {
+	if locale == r.locale {
+		return r
+	}
+	scoped := *r
+	scoped.locale = locale
+	return &scoped
+}
+
+// Localizer returns the responder's message catalogs, or nil if localization
+// is not configured.
+func (r *Responder) Localizer() *i18n.Bundle {
+	return r.localizer
+}
+
+// Locale returns the locale messages are rendered in, or language.Und when
+// localization is disabled.
+func (r *Responder) Locale() language.Tag {
+	if !r.localizationEnabled() {
+		return language.Und
+	}
+	if r.locale == language.Und {
+		return r.localizer.DefaultLocale()
+	}
+	return r.locale
+}
+
+// ForRoute returns a copy of the responder applying the metadata visibility
+// overrides and renderer configured for route.
+func (r *Responder) ForRoute(route string) *Responder {
+	scoped := r
+	if route != r.route {
+		copied := *r
+		copied.route = route
+		scoped = &copied
+	}
+	if renderer := r.routeRenderer(route); renderer != nil {
+		scoped = scoped.ForRenderer(renderer)
+	}
+	return scoped
+}
+
+// TenantID returns the tenant the responder is scoped to, if any.
+func (r *Responder) TenantID() string {
+	return r.tenantID
+}
@@ -1,13 +1,12 @@
 package core
 
 import (
-	"bytes"
 	"net/http"
 	"time"
 
 	"github.com/andreascandle/FlexiResponseGo/config"
-	"github.com/andreascandle/FlexiResponseGo/utils"
 	jsoniter "github.com/json-iterator/go"
+	"go.uber.org/zap"
 )
 
 var json = jsoniter.ConfigCompatibleWithStandardLibrary
@@ -16,108 +15,194 @@ var json = jsoniter.ConfigCompatibleWithStandardLibrary
 type StandardResponse struct {
 	Status      string                 `json:"status"`
 	Message     string                 `json:"message"`
+	MessageKey  string                 `json:"message_key,omitempty"`
 	Data        interface{}            `json:"data,omitempty"`
 	Error       string                 `json:"error,omitempty"`
 	TraceID     string                 `json:"trace_id,omitempty"`
 	FieldErrors map[string]interface{} `json:"field_errors,omitempty"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	Links       Links                  `json:"links,omitempty"`
+
+	pending     *Message        // Keyed message re-rendered by the writing responder
+	validators  *validators     // ETag, Last-Modified and request conditions
+	cachePolicy *CachePolicy    // Cache-Control and Vary of successful responses
+	cacheTags   []string        // Labels for response cache invalidation
+	location    string          // Location header
+	retryAfter  time.Duration   // Retry-After header
+	selectable  bool            // Data may be projected onto requested fields
+	static      *staticMetadata // Pre-encoded part of Metadata
 }
 
 // NewSuccessResponse creates a standardized success response.
 func NewSuccessResponse(traceID, message string, data interface{}) StandardResponse {
+	return Default().NewSuccessResponse(traceID, message, data)
+}
+
+// NewErrorResponse creates a standardized error response.
+func NewErrorResponse(traceID, message, errorDetail string) StandardResponse {
+	return Default().NewErrorResponse(traceID, message, errorDetail)
+}
+
+// NewValidationErrorResponse creates a response for validation errors.
+func NewValidationErrorResponse(traceID, message string, fieldErrors map[string]interface{}) StandardResponse {
+	return Default().NewValidationErrorResponse(traceID, message, fieldErrors)
+}
+
+// WriteJSON sends a JSON response with optimal performance.
+func WriteJSON(w http.ResponseWriter, statusCode int, resp StandardResponse) error {
+	return Default().WriteJSON(w, statusCode, resp)
+}
+
+// WriteErrorResponse writes an APIError to the response using StandardResponse.
+func WriteErrorResponse(w http.ResponseWriter, statusCode int, traceID string, apiErr APIError) error {
+	return Default().WriteErrorResponse(w, statusCode, traceID, apiErr)
+}
+
+// NewSuccessResponse creates a standardized success response.
+func (r *Responder) NewSuccessResponse(traceID, message string, data interface{}) StandardResponse {
 	if traceID == "" {
-		traceID = utils.GenerateTraceID(16)
+		traceID = r.NewTraceID()
 	}
-	return StandardResponse{
+	resp := StandardResponse{
 		Status:  "success",
-		Message: localizeMessage(message),
+		Message: r.localizeMessage(message),
 		Data:    data,
 		TraceID: traceID,
-		Metadata: mergeMetadata(map[string]interface{}{
-			"timestamp": time.Now().Format(time.RFC3339),
-		}),
 	}
+	resp.Metadata, resp.static = r.newMetadata(false)
+	return resp
 }
 
 // NewErrorResponse creates a standardized error response.
-func NewErrorResponse(traceID, message, errorDetail string) StandardResponse {
+func (r *Responder) NewErrorResponse(traceID, message, errorDetail string) StandardResponse {
 	if traceID == "" {
-		traceID = utils.GenerateTraceID(16)
+		traceID = r.NewTraceID()
 	}
-	return StandardResponse{
+	resp := StandardResponse{
 		Status:  "error",
-		Message: localizeMessage(message),
+		Message: r.localizeMessage(message),
 		Error:   sanitizeError(errorDetail),
 		TraceID: traceID,
-		Metadata: mergeMetadata(map[string]interface{}{
-			"timestamp": time.Now().Format(time.RFC3339),
-		}),
 	}
+	resp.Metadata, resp.static = r.newMetadata(true)
+	return resp
 }
 
 // NewValidationErrorResponse creates a response for validation errors.
-func NewValidationErrorResponse(traceID, message string, fieldErrors map[string]interface{}) StandardResponse {
+func (r *Responder) NewValidationErrorResponse(traceID, message string, fieldErrors map[string]interface{}) StandardResponse {
 	if traceID == "" {
-		traceID = utils.GenerateTraceID(16)
+		traceID = r.NewTraceID()
 	}
-	return StandardResponse{
+	resp := StandardResponse{
 		Status:      "error",
-		Message:     localizeMessage(message),
-		FieldErrors: fieldErrors,
+		Message:     r.localizeMessage(message),
+		FieldErrors: r.localizeFieldErrors(fieldErrors),
 		TraceID:     traceID,
-		Metadata: mergeMetadata(map[string]interface{}{
-			"timestamp": time.Now().Format(time.RFC3339),
-		}),
 	}
+	resp.Metadata, resp.static = r.newMetadata(true)
+	return resp
 }
 
-// WriteJSON sends a JSON response with optimal performance.
-func WriteJSON(w http.ResponseWriter, statusCode int, resp StandardResponse) error {
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("X-Trace-ID", resp.TraceID)
-	w.WriteHeader(statusCode)
-
-	var buf bytes.Buffer
-	if err := json.NewEncoder(&buf).Encode(resp); err != nil {
-		http.Error(w, "Failed to encode response", http.StatusInternalServerError)
-		return err
+// WriteJSON sends a JSON response with optimal performance. The response is
+// encoded before any header is written; validators and request conditions
+// attached with ResponseOptions are applied as described for Prepare. When
+// encoding fails, a ServerError envelope with the response's trace ID is
+// written instead and an *EncodingError is returned.
+func (r *Responder) WriteJSON(w http.ResponseWriter, statusCode int, resp StandardResponse) error {
+	prepared, err := r.Prepare(statusCode, resp)
+	if writeErr := prepared.Write(w); err == nil {
+		err = writeErr
 	}
-
-	_, err := w.Write(buf.Bytes())
 	return err
 }
 
 // WriteErrorResponse writes an APIError to the response using StandardResponse.
-func WriteErrorResponse(w http.ResponseWriter, statusCode int, traceID string, apiErr APIError) error {
+func (r *Responder) WriteErrorResponse(w http.ResponseWriter, statusCode int, traceID string, apiErr APIError) error {
+	return r.WriteJSON(w, statusCode, r.NewAPIErrorResponse(statusCode, traceID, apiErr))
+}
+
+// NewAPIErrorResponse creates the response written for an APIError.
+func (r *Responder) NewAPIErrorResponse(statusCode int, traceID string, apiErr APIError) StandardResponse {
 	resp := StandardResponse{
 		Status:  "error",
-		Message: apiErr.Message,
+		Message: r.localizeMessage(apiErr.Message),
 		Error:   apiErr.Details,
 		TraceID: traceID,
-		Metadata: mergeMetadata(map[string]interface{}{
-			"category":  apiErr.Category,
-			"code":      apiErr.Code,
-			"timestamp": time.Now().Format(time.RFC3339),
-		}),
 	}
-	return WriteJSON(w, statusCode, resp)
+	resp.Metadata, resp.static = r.newMetadata(statusCode >= 400,
+		metadataField{"category", apiErr.Category},
+		metadataField{"code", apiErr.Code},
+	)
+	return resp
 }
 
-// mergeMetadata combines global and local metadata dynamically.
-func mergeMetadata(localMetadata map[string]interface{}) map[string]interface{} {
-	conf := config.GetConfig()
-	for k, v := range conf.GlobalMetadata {
-		localMetadata[k] = v
+// tenantOverlay returns the overlay for the responder's tenant, falling back
+// to the global configuration when it cannot be loaded.
+func (r *Responder) tenantOverlay() *config.Overlay {
+	if r.tenantID == "" || r.tenants == nil {
+		return nil
 	}
-	return localMetadata
+	overlay, err := r.tenants.Overlay(r.tenantID)
+	if err != nil {
+		r.Logger().Warn("Failed to load tenant overlay",
+			zap.String("tenant_id", r.tenantID),
+			zap.Error(err),
+		)
+		return nil
+	}
+	return overlay
 }
 
-// localizeMessage returns a localized message if localization is enabled.
-func localizeMessage(message string) string {
-	conf := config.GetConfig()
-	if conf.GlobalMetadata["enableLocalization"] == true {
-		// Placeholder for localization logic. Extend with an i18n system or database.
+// localizeMessage treats message as a catalog key and returns its rendering
+// in the responder's locale. Messages missing from every catalog in the
+// fallback chain are returned unchanged.
+func (r *Responder) localizeMessage(message string) string {
+	if !r.localizationEnabled() {
 		return message
 	}
+	if text, ok := r.localizer.Localize(r.Locale(), message, nil); ok {
+		return text
+	}
 	return message
 }
+
+// localizeFieldErrors localizes string field messages, and lists of them, by
+// key and renders Message values.
+func (r *Responder) localizeFieldErrors(fieldErrors map[string]interface{}) map[string]interface{} {
+	if fieldErrors == nil {
+		return nil
+	}
+	localized := make(map[string]interface{}, len(fieldErrors))
+	for field, value := range fieldErrors {
+		switch v := value.(type) {
+		case Message:
+			localized[field] = r.renderMessage(v)
+		case string:
+			localized[field] = r.localizeMessage(v)
+		case []string:
+			messages := make([]string, len(v))
+			for i, m := range v {
+				messages[i] = r.localizeMessage(m)
+			}
+			localized[field] = messages
+		default:
+			localized[field] = value
+		}
+	}
+	return localized
+}
+
+// localizationEnabled reports whether catalogs are configured and not turned
+// off by the enableLocalization setting. GlobalMetadata is consulted for
+// configurations written before settings were split out.
+func (r *Responder) localizationEnabled() bool {
+	if r.localizer == nil {
+		return false
+	}
+	conf := r.config.Snapshot()
+	enabled, ok := conf.Setting("enableLocalization")
+	if !ok {
+		enabled, ok = conf.GlobalMetadata["enableLocalization"]
+	}
+	return !ok || enabled != false
+}
@@ -0,0 +1,65 @@
+package core
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// WithLocation sends a Location header, such as the URL of the resource a
+// 201 Created response reports.
+func WithLocation(url string) ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.location = url
+	}
+}
+
+// WithStatusMonitor points a 202 Accepted response at the status monitor of
+// the accepted request: its URL is sent in the Location header and as the
+// "monitor" link.
+func WithStatusMonitor(url string) ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.location = url
+		WithLinks(Links{"monitor": {Href: url}})(resp)
+	}
+}
+
+// WithRetryAfter sends a Retry-After header telling clients how long to wait
+// before polling again or retrying, rounded up to whole seconds.
+func WithRetryAfter(d time.Duration) ResponseOption {
+	return func(resp *StandardResponse) {
+		resp.retryAfter = d
+	}
+}
+
+// setStatusHeaders sets the Location and Retry-After headers of resp.
+func setStatusHeaders(h http.Header, resp StandardResponse) {
+	if resp.location != "" {
+		h.Set("Location", resp.location)
+	}
+	if resp.retryAfter > 0 {
+		seconds := (resp.retryAfter + time.Second - 1) / time.Second
+		h.Set("Retry-After", strconv.FormatInt(int64(seconds), 10))
+	}
+}
+
+// bodyless reports whether responses with statusCode never carry content.
+func bodyless(statusCode int) bool {
+	return statusCode >= 100 && statusCode < 200 ||
+		statusCode == http.StatusNoContent ||
+		statusCode == http.StatusResetContent ||
+		statusCode == http.StatusNotModified
+}
+
+// prepareBodyless prepares a response without content. Only the trace ID,
+// Location, Retry-After and cache headers are sent; Content-Type and the
+// validators of the unsent body are left out.
+func (r *Responder) prepareBodyless(statusCode int, resp StandardResponse) *Prepared {
+	p := &Prepared{StatusCode: statusCode, Header: make(http.Header)}
+	p.Header.Set("X-Trace-ID", resp.TraceID)
+	setStatusHeaders(p.Header, resp)
+	if statusCode == http.StatusNoContent {
+		r.setCacheHeaders(p.Header, resp)
+	}
+	return p
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// uriOperator describes the expansion rules of an RFC 6570 operator.
+type uriOperator struct {
+	first    string // Prefix emitted before the first defined variable
+	sep      string // Separator between variables
+	named    bool   // Emit name=value pairs
+	ifEmpty  string // Suffix for named variables with empty values
+	reserved bool   // Allow reserved characters unescaped
+}
+
+// simpleExpansion applies to expressions without an operator.
+var simpleExpansion = uriOperator{first: "", sep: ","}
+
+var uriOperators = map[byte]uriOperator{
+	'+': {first: "", sep: ",", reserved: true},
+	'#': {first: "#", sep: ",", reserved: true},
+	'.': {first: ".", sep: "."},
+	'/': {first: "/", sep: "/"},
+	';': {first: ";", sep: ";", named: true},
+	'?': {first: "?", sep: "&", named: true, ifEmpty: "="},
+	'&': {first: "&", sep: "&", named: true, ifEmpty: "="},
+}
+
+// ExpandURITemplate expands an RFC 6570 URI template (levels 1-4, without
+// prefix modifiers) using values. Undefined variables expand to nothing.
+// Values may be strings, numbers, []string, []interface{} or map[string]string.
+func ExpandURITemplate(template string, values map[string]interface{}) (string, error) {
+	expanded, _, err := expandURITemplate(template, values, false)
+	return expanded, err
+}
+
+// expandURITemplate expands template. With keepUndefined, expressions that
+// reference an undefined variable are copied verbatim and templated reports
+// whether any were left.
+func expandURITemplate(template string, values map[string]interface{}, keepUndefined bool) (expanded string, templated bool, err error) {
+	var b strings.Builder
+	for i := 0; i < len(template); {
+		if template[i] != '{' {
+			b.WriteByte(template[i])
+			i++
+			continue
+		}
+		end := strings.IndexByte(template[i:], '}')
+		if end < 0 {
+			return "", false, fmt.Errorf("uri template: unclosed expression at offset %d", i)
+		}
+		expr := template[i+1 : i+end]
+		if keepUndefined && !expressionDefined(expr, values) {
+			b.WriteString(template[i : i+end+1])
+			templated = true
+		} else if err := expandExpression(&b, expr, values); err != nil {
+			return "", false, err
+		}
+		i += end + 1
+	}
+	return b.String(), templated, nil
+}
+
+// expressionDefined reports whether every variable of expr has a value.
+func expressionDefined(expr string, values map[string]interface{}) bool {
+	if expr != "" && strings.IndexByte("+#./;?&", expr[0]) >= 0 {
+		expr = expr[1:]
+	}
+	for _, spec := range strings.Split(expr, ",") {
+		if v, ok := values[strings.TrimSuffix(spec, "*")]; !ok || v == nil {
+			return false
+		}
+	}
+	return true
+}
+
+func expandExpression(b *strings.Builder, expr string, values map[string]interface{}) error {
+	if expr == "" {
+		return fmt.Errorf("uri template: empty expression")
+	}
+	op, ok := uriOperators[expr[0]]
+	if ok {
+		expr = expr[1:]
+	} else {
+		op = simpleExpansion
+	}
+
+	first := true
+	for _, spec := range strings.Split(expr, ",") {
+		explode := strings.HasSuffix(spec, "*")
+		name := strings.TrimSuffix(spec, "*")
+		value, defined := values[name]
+		if !defined || value == nil {
+			continue
+		}
+		parts, pairs := templateValue(value)
+		if parts == nil && pairs == nil {
+			continue
+		}
+
+		if first {
+			b.WriteString(op.first)
+			first = false
+		} else {
+			b.WriteString(op.sep)
+		}
+
+		switch {
+		case pairs != nil:
+			keys := make([]string, 0, len(pairs))
+			for k := range pairs {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			if explode {
+				for i, k := range keys {
+					if i > 0 {
+						b.WriteString(op.sep)
+					}
+					b.WriteString(escapeTemplate(k, op.reserved) + "=" + escapeTemplate(pairs[k], op.reserved))
+				}
+				continue
+			}
+			if op.named {
+				b.WriteString(name + "=")
+			}
+			for i, k := range keys {
+				if i > 0 {
+					b.WriteByte(',')
+				}
+				b.WriteString(escapeTemplate(k, op.reserved) + "," + escapeTemplate(pairs[k], op.reserved))
+			}
+		case len(parts) == 1 && !explode:
+			if op.named {
+				b.WriteString(name)
+				if parts[0] == "" {
+					b.WriteString(op.ifEmpty)
+					continue
+				}
+				b.WriteByte('=')
+			}
+			b.WriteString(escapeTemplate(parts[0], op.reserved))
+		default:
+			for i, part := range parts {
+				if i > 0 {
+					if explode {
+						b.WriteString(op.sep)
+					} else {
+						b.WriteByte(',')
+					}
+				}
+				if op.named && (explode || i == 0) {
+					b.WriteString(name + "=")
+				}
+				b.WriteString(escapeTemplate(part, op.reserved))
+			}
+		}
+	}
+	return nil
+}
+
+// templateValue normalizes a value into a list or an associative array.
+func templateValue(value interface{}) ([]string, map[string]string) {
+	switch v := value.(type) {
+	case []string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return v, nil
+	case []interface{}:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		parts := make([]string, len(v))
+		for i, item := range v {
+			parts[i] = fmt.Sprint(item)
+		}
+		return parts, nil
+	case map[string]string:
+		if len(v) == 0 {
+			return nil, nil
+		}
+		return nil, v
+	default:
+		return []string{fmt.Sprint(v)}, nil
+	}
+}
+
+// escapeTemplate percent-encodes s, keeping reserved characters when allowed.
+func escapeTemplate(s string, reserved bool) string {
+	escaped := url.PathEscape(s)
+	// PathEscape leaves sub-delimiters, ':' and '@' unescaped; RFC 6570 simple
+	// expansion only allows unreserved characters.
+	if !reserved {
+		replacer := strings.NewReplacer(
+			"!", "%21", "$", "%24", "&", "%26", "'", "%27", "(", "%28", ")", "%29",
+			"*", "%2A", "+", "%2B", ",", "%2C", ";", "%3B", "=", "%3D", ":", "%3A", "@", "%40",
+		)
+		return replacer.Replace(escaped)
+	}
+	return strings.NewReplacer("%2F", "/", "%3F", "?", "%23", "#", "%5B", "[", "%5D", "]").Replace(escaped)
+}
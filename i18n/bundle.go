@@ -0,0 +1,214 @@
+// Package i18n provides message catalogs, ICU-style message formatting and
+// Accept-Language negotiation for localized responses.
+package i18n
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"golang.org/x/text/language"
+	"gopkg.in/yaml.v3"
+)
+
+// Bundle holds message catalogs for a set of locales. Messages are looked up
+// along a fallback chain: the requested locale, its parents (pt-BR, then pt)
+// and finally the bundle's default locale.
+type Bundle struct {
+	defaultLocale language.Tag
+
+	mu       sync.RWMutex
+	catalogs map[language.Tag]map[string]string
+	tags     []language.Tag // Default locale first, as required by the matcher
+	matcher  language.Matcher
+}
+
+// NewBundle creates an empty bundle whose fallback locale is defaultLocale.
+func NewBundle(defaultLocale language.Tag) *Bundle {
+	b := &Bundle{
+		defaultLocale: defaultLocale,
+		catalogs:      map[language.Tag]map[string]string{defaultLocale: {}},
+		tags:          []language.Tag{defaultLocale},
+	}
+	b.matcher = language.NewMatcher(b.tags)
+	return b
+}
+
+// DefaultLocale returns the locale used when nothing better matches.
+func (b *Bundle) DefaultLocale() language.Tag {
+	return b.defaultLocale
+}
+
+// Locales returns the locales with a catalog, default locale first.
+func (b *Bundle) Locales() []language.Tag {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return append([]language.Tag(nil), b.tags...)
+}
+
+// AddMessages merges messages into the catalog for locale. Messages use the
+// syntax accepted by Format.
+func (b *Bundle) AddMessages(locale language.Tag, messages map[string]string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	catalog, ok := b.catalogs[locale]
+	if !ok {
+		catalog = make(map[string]string, len(messages))
+		b.catalogs[locale] = catalog
+		b.tags = append(b.tags, locale)
+		b.matcher = language.NewMatcher(b.tags)
+	}
+	for k, v := range messages {
+		catalog[k] = v
+	}
+}
+
+// LoadFile loads a catalog from a JSON, YAML or TOML file named after its
+// locale, such as "fr.yaml" or "pt-BR.json". Nested objects are flattened into
+// dotted keys, so {"errors": {"notFound": "..."}} defines "errors.notFound".
+func (b *Bundle) LoadFile(path string) error {
+	base := filepath.Base(path)
+	locale, err := language.Parse(strings.TrimSuffix(base, filepath.Ext(base)))
+	if err != nil {
+		return fmt.Errorf("i18n: %s: file name is not a locale: %w", path, err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var raw map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		err = json.Unmarshal(data, &raw)
+	case ".yaml", ".yml":
+		err = yaml.NewDecoder(bytes.NewReader(data)).Decode(&raw)
+	case ".toml":
+		err = toml.Unmarshal(data, &raw)
+	default:
+		return fmt.Errorf("i18n: unsupported catalog extension %q", filepath.Ext(path))
+	}
+	if err != nil {
+		return fmt.Errorf("i18n: %s: %w", path, err)
+	}
+
+	messages := make(map[string]string)
+	if err := flatten("", raw, messages); err != nil {
+		return fmt.Errorf("i18n: %s: %w", path, err)
+	}
+	for key, message := range messages {
+		if _, err := Format(locale, message, nil); err != nil {
+			return fmt.Errorf("i18n: %s: %s: %w", path, key, err)
+		}
+	}
+	b.AddMessages(locale, messages)
+	return nil
+}
+
+// LoadDir loads every catalog file in dir.
+func (b *Bundle) LoadDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		switch strings.ToLower(filepath.Ext(entry.Name())) {
+		case ".json", ".yaml", ".yml", ".toml":
+			if !entry.IsDir() {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := b.LoadFile(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func flatten(prefix string, values map[string]interface{}, out map[string]string) error {
+	for k, v := range values {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch value := v.(type) {
+		case string:
+			out[key] = value
+		case map[string]interface{}:
+			if err := flatten(key, value, out); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("%s: message must be a string or an object", key)
+		}
+	}
+	return nil
+}
+
+// Negotiate picks the best supported locale for an Accept-Language header,
+// falling back to the default locale.
+func (b *Bundle) Negotiate(acceptLanguage string) language.Tag {
+	desired, _, err := language.ParseAcceptLanguage(acceptLanguage)
+	if err != nil || len(desired) == 0 {
+		return b.defaultLocale
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, index, confidence := b.matcher.Match(desired...)
+	if confidence == language.No {
+		return b.defaultLocale
+	}
+	return b.tags[index]
+}
+
+// Fallbacks returns the lookup chain for locale.
+func (b *Bundle) Fallbacks(locale language.Tag) []language.Tag {
+	var chain []language.Tag
+	for tag := locale; ; tag = tag.Parent() {
+		chain = append(chain, tag)
+		if tag == language.Und || tag == b.defaultLocale {
+			break
+		}
+	}
+	if chain[len(chain)-1] != b.defaultLocale {
+		chain = append(chain, b.defaultLocale)
+	}
+	return chain
+}
+
+// Lookup returns the raw message for key and the locale it was found in.
+func (b *Bundle) Lookup(locale language.Tag, key string) (string, language.Tag, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for _, tag := range b.Fallbacks(locale) {
+		if message, ok := b.catalogs[tag][key]; ok {
+			return message, tag, true
+		}
+	}
+	return "", language.Und, false
+}
+
+// Localize renders the message for key in locale. It reports false when no
+// catalog in the fallback chain defines key.
+func (b *Bundle) Localize(locale language.Tag, key string, params map[string]interface{}) (string, bool) {
+	message, found, ok := b.Lookup(locale, key)
+	if !ok {
+		return "", false
+	}
+	text, err := Format(found, message, params)
+	if err != nil {
+		return message, true
+	}
+	return text, true
+}
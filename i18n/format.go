@@ -0,0 +1,253 @@
+package i18n
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+)
+
+// formNames maps CLDR plural forms to their ICU selector keywords.
+var formNames = map[plural.Form]string{
+	plural.Other: "other",
+	plural.Zero:  "zero",
+	plural.One:   "one",
+	plural.Two:   "two",
+	plural.Few:   "few",
+	plural.Many:  "many",
+}
+
+// Format renders an ICU MessageFormat pattern for the given locale.
+//
+// Supported syntax:
+//
+//	{name}                                   parameter substitution
+//	{count, plural, =0 {none} one {# item} other {# items}}
+//	{place, selectordinal, one {#st} two {#nd} few {#rd} other {#th}}
+//	{gender, select, female {her} male {his} other {their}}
+//	'{literal}' and '' for quoting
+//
+// Plural categories follow the CLDR rules of the locale. Parameters missing
+// from params are left in place as "{name}".
+func Format(locale language.Tag, pattern string, params map[string]interface{}) (string, error) {
+	f := formatter{locale: locale, params: params}
+	return f.render(pattern, "")
+}
+
+type formatter struct {
+	locale language.Tag
+	params map[string]interface{}
+}
+
+// render expands pattern. Inside plural branches, pound holds the value that
+// replaces '#'.
+func (f formatter) render(pattern, pound string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(pattern); {
+		switch c := pattern[i]; c {
+		case '\'':
+			i = quoted(pattern, i, &b)
+		case '#':
+			if pound != "" {
+				b.WriteString(pound)
+			} else {
+				b.WriteByte(c)
+			}
+			i++
+		case '{':
+			end := closingBrace(pattern, i)
+			if end < 0 {
+				return "", fmt.Errorf("i18n: unclosed '{' at offset %d in %q", i, pattern)
+			}
+			text, err := f.argument(pattern[i+1 : end])
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(text)
+			i = end + 1
+		default:
+			b.WriteByte(c)
+			i++
+		}
+	}
+	return b.String(), nil
+}
+
+// quoted handles an apostrophe at pattern[i] and returns the next offset.
+func quoted(pattern string, i int, b *strings.Builder) int {
+	if i+1 < len(pattern) && pattern[i+1] == '\'' {
+		b.WriteByte('\'')
+		return i + 2
+	}
+	if i+1 < len(pattern) && strings.ContainsRune("{}#", rune(pattern[i+1])) {
+		end := strings.IndexByte(pattern[i+1:], '\'')
+		if end < 0 {
+			b.WriteString(pattern[i+1:])
+			return len(pattern)
+		}
+		b.WriteString(pattern[i+1 : i+1+end])
+		return i + end + 2
+	}
+	b.WriteByte('\'')
+	return i + 1
+}
+
+// closingBrace returns the index of the brace closing the one at open.
+func closingBrace(pattern string, open int) int {
+	depth := 0
+	for i := open; i < len(pattern); i++ {
+		switch pattern[i] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+func (f formatter) argument(arg string) (string, error) {
+	parts := strings.SplitN(arg, ",", 3)
+	name := strings.TrimSpace(parts[0])
+	value, ok := f.params[name]
+	if len(parts) == 1 {
+		if !ok {
+			return "{" + name + "}", nil
+		}
+		return fmt.Sprint(value), nil
+	}
+
+	kind := strings.TrimSpace(parts[1])
+	if len(parts) < 3 {
+		// Formatting styles such as {n, number} render the plain value.
+		if !ok {
+			return "{" + arg + "}", nil
+		}
+		return fmt.Sprint(value), nil
+	}
+	options, offset, err := parseOptions(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("i18n: argument %q: %w", name, err)
+	}
+
+	switch kind {
+	case "select":
+		branch, found := options[fmt.Sprint(value)]
+		if !found || !ok {
+			branch = options["other"]
+		}
+		return f.render(branch, "")
+	case "plural", "selectordinal":
+		n, isNumber := toFloat(value)
+		if !isNumber {
+			return f.render(options["other"], "")
+		}
+		if branch, found := options["="+strconv.FormatFloat(n, 'f', -1, 64)]; found {
+			return f.render(branch, strconv.FormatFloat(n-offset, 'f', -1, 64))
+		}
+		rules := plural.Cardinal
+		if kind == "selectordinal" {
+			rules = plural.Ordinal
+		}
+		shown := n - offset
+		branch, found := options[formNames[pluralForm(rules, f.locale, shown)]]
+		if !found {
+			branch = options["other"]
+		}
+		return f.render(branch, strconv.FormatFloat(shown, 'f', -1, 64))
+	default:
+		return "", fmt.Errorf("i18n: unsupported argument type %q", kind)
+	}
+}
+
+// parseOptions parses "offset:1 =0 {..} one {..} other {..}".
+func parseOptions(s string) (map[string]string, float64, error) {
+	options := make(map[string]string)
+	var offset float64
+	for i := 0; i < len(s); {
+		if s[i] == ' ' || s[i] == '\t' || s[i] == '\n' {
+			i++
+			continue
+		}
+		open := strings.IndexByte(s[i:], '{')
+		if open < 0 {
+			return nil, 0, fmt.Errorf("expected '{' after %q", strings.TrimSpace(s[i:]))
+		}
+		selector := strings.TrimSpace(s[i : i+open])
+		if rest, ok := strings.CutPrefix(selector, "offset:"); ok {
+			fields := strings.Fields(rest)
+			if len(fields) == 0 {
+				return nil, 0, fmt.Errorf("invalid offset")
+			}
+			parsed, err := strconv.ParseFloat(fields[0], 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("invalid offset %q", fields[0])
+			}
+			offset = parsed
+			selector = strings.TrimSpace(strings.Join(fields[1:], " "))
+		}
+		end := closingBrace(s, i+open)
+		if end < 0 {
+			return nil, 0, fmt.Errorf("unclosed option %q", selector)
+		}
+		options[selector] = s[i+open+1 : end]
+		i = end + 1
+	}
+	if _, ok := options["other"]; !ok {
+		return nil, 0, fmt.Errorf("missing 'other' option")
+	}
+	return options, offset, nil
+}
+
+// pluralForm selects the CLDR plural form for n in locale.
+func pluralForm(rules *plural.Rules, locale language.Tag, n float64) plural.Form {
+	if n < 0 {
+		n = -n
+	}
+	digits := strconv.FormatFloat(n, 'f', -1, 64)
+	intPart, frac, _ := strings.Cut(digits, ".")
+	i, _ := strconv.Atoi(intPart)
+	trimmed := strings.TrimRight(frac, "0")
+	f, _ := strconv.Atoi("0" + frac)
+	t, _ := strconv.Atoi("0" + trimmed)
+	return rules.MatchPlural(locale, i, len(frac), len(trimmed), f, t)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case float32:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		parsed, err := strconv.ParseFloat(n, 64)
+		return parsed, err == nil
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,193 @@
+package idempotency
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileStore is a Store keeping one JSON file per key in a directory, so
+// records survive restarts and can be shared by processes on one host.
+// Reservations are created atomically by hard-linking a fully written file
+// into place, which fails when the key's file already exists. Expired files
+// are taken over by renaming them aside and checking they are the expired
+// record, so a reservation made meanwhile by another process is put back.
+type FileStore struct {
+	dir string
+	now func() time.Time
+}
+
+// NewFileStore creates a store in dir, creating the directory if needed.
+func NewFileStore(dir string, opts ...StoreOption) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, now: newStoreConfig(opts).now}, nil
+}
+
+// Reserve implements Store.
+func (s *FileStore) Reserve(key string, rec Record) (Record, bool, error) {
+	tmp, err := s.writeTemp(rec)
+	if err != nil {
+		return Record{}, false, err
+	}
+	defer os.Remove(tmp)
+
+	path := s.path(key)
+	for attempt := 0; attempt < 2; attempt++ {
+		err := os.Link(tmp, path)
+		if err == nil {
+			return Record{}, true, nil
+		}
+		if !errors.Is(err, fs.ErrExist) {
+			return Record{}, false, err
+		}
+		existing, data, err := s.read(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Deleted since the link failed
+		}
+		if err != nil {
+			return Record{}, false, err
+		}
+		if !expired(existing, s.now()) {
+			return existing, false, nil
+		}
+		if _, err := s.removeIfUnchanged(path, data); err != nil {
+			return Record{}, false, err
+		}
+	}
+	return Record{}, false, errors.New("idempotency: key " + key + " is contended")
+}
+
+// Save implements Store.
+func (s *FileStore) Save(key string, rec Record) error {
+	tmp, err := s.writeTemp(rec)
+	if err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path(key)); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// Delete implements Store.
+func (s *FileStore) Delete(key string) error {
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// Cleanup removes the files of expired records and returns how many were
+// removed.
+func (s *FileStore) Cleanup() (int, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return 0, err
+	}
+	now := s.now()
+	removed := 0
+	for _, entry := range entries {
+		if !strings.HasSuffix(entry.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(s.dir, entry.Name())
+		rec, data, err := s.read(path)
+		if err != nil || !expired(rec, now) {
+			continue
+		}
+		if ok, _ := s.removeIfUnchanged(path, data); ok {
+			removed++
+		}
+	}
+	return removed, nil
+}
+
+// StartCleanup runs Cleanup every interval until the returned function is
+// called.
+func (s *FileStore) StartCleanup(interval time.Duration) (stop func()) {
+	return startCleanup(interval, func() { _, _ = s.Cleanup() })
+}
+
+// path returns the file of key. Keys are hashed, so any key maps to a safe
+// file name.
+func (s *FileStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+// read returns the record in path and the file's contents.
+func (s *FileStore) read(path string) (Record, []byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Record{}, nil, err
+	}
+	var rec Record
+	err = json.Unmarshal(data, &rec)
+	return rec, data, err
+}
+
+// removeIfUnchanged removes path if it still holds data. The file is renamed
+// to a unique tombstone first, so it can be compared without racing other
+// processes; a file replaced since data was read is linked back into place.
+// It reports whether the file was removed; a file already gone is not an
+// error.
+func (s *FileStore) removeIfUnchanged(path string, data []byte) (bool, error) {
+	tombstone, err := os.CreateTemp(s.dir, ".tombstone-*")
+	if err != nil {
+		return false, err
+	}
+	tombstone.Close()
+	defer os.Remove(tombstone.Name())
+
+	if err := os.Rename(path, tombstone.Name()); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil // Taken over by another process
+		}
+		return false, err
+	}
+	current, err := os.ReadFile(tombstone.Name())
+	if err != nil {
+		return false, err
+	}
+	if bytes.Equal(current, data) {
+		return true, nil
+	}
+	// Another process replaced the file after data was read; restore its
+	// record unless yet another file has been linked into place meanwhile.
+	if err := os.Link(tombstone.Name(), path); err != nil && !errors.Is(err, fs.ErrExist) {
+		return false, err
+	}
+	return false, nil
+}
+
+// writeTemp writes rec to a new temporary file in the store's directory.
+func (s *FileStore) writeTemp(rec Record) (string, error) {
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return "", err
+	}
+	f, err := os.CreateTemp(s.dir, ".record-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		os.Remove(f.Name())
+		return "", err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(f.Name())
+		return "", err
+	}
+	return f.Name(), nil
+}
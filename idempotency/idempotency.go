@@ -0,0 +1,296 @@
+// Package idempotency makes retries of unsafe requests safe: the first
+// response to a request carrying an Idempotency-Key is stored and replayed for
+// every retry with the same key, so the handler runs only once.
+package idempotency
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"go.uber.org/zap"
+)
+
+// Record is the state of an idempotency key: a reservation held while the
+// first request is processed, then that request's response.
+type Record struct {
+	Fingerprint string      `json:"fingerprint"` // Hash of the request the key was first used with
+	Completed   bool        `json:"completed"`
+	StatusCode  int         `json:"status_code,omitempty"`
+	Header      http.Header `json:"header,omitempty"`
+	Body        []byte      `json:"body,omitempty"`
+	ExpiresAt   time.Time   `json:"expires_at"`
+}
+
+// Store persists idempotency records. Stores must ignore records whose
+// ExpiresAt has passed, and may delete them at any time.
+type Store interface {
+	// Reserve atomically saves rec for key unless an unexpired record
+	// exists, in which case that record is returned with reserved false.
+	Reserve(key string, rec Record) (existing Record, reserved bool, err error)
+	// Save replaces the record of key.
+	Save(key string, rec Record) error
+	// Delete removes the record of key. Deleting a missing key is not an error.
+	Delete(key string) error
+}
+
+var (
+	// ErrInvalidKey is the error sent for an empty or overlong key.
+	ErrInvalidKey = core.NewAPIError(core.ClientError, http.StatusBadRequest,
+		"Bad Request", "The Idempotency-Key header must hold 1 to 255 characters.")
+	// ErrRequestInFlight is the error sent for a retry arriving while the
+	// first request with its key is still being processed.
+	ErrRequestInFlight = core.NewAPIError(core.ClientError, http.StatusConflict,
+		"Conflict", "A request with this Idempotency-Key is still being processed.")
+	// ErrKeyReused is the error sent when a key is reused for a different
+	// request.
+	ErrKeyReused = core.NewAPIError(core.ClientError, http.StatusUnprocessableEntity,
+		"Unprocessable Entity", "The Idempotency-Key was already used for a different request.")
+	// ErrBodyTooLarge is the error sent for a request with a key whose body
+	// is larger than the guard's MaxBodySize.
+	ErrBodyTooLarge = core.NewAPIError(core.ClientError, http.StatusRequestEntityTooLarge,
+		"Payload Too Large", "The request body is too large for an idempotent request.")
+	// ErrStoreUnavailable is the error sent when the store cannot be reached.
+	ErrStoreUnavailable = core.NewAPIError(core.ServerError, http.StatusServiceUnavailable,
+		"Service Unavailable", "The request could not be checked for duplicates.")
+)
+
+// ReplayedHeader marks replayed responses.
+const ReplayedHeader = "Idempotent-Replayed"
+
+// maxKeyLength bounds the length of idempotency keys.
+const maxKeyLength = 255
+
+// Guard enforces idempotency keys for the requests of a set of methods.
+type Guard struct {
+	store       Store
+	responder   *core.Responder
+	header      string
+	methods     map[string]bool
+	ttl         time.Duration
+	lockTimeout time.Duration
+	maxBodySize int
+	now         func() time.Time
+}
+
+// Option configures a Guard.
+type Option func(*Guard)
+
+// WithResponder writes error responses through responder instead of the
+// default core Responder.
+func WithResponder(responder *core.Responder) Option {
+	return func(g *Guard) {
+		g.responder = responder
+	}
+}
+
+// WithHeader sets the request header holding the key (default
+// "Idempotency-Key").
+func WithHeader(name string) Option {
+	return func(g *Guard) {
+		g.header = name
+	}
+}
+
+// WithMethods sets the request methods keys are enforced for (default POST
+// and PATCH).
+func WithMethods(methods ...string) Option {
+	return func(g *Guard) {
+		g.methods = make(map[string]bool, len(methods))
+		for _, method := range methods {
+			g.methods[method] = true
+		}
+	}
+}
+
+// WithTTL sets how long responses are replayed (default 24h).
+func WithTTL(ttl time.Duration) Option {
+	return func(g *Guard) {
+		if ttl > 0 {
+			g.ttl = ttl
+		}
+	}
+}
+
+// WithLockTimeout sets how long a key stays reserved by a request that never
+// completes, such as one whose process crashed (default 1m).
+func WithLockTimeout(d time.Duration) Option {
+	return func(g *Guard) {
+		if d > 0 {
+			g.lockTimeout = d
+		}
+	}
+}
+
+// WithMaxBodySize skips storing responses larger than size bytes (default
+// 1 MiB); retries of such requests run the handler again. Requests with a key
+// whose body is larger are rejected with 413 Payload Too Large.
+func WithMaxBodySize(size int) Option {
+	return func(g *Guard) {
+		if size > 0 {
+			g.maxBodySize = size
+		}
+	}
+}
+
+// WithClock sets the time source used for expiry.
+func WithClock(now func() time.Time) Option {
+	return func(g *Guard) {
+		g.now = now
+	}
+}
+
+// New creates a Guard keeping records in store.
+func New(store Store, opts ...Option) *Guard {
+	g := &Guard{
+		store:       store,
+		header:      "Idempotency-Key",
+		methods:     map[string]bool{http.MethodPost: true, http.MethodPatch: true},
+		ttl:         24 * time.Hour,
+		lockTimeout: time.Minute,
+		maxBodySize: 1 << 20,
+		now:         time.Now,
+	}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// MaxBodySize returns the size in bytes of the largest request body the guard
+// accepts with a key and of the largest response it stores for replay.
+func (g *Guard) MaxBodySize() int {
+	return g.maxBodySize
+}
+
+// Responder returns the Responder writing the guard's error responses.
+func (g *Guard) Responder() *core.Responder {
+	if g.responder == nil {
+		return core.Default()
+	}
+	return g.responder
+}
+
+// Pending is a request that reserved its idempotency key and is being
+// processed. Its response is stored with Finish.
+type Pending struct {
+	guard       *Guard
+	key         string
+	fingerprint string
+	done        bool
+}
+
+// Begin checks a request against its idempotency key. When the request must
+// not reach the handler, the response to send instead is returned: a replay
+// of the stored response, or an error for an invalid key, a body larger than
+// MaxBodySize, a request still in flight or a key reused with a different
+// request. Otherwise the returned Pending, nil for requests without a key,
+// must be finished with the handler's response.
+//
+// The fingerprint covers the method, path, Authorization header and body, so
+// a key reused by another client is rejected rather than replayed.
+func (g *Guard) Begin(method, path string, headers http.Header, body []byte) (*Pending, *core.Prepared) {
+	if !g.methods[method] {
+		return nil, nil
+	}
+	key, ok := headers[http.CanonicalHeaderKey(g.header)]
+	if !ok {
+		return nil, nil
+	}
+	if len(key) != 1 || key[0] == "" || len(key[0]) > maxKeyLength {
+		return nil, g.reject(headers, http.StatusBadRequest, ErrInvalidKey)
+	}
+	if len(body) > g.maxBodySize {
+		return nil, g.reject(headers, http.StatusRequestEntityTooLarge, ErrBodyTooLarge)
+	}
+
+	fingerprint := Fingerprint(method, path, headers.Get("Authorization"), body)
+	existing, reserved, err := g.store.Reserve(key[0], Record{
+		Fingerprint: fingerprint,
+		ExpiresAt:   g.now().Add(g.lockTimeout),
+	})
+	switch {
+	case err != nil:
+		g.Responder().Logger().Error("Failed to reserve idempotency key", zap.Error(err))
+		return nil, g.reject(headers, http.StatusServiceUnavailable, ErrStoreUnavailable)
+	case reserved:
+		return &Pending{guard: g, key: key[0], fingerprint: fingerprint}, nil
+	case existing.Fingerprint != fingerprint:
+		return nil, g.reject(headers, http.StatusUnprocessableEntity, ErrKeyReused)
+	case !existing.Completed:
+		return nil, g.reject(headers, http.StatusConflict, ErrRequestInFlight, core.WithRetryAfter(time.Second))
+	}
+
+	replay := &core.Prepared{StatusCode: existing.StatusCode, Header: existing.Header.Clone(), Body: existing.Body}
+	if replay.Header == nil {
+		replay.Header = make(http.Header)
+	}
+	replay.Header.Set(ReplayedHeader, "true")
+	return nil, replay
+}
+
+// Finish stores the handler's response for replay, without its
+// Content-Length, Date and Set-Cookie headers. Server errors and responses too
+// large to store release the key instead, so a retry runs the handler again.
+// Finishing a nil Pending does nothing.
+func (p *Pending) Finish(statusCode int, header http.Header, body []byte) error {
+	if p == nil || p.done {
+		return nil
+	}
+	p.done = true
+	g := p.guard
+	if statusCode >= 500 || len(body) > g.maxBodySize {
+		return g.store.Delete(p.key)
+	}
+	header = header.Clone()
+	for _, name := range []string{"Content-Length", "Date", "Set-Cookie"} {
+		header.Del(name)
+	}
+	return g.store.Save(p.key, Record{
+		Fingerprint: p.fingerprint,
+		Completed:   true,
+		StatusCode:  statusCode,
+		Header:      header,
+		Body:        append([]byte(nil), body...),
+		ExpiresAt:   g.now().Add(g.ttl),
+	})
+}
+
+// Abandon releases the key of a request that ended without a response, such
+// as one whose handler panicked. It does nothing once the request finished.
+func (p *Pending) Abandon() {
+	if p == nil || p.done {
+		return
+	}
+	p.done = true
+	_ = p.guard.store.Delete(p.key)
+}
+
+// reject prepares the error response sent instead of running the handler.
+func (g *Guard) reject(headers http.Header, statusCode int, apiErr core.APIError, opts ...core.ResponseOption) *core.Prepared {
+	responder := g.Responder()
+	traceID := headers.Get("X-Trace-ID")
+	if traceID == "" {
+		traceID = responder.NewTraceID()
+	}
+	resp := responder.NewAPIErrorResponse(statusCode, traceID, apiErr)
+	for _, opt := range opts {
+		opt(&resp)
+	}
+	prepared, _ := responder.Prepare(statusCode, resp)
+	return prepared
+}
+
+// Fingerprint hashes the parts of a request that must match for a retry to
+// be replayed.
+func Fingerprint(method, path, authorization string, body []byte) string {
+	h := sha256.New()
+	for _, part := range []string{method, path, authorization} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process Store. Expired records are ignored as soon as
+// they expire and removed by Cleanup.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu      sync.Mutex
+	records map[string]Record
+}
+
+// StoreOption configures a MemoryStore or FileStore.
+type StoreOption func(*storeConfig)
+
+type storeConfig struct {
+	now func() time.Time
+}
+
+// WithStoreClock sets the time source used for expiry.
+func WithStoreClock(now func() time.Time) StoreOption {
+	return func(c *storeConfig) {
+		c.now = now
+	}
+}
+
+func newStoreConfig(opts []StoreOption) storeConfig {
+	c := storeConfig{now: time.Now}
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return c
+}
+
+// NewMemoryStore creates an empty in-memory store.
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	return &MemoryStore{now: newStoreConfig(opts).now, records: make(map[string]Record)}
+}
+
+// Reserve implements Store.
+func (s *MemoryStore) Reserve(key string, rec Record) (Record, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if existing, ok := s.records[key]; ok && !expired(existing, s.now()) {
+		return existing, false, nil
+	}
+	s.records[key] = rec
+	return Record{}, true, nil
+}
+
+// Save implements Store.
+func (s *MemoryStore) Save(key string, rec Record) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[key] = rec
+	return nil
+}
+
+// Delete implements Store.
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.records, key)
+	return nil
+}
+
+// Len returns the number of stored records, including expired ones not yet
+// removed.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.records)
+}
+
+// Cleanup removes expired records and returns how many were removed.
+func (s *MemoryStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	removed := 0
+	for key, rec := range s.records {
+		if expired(rec, now) {
+			delete(s.records, key)
+			removed++
+		}
+	}
+	return removed
+}
+
+// StartCleanup runs Cleanup every interval until the returned function is
+// called.
+func (s *MemoryStore) StartCleanup(interval time.Duration) (stop func()) {
+	return startCleanup(interval, func() { s.Cleanup() })
+}
+
+// startCleanup calls cleanup every interval until the returned function is
+// called.
+func startCleanup(interval time.Duration, cleanup func()) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				cleanup()
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func expired(rec Record, now time.Time) bool {
+	return !now.Before(rec.ExpiresAt)
+}
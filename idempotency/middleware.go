@@ -0,0 +1,102 @@
+package idempotency
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"go.uber.org/zap"
+)
+
+// errUnreadableBody is the error sent when the request body cannot be read.
+var errUnreadableBody = core.NewAPIError(core.ClientError, http.StatusBadRequest,
+	"Bad Request", "The request body could not be read.")
+
+// Middleware enforces idempotency keys for the requests of next: duplicates
+// are answered with the stored response and first requests are recorded for
+// replay.
+func (g *Guard) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pending, prepared := g.BeginRequest(r)
+		if prepared != nil {
+			_ = prepared.Write(w)
+			return
+		}
+		if pending == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer pending.Abandon()
+		rec := &recorder{ResponseWriter: w, statusCode: http.StatusOK, limit: g.MaxBodySize()}
+		next.ServeHTTP(rec, r)
+		if rec.overflow {
+			return // Too large to store; Abandon releases the key
+		}
+		if err := pending.Finish(rec.statusCode, w.Header(), rec.body.Bytes()); err != nil {
+			g.Responder().Logger().Error("Failed to store idempotent response", zap.Error(err))
+		}
+	})
+}
+
+// BeginRequest is Begin for a net/http request. The body of a request with a
+// key is read for the fingerprint, up to one byte past MaxBodySize so Begin
+// can reject larger bodies, and replaced, so handlers can still read it.
+func (g *Guard) BeginRequest(r *http.Request) (*Pending, *core.Prepared) {
+	if !g.methods[r.Method] {
+		return nil, nil
+	}
+	if _, ok := r.Header[http.CanonicalHeaderKey(g.header)]; !ok {
+		return nil, nil
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, int64(g.maxBodySize)+1))
+	if err != nil {
+		return nil, g.reject(r.Header, http.StatusBadRequest, errUnreadableBody)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	return g.Begin(r.Method, r.URL.Path, r.Header, body)
+}
+
+// recorder copies a response while it is written, up to limit bytes.
+type recorder struct {
+	http.ResponseWriter
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+	limit       int
+	overflow    bool
+}
+
+func (rec *recorder) WriteHeader(code int) {
+	if !rec.wroteHeader {
+		rec.statusCode = code
+		rec.wroteHeader = true
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *recorder) Write(p []byte) (int, error) {
+	rec.wroteHeader = true
+	if !rec.overflow {
+		if rec.body.Len()+len(p) > rec.limit {
+			rec.overflow = true
+			rec.body = bytes.Buffer{}
+		} else {
+			rec.body.Write(p)
+		}
+	}
+	return rec.ResponseWriter.Write(p)
+}
+
+// Flush sends buffered data to the client, when the underlying writer
+// supports it.
+func (rec *recorder) Flush() {
+	rec.wroteHeader = true
+	if flusher, ok := rec.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (rec *recorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
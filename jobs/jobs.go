@@ -0,0 +1,74 @@
+// Package jobs tracks long-running operations that are answered with 202
+// Accepted, and renders the status of each job for clients polling its
+// status URL.
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+)
+
+// State is the lifecycle state of a job.
+type State string
+
+const (
+	Pending   State = "pending"   // Accepted but not started
+	Running   State = "running"   // Being processed
+	Succeeded State = "succeeded" // Finished with a result
+	Failed    State = "failed"    // Finished with an error
+)
+
+// Done reports whether the state is final.
+func (s State) Done() bool {
+	return s == Succeeded || s == Failed
+}
+
+// Job is the status of a long-running operation.
+type Job struct {
+	ID        string         `json:"id"`
+	State     State          `json:"state"`
+	Result    interface{}    `json:"result,omitempty"`
+	Error     *core.APIError `json:"error,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	ExpiresAt time.Time      `json:"expires_at"` // The job is forgotten after this time
+}
+
+// JobStore persists jobs. Stores must not return jobs whose ExpiresAt has
+// passed, and may delete them at any time.
+type JobStore interface {
+	// Save creates or replaces a job.
+	Save(job Job) error
+	// Get returns a job, or ErrNotFound when it does not exist or expired.
+	Get(id string) (Job, error)
+	// Delete removes a job. Deleting a missing job is not an error.
+	Delete(id string) error
+}
+
+var (
+	// ErrNotFound is returned for unknown and expired jobs.
+	ErrNotFound = errors.New("jobs: job not found")
+	// ErrFinished is returned when changing the state of a finished job.
+	ErrFinished = errors.New("jobs: job already finished")
+)
+
+// ErrJobNotFound is the error sent for the status URL of an unknown or
+// expired job.
+var ErrJobNotFound = core.NewAPIError(core.ClientError, http.StatusNotFound,
+	"Not Found", "The job does not exist or has expired.")
+
+// ErrJobUnavailable is the error sent when the job store cannot be read.
+var ErrJobUnavailable = core.NewAPIError(core.ServerError, http.StatusServiceUnavailable,
+	"Service Unavailable", "The job status is temporarily unavailable.")
+
+// LookupError returns the status and error to send when looking up a job
+// failed with err.
+func LookupError(err error) (int, core.APIError) {
+	if errors.Is(err, ErrNotFound) {
+		return http.StatusNotFound, ErrJobNotFound
+	}
+	return http.StatusServiceUnavailable, ErrJobUnavailable
+}
@@ -0,0 +1,108 @@
+package jobs
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-process JobStore. Expired jobs are hidden as soon as
+// they expire and removed by Cleanup.
+type MemoryStore struct {
+	now func() time.Time
+
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// StoreOption configures a MemoryStore.
+type StoreOption func(*MemoryStore)
+
+// WithStoreClock sets the time source used for expiry.
+func WithStoreClock(now func() time.Time) StoreOption {
+	return func(s *MemoryStore) {
+		s.now = now
+	}
+}
+
+// NewMemoryStore creates an empty in-memory job store.
+func NewMemoryStore(opts ...StoreOption) *MemoryStore {
+	s := &MemoryStore{now: time.Now, jobs: make(map[string]Job)}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Save implements JobStore.
+func (s *MemoryStore) Save(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+// Get implements JobStore.
+func (s *MemoryStore) Get(id string) (Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	job, ok := s.jobs[id]
+	if !ok || s.expired(job, s.now()) {
+		return Job{}, ErrNotFound
+	}
+	return job, nil
+}
+
+// Delete implements JobStore.
+func (s *MemoryStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.jobs, id)
+	return nil
+}
+
+// Len returns the number of stored jobs, including expired ones not yet
+// removed.
+func (s *MemoryStore) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.jobs)
+}
+
+// Cleanup removes expired jobs and returns how many were removed.
+func (s *MemoryStore) Cleanup() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	removed := 0
+	for id, job := range s.jobs {
+		if s.expired(job, now) {
+			delete(s.jobs, id)
+			removed++
+		}
+	}
+	return removed
+}
+
+// StartCleanup runs Cleanup every interval until the returned function is
+// called.
+func (s *MemoryStore) StartCleanup(interval time.Duration) (stop func()) {
+	done := make(chan struct{})
+	var once sync.Once
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				s.Cleanup()
+			}
+		}
+	}()
+	return func() { once.Do(func() { close(done) }) }
+}
+
+func (s *MemoryStore) expired(job Job, now time.Time) bool {
+	return !job.ExpiresAt.IsZero() && !now.Before(job.ExpiresAt)
+}
@@ -0,0 +1,200 @@
+package jobs
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+)
+
+// Tracker creates jobs in a JobStore, moves them through their states and
+// renders the responses announcing and reporting them. A job is expected to be
+// advanced by the single worker processing it.
+type Tracker struct {
+	store      JobStore
+	statusPath string
+	retryAfter time.Duration
+	ttl        time.Duration
+	now        func() time.Time
+}
+
+// Option configures a Tracker.
+type Option func(*Tracker)
+
+// WithStatusPath sets the path under which job status URLs are served
+// (default "/jobs/"). The status URL of a job is the path followed by its ID.
+func WithStatusPath(path string) Option {
+	return func(t *Tracker) {
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		t.statusPath = path
+	}
+}
+
+// WithRetryAfter sets the polling interval suggested to clients of unfinished
+// jobs in the Retry-After header (default 5s).
+func WithRetryAfter(d time.Duration) Option {
+	return func(t *Tracker) {
+		t.retryAfter = d
+	}
+}
+
+// WithTTL sets how long a job is kept after its last change (default 24h).
+func WithTTL(ttl time.Duration) Option {
+	return func(t *Tracker) {
+		if ttl > 0 {
+			t.ttl = ttl
+		}
+	}
+}
+
+// WithClock sets the time source used for job timestamps and expiry.
+func WithClock(now func() time.Time) Option {
+	return func(t *Tracker) {
+		t.now = now
+	}
+}
+
+// NewTracker creates a Tracker keeping jobs in store.
+func NewTracker(store JobStore, opts ...Option) *Tracker {
+	t := &Tracker{
+		store:      store,
+		statusPath: "/jobs/",
+		retryAfter: 5 * time.Second,
+		ttl:        24 * time.Hour,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(t)
+	}
+	return t
+}
+
+// Create saves a new pending job.
+func (t *Tracker) Create() (Job, error) {
+	id, err := newJobID()
+	if err != nil {
+		return Job{}, err
+	}
+	now := t.now()
+	job := Job{ID: id, State: Pending, CreatedAt: now, UpdatedAt: now, ExpiresAt: now.Add(t.ttl)}
+	if err := t.store.Save(job); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// Get returns a job, or ErrNotFound when it does not exist or expired.
+func (t *Tracker) Get(id string) (Job, error) {
+	if id == "" {
+		return Job{}, ErrNotFound
+	}
+	return t.store.Get(id)
+}
+
+// Start marks a job as running.
+func (t *Tracker) Start(id string) (Job, error) {
+	return t.update(id, func(job *Job) {
+		job.State = Running
+	})
+}
+
+// Succeed finishes a job with its result.
+func (t *Tracker) Succeed(id string, result interface{}) (Job, error) {
+	return t.update(id, func(job *Job) {
+		job.State, job.Result = Succeeded, result
+	})
+}
+
+// Fail finishes a job with the error reported to clients.
+func (t *Tracker) Fail(id string, apiErr core.APIError) (Job, error) {
+	return t.update(id, func(job *Job) {
+		job.State, job.Error = Failed, &apiErr
+	})
+}
+
+// update applies change to an unfinished job and saves it with a renewed
+// expiry.
+func (t *Tracker) update(id string, change func(*Job)) (Job, error) {
+	job, err := t.store.Get(id)
+	if err != nil {
+		return Job{}, err
+	}
+	if job.State.Done() {
+		return Job{}, ErrFinished
+	}
+	change(&job)
+	job.UpdatedAt = t.now()
+	job.ExpiresAt = job.UpdatedAt.Add(t.ttl)
+	if err := t.store.Save(job); err != nil {
+		return Job{}, err
+	}
+	return job, nil
+}
+
+// StatusURL returns the URL polled for the status of a job.
+func (t *Tracker) StatusURL(id string) string {
+	return t.statusPath + url.PathEscape(id)
+}
+
+// JobID returns the ID of the job whose status URL is path, or "" when path
+// is not a status URL.
+func (t *Tracker) JobID(path string) string {
+	escaped, ok := strings.CutPrefix(path, t.statusPath)
+	if !ok || escaped == "" || strings.Contains(escaped, "/") {
+		return ""
+	}
+	id, err := url.PathUnescape(escaped)
+	if err != nil {
+		return ""
+	}
+	return id
+}
+
+// Options returns the response options of a response reporting job: a
+// "monitor" link and Location header pointing at its status URL and, until
+// the job finishes, a Retry-After hint and Cache-Control: no-store.
+func (t *Tracker) Options(job Job) []core.ResponseOption {
+	opts := []core.ResponseOption{core.WithStatusMonitor(t.StatusURL(job.ID))}
+	if !job.State.Done() {
+		opts = append(opts,
+			core.WithRetryAfter(t.retryAfter),
+			core.WithCachePolicy(core.CachePolicy{NoStore: true}),
+		)
+	}
+	return opts
+}
+
+// NewAcceptedResponse creates the response, sent with 202 Accepted, announcing
+// a new job. Its data is the job and it points at the job's status URL.
+func (t *Tracker) NewAcceptedResponse(r *core.Responder, traceID string, job Job) core.StandardResponse {
+	return t.newResponse(r, traceID, "Accepted", job)
+}
+
+// NewStatusResponse creates the response, sent with 200 OK, reporting the
+// state of a job. Failed jobs embed the APIError they failed with; the
+// response itself is a success, as the status was read.
+func (t *Tracker) NewStatusResponse(r *core.Responder, traceID string, job Job) core.StandardResponse {
+	return t.newResponse(r, traceID, "Job "+string(job.State), job)
+}
+
+func (t *Tracker) newResponse(r *core.Responder, traceID, message string, job Job) core.StandardResponse {
+	resp := r.NewSuccessResponse(traceID, message, job)
+	for _, opt := range t.Options(job) {
+		opt(&resp)
+	}
+	return resp
+}
+
+// newJobID returns a random, unguessable job ID.
+func newJobID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b[:]), nil
+}
@@ -0,0 +1,100 @@
+// Package jsonapi renders core.StandardResponse values as JSON:API 1.1
+// documents (https://jsonapi.org).
+package jsonapi
+
+import jsoniter "github.com/json-iterator/go"
+
+var json = jsoniter.ConfigCompatibleWithStandardLibrary
+
+// MediaType is the JSON:API media type.
+const MediaType = "application/vnd.api+json"
+
+// Document is a top-level JSON:API document. It holds either Data or Errors.
+type Document struct {
+	Data     interface{}            // *Resource, []*Resource or nil
+	Errors   []ErrorObject          // Set instead of Data for error documents
+	Included []*Resource            // Related resources of a compound document
+	Links    map[string]string      // Top-level links such as self or next
+	Meta     map[string]interface{} // Non-standard information
+}
+
+// MarshalJSON emits "data" (possibly null) for success documents and omits it
+// when the document carries errors.
+func (d Document) MarshalJSON() ([]byte, error) {
+	doc := map[string]interface{}{
+		"jsonapi": map[string]string{"version": "1.1"},
+	}
+	if len(d.Errors) > 0 {
+		doc["errors"] = d.Errors
+	} else {
+		doc["data"] = d.Data
+	}
+	if len(d.Included) > 0 {
+		doc["included"] = d.Included
+	}
+	if len(d.Links) > 0 {
+		doc["links"] = d.Links
+	}
+	if len(d.Meta) > 0 {
+		doc["meta"] = d.Meta
+	}
+	return json.Marshal(doc)
+}
+
+// Resource is a JSON:API resource object.
+type Resource struct {
+	Type          string                  `json:"type"`
+	ID            string                  `json:"id"`
+	Attributes    map[string]interface{}  `json:"attributes,omitempty"`
+	Relationships map[string]Relationship `json:"relationships,omitempty"`
+	Links         map[string]string       `json:"links,omitempty"`
+	Meta          map[string]interface{}  `json:"meta,omitempty"`
+}
+
+// Identifier is a resource identifier object used in relationships.
+type Identifier struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+// Relationship is a JSON:API relationship object. Data is nil, an Identifier
+// or a []Identifier; nil to-one relationships are emitted as null.
+type Relationship struct {
+	Data  interface{}       `json:"data"`
+	Links map[string]string `json:"links,omitempty"`
+}
+
+// ErrorObject is a JSON:API error object.
+type ErrorObject struct {
+	ID     string                 `json:"id,omitempty"`
+	Status string                 `json:"status,omitempty"`
+	Code   string                 `json:"code,omitempty"`
+	Title  string                 `json:"title,omitempty"`
+	Detail string                 `json:"detail,omitempty"`
+	Source *ErrorSource           `json:"source,omitempty"`
+	Meta   map[string]interface{} `json:"meta,omitempty"`
+}
+
+// ErrorSource points at the part of the request that caused an error.
+type ErrorSource struct {
+	Pointer   string `json:"pointer,omitempty"`
+	Parameter string `json:"parameter,omitempty"`
+	Header    string `json:"header,omitempty"`
+}
+
+// Linker is implemented by resource structs that provide resource-level links.
+type Linker interface {
+	JSONAPILinks() map[string]string
+}
+
+// Metaer is implemented by resource structs that provide resource-level meta.
+type Metaer interface {
+	JSONAPIMeta() map[string]interface{}
+}
+
+// Payload wraps response data with top-level links and meta.
+type Payload struct {
+	Data  interface{}
+	Links map[string]string
+	Meta  map[string]interface{}
+}
@@ -0,0 +1,329 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// Struct tags describing resources:
+//
+//	type Article struct {
+//		ID       int       `jsonapi:"primary,articles"`
+//		Title    string    `jsonapi:"attr,title"`
+//		Summary  string    `jsonapi:"attr,summary,omitempty"`
+//		Author   *Person   `jsonapi:"relation,author"`
+//		Comments []Comment `jsonapi:"relation,comments"`
+//	}
+//
+// Fields without a jsonapi tag are not rendered.
+const tagName = "jsonapi"
+
+// maxDepth bounds how deeply relationships are followed, guarding against
+// chains of distinct resources generated without end.
+const maxDepth = 32
+
+// ErrNotResource is returned by Marshal for data that is not made of
+// jsonapi-tagged structs, such as maps, scalars and untagged structs.
+var ErrNotResource = errors.New("jsonapi: data is not a resource")
+
+// Marshal converts data into primary data and the related resources to
+// include. data may be a tagged struct, a pointer to one, or a slice of them;
+// nil yields null primary data. Other data returns an error wrapping
+// ErrNotResource.
+func Marshal(data interface{}) (primary interface{}, included []*Resource, err error) {
+	c := collector{seen: make(map[Identifier]bool), expanded: make(map[Identifier]bool)}
+
+	v := reflect.ValueOf(data)
+	for v.IsValid() && (v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface) {
+		if v.IsNil() {
+			return nil, nil, nil
+		}
+		v = v.Elem()
+	}
+	if !v.IsValid() {
+		return nil, nil, nil
+	}
+	if !isResource(v) {
+		return nil, nil, fmt.Errorf("%w: cannot render %s", ErrNotResource, v.Type())
+	}
+
+	switch v.Kind() {
+	case reflect.Slice, reflect.Array:
+		resources := make([]*Resource, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			resource, err := c.resource(v.Index(i))
+			if err != nil {
+				return nil, nil, err
+			}
+			resources = append(resources, resource)
+		}
+		for _, r := range resources {
+			c.seen[Identifier{r.Type, r.ID}] = true
+		}
+		primary = resources
+	default:
+		resource, err := c.resource(v)
+		if err != nil {
+			return nil, nil, err
+		}
+		c.seen[Identifier{resource.Type, resource.ID}] = true
+		primary = resource
+	}
+
+	for _, r := range c.related {
+		if !c.seen[Identifier{r.Type, r.ID}] {
+			c.seen[Identifier{r.Type, r.ID}] = true
+			included = append(included, r)
+		}
+	}
+	return primary, included, nil
+}
+
+// isResource reports whether v is a jsonapi-tagged struct or a slice of them.
+// Slices of interfaces are resources when every element is.
+func isResource(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct:
+		return isResourceType(v.Type())
+	case reflect.Slice, reflect.Array:
+		elem := v.Type().Elem()
+		for elem.Kind() == reflect.Pointer {
+			elem = elem.Elem()
+		}
+		if elem.Kind() != reflect.Interface {
+			return isResourceType(elem)
+		}
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			for item.Kind() == reflect.Pointer || item.Kind() == reflect.Interface {
+				if item.IsNil() {
+					return false
+				}
+				item = item.Elem()
+			}
+			if item.Kind() != reflect.Struct || !isResourceType(item.Type()) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
+
+// isResourceType reports whether t is a struct with jsonapi-tagged fields.
+func isResourceType(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	for i := 0; i < t.NumField(); i++ {
+		if _, ok := parseTag(t.Field(i).Tag.Get(tagName)); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// collector accumulates related resources while marshaling.
+type collector struct {
+	related  []*Resource
+	seen     map[Identifier]bool
+	expanded map[Identifier]bool // Resources whose relationships were followed
+	depth    int
+}
+
+type fieldTag struct {
+	kind      string // primary, attr or relation
+	name      string
+	omitEmpty bool
+}
+
+func parseTag(tag string) (fieldTag, bool) {
+	if tag == "" || tag == "-" {
+		return fieldTag{}, false
+	}
+	parts := strings.Split(tag, ",")
+	ft := fieldTag{kind: parts[0]}
+	if len(parts) > 1 {
+		ft.name = parts[1]
+	}
+	for _, opt := range parts[2:] {
+		if opt == "omitempty" {
+			ft.omitEmpty = true
+		}
+	}
+	return ft, true
+}
+
+func (c *collector) resource(v reflect.Value) (*Resource, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil, fmt.Errorf("jsonapi: nil resource")
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("jsonapi: cannot render %s as a resource", v.Type())
+	}
+	if c.depth >= maxDepth {
+		return nil, fmt.Errorf("jsonapi: relationships nested deeper than %d", maxDepth)
+	}
+	c.depth++
+	defer func() { c.depth-- }()
+
+	t := v.Type()
+	resource := &Resource{}
+	if id, ok := identify(v); ok {
+		c.expanded[id] = true
+	}
+	hasPrimary := false
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag, ok := parseTag(field.Tag.Get(tagName))
+		if !ok {
+			continue
+		}
+		value := v.Field(i)
+
+		switch tag.kind {
+		case "primary":
+			resource.Type = tag.name
+			resource.ID = formatID(value)
+			hasPrimary = true
+		case "attr":
+			if tag.omitEmpty && value.IsZero() {
+				continue
+			}
+			if resource.Attributes == nil {
+				resource.Attributes = make(map[string]interface{})
+			}
+			resource.Attributes[tag.name] = value.Interface()
+		case "relation":
+			rel, err := c.relationship(value, tag.omitEmpty)
+			if err != nil {
+				return nil, fmt.Errorf("jsonapi: %s.%s: %w", t.Name(), field.Name, err)
+			}
+			if rel == nil {
+				continue
+			}
+			if resource.Relationships == nil {
+				resource.Relationships = make(map[string]Relationship)
+			}
+			resource.Relationships[tag.name] = *rel
+		default:
+			return nil, fmt.Errorf("jsonapi: %s.%s: unknown tag kind %q", t.Name(), field.Name, tag.kind)
+		}
+	}
+	if !hasPrimary {
+		return nil, fmt.Errorf("jsonapi: %s has no primary field", t)
+	}
+
+	if v.CanAddr() {
+		v = v.Addr()
+	}
+	if linker, ok := v.Interface().(Linker); ok {
+		resource.Links = linker.JSONAPILinks()
+	}
+	if metaer, ok := v.Interface().(Metaer); ok {
+		resource.Meta = metaer.JSONAPIMeta()
+	}
+	return resource, nil
+}
+
+// relationship builds a relationship object and records related resources.
+// It returns nil for empty relationships tagged omitempty.
+func (c *collector) relationship(v reflect.Value, omitEmpty bool) (*Relationship, error) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if omitEmpty {
+				return nil, nil
+			}
+			return &Relationship{Data: nil}, nil
+		}
+		v = v.Elem()
+	}
+
+	if v.Kind() == reflect.Slice || v.Kind() == reflect.Array {
+		if omitEmpty && v.Len() == 0 {
+			return nil, nil
+		}
+		ids := make([]Identifier, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			id, err := c.relate(v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return &Relationship{Data: ids}, nil
+	}
+
+	id, err := c.relate(v)
+	if err != nil {
+		return nil, err
+	}
+	return &Relationship{Data: id}, nil
+}
+
+// relate records a related resource and returns its identifier. Resources
+// already being rendered are only referenced, so cyclic relationships such
+// as an author listing their articles end.
+func (c *collector) relate(v reflect.Value) (Identifier, error) {
+	if id, ok := identify(v); ok && c.expanded[id] {
+		return id, nil
+	}
+	related, err := c.resource(v)
+	if err != nil {
+		return Identifier{}, err
+	}
+	c.related = append(c.related, related)
+	return Identifier{related.Type, related.ID}, nil
+}
+
+// identify returns the identifier of a resource from its primary field.
+func identify(v reflect.Value) (Identifier, bool) {
+	for v.Kind() == reflect.Pointer || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return Identifier{}, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return Identifier{}, false
+	}
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if tag, ok := parseTag(t.Field(i).Tag.Get(tagName)); ok && tag.kind == "primary" && t.Field(i).IsExported() {
+			return Identifier{tag.name, formatID(v.Field(i))}, true
+		}
+	}
+	return Identifier{}, false
+}
+
+func formatID(v reflect.Value) string {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.String:
+		return v.String()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10)
+	default:
+		if s, ok := v.Interface().(fmt.Stringer); ok {
+			return s.String()
+		}
+		return fmt.Sprint(v.Interface())
+	}
+}
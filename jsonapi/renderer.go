@@ -0,0 +1,153 @@
+package jsonapi
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/andreascandle/FlexiResponseGo/core"
+)
+
+// Renderer renders responses as JSON:API documents. Register it with
+// core.WithRenderer to serve clients sending "Accept: application/vnd.api+json",
+// or with core.WithRouteRenderer to use it on specific routes.
+type Renderer struct{}
+
+// ContentType implements core.Renderer.
+func (Renderer) ContentType() string {
+	return MediaType
+}
+
+// Render implements core.Renderer. Success responses carry their data as
+// resources; error responses become an errors array, with one error per
+// field error pointing at the offending attribute. The message, trace ID and
+// metadata are reported in the top-level meta, and response links become
+// top-level links. Data that is not made of jsonapi-tagged structs, such as a
+// map, is reported under the "data" member of the top-level meta with null
+// primary data.
+func (Renderer) Render(statusCode int, resp core.StandardResponse) (interface{}, error) {
+	doc := Document{Meta: topLevelMeta(resp)}
+
+	if resp.Status == "error" {
+		doc.Errors = errorObjects(statusCode, resp)
+		return doc, nil
+	}
+
+	if len(resp.Links) > 0 {
+		doc.Links = make(map[string]string, len(resp.Links))
+		for rel, link := range resp.Links {
+			doc.Links[rel] = link.Href
+		}
+	}
+
+	data := resp.Data
+	if payload, ok := data.(Payload); ok {
+		data = payload.Data
+		for rel, href := range payload.Links {
+			if doc.Links == nil {
+				doc.Links = make(map[string]string, len(payload.Links))
+			}
+			doc.Links[rel] = href
+		}
+		for k, v := range payload.Meta {
+			doc.Meta[k] = v
+		}
+	}
+	primary, included, err := Marshal(data)
+	if errors.Is(err, ErrNotResource) {
+		// Data that is not modelled as resources is still served, as
+		// top-level meta beside null primary data.
+		doc.Meta["data"] = data
+		return doc, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc.Data = primary
+	doc.Included = included
+	return doc, nil
+}
+
+func topLevelMeta(resp core.StandardResponse) map[string]interface{} {
+	meta := make(map[string]interface{}, len(resp.Metadata)+3)
+	for k, v := range resp.Metadata {
+		meta[k] = v
+	}
+	if resp.Message != "" {
+		meta["message"] = resp.Message
+	}
+	if resp.MessageKey != "" {
+		meta["message_key"] = resp.MessageKey
+	}
+	if resp.TraceID != "" {
+		meta["trace_id"] = resp.TraceID
+	}
+	return meta
+}
+
+// errorObjects maps a response's error and field errors to JSON:API errors.
+func errorObjects(statusCode int, resp core.StandardResponse) []ErrorObject {
+	status := strconv.Itoa(statusCode)
+	code := ""
+	if c, ok := resp.Metadata["code"]; ok {
+		code = fmt.Sprint(c)
+	}
+
+	if len(resp.FieldErrors) == 0 {
+		return []ErrorObject{{
+			ID:     resp.TraceID,
+			Status: status,
+			Code:   code,
+			Title:  resp.Message,
+			Detail: resp.Error,
+		}}
+	}
+
+	fields := make([]string, 0, len(resp.FieldErrors))
+	for field := range resp.FieldErrors {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	var errs []ErrorObject
+	for _, field := range fields {
+		source := &ErrorSource{Pointer: "/data/attributes/" + pointerPath(field)}
+		for _, detail := range details(resp.FieldErrors[field]) {
+			errs = append(errs, ErrorObject{
+				Status: status,
+				Code:   code,
+				Title:  resp.Message,
+				Detail: detail,
+				Source: source,
+			})
+		}
+	}
+	return errs
+}
+
+// pointerPath converts a dotted field name into JSON Pointer segments.
+func pointerPath(field string) string {
+	segments := strings.Split(field, ".")
+	for i, s := range segments {
+		s = strings.ReplaceAll(s, "~", "~0")
+		segments[i] = strings.ReplaceAll(s, "/", "~1")
+	}
+	return strings.Join(segments, "/")
+}
+
+func details(value interface{}) []string {
+	switch v := value.(type) {
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, len(v))
+		for i, item := range v {
+			out[i] = fmt.Sprint(item)
+		}
+		return out
+	default:
+		return []string{fmt.Sprint(v)}
+	}
+}
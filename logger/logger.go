@@ -2,15 +2,21 @@ package logger
 
 import (
 	"sync"
+	"sync/atomic"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
 )
 
 type Logger struct {
 	zapLogger *zap.Logger
 	config    Config
 	mu        sync.RWMutex
+	// newCore overrides the output core, such as for in-memory test loggers.
+	newCore func(level zapcore.Level) zapcore.Core
+	// observed holds the entries recorded by NewObserved loggers.
+	observed *observer.ObservedLogs
 }
 
 type Config struct {
@@ -27,17 +33,40 @@ func DefaultConfig() Config {
 }
 
 var (
-	globalLogger *Logger
+	globalLogger atomic.Pointer[Logger]
 	once         sync.Once
 )
 
+// New creates a standalone logger with the given configuration.
+func New(cfg Config) *Logger {
+	l := &Logger{}
+	l.configure(cfg)
+	return l
+}
+
+// NewWithCore creates a standalone logger writing to the core returned by
+// newCore for the configured level, such as an in-memory core for tests.
+func NewWithCore(cfg Config, newCore func(level zapcore.Level) zapcore.Core) *Logger {
+	l := &Logger{newCore: newCore}
+	l.configure(cfg)
+	return l
+}
+
 // GetLogger initializes or returns the singleton logger instance.
 func GetLogger() *Logger {
 	once.Do(func() {
-		globalLogger = &Logger{}
-		globalLogger.configure(DefaultConfig())
+		globalLogger.Store(New(DefaultConfig()))
 	})
-	return globalLogger
+	return globalLogger.Load()
+}
+
+// ReplaceGlobal swaps the singleton logger and returns a function restoring the previous one.
+func ReplaceGlobal(l *Logger) func() {
+	previous := GetLogger()
+	globalLogger.Store(l)
+	return func() {
+		globalLogger.Store(previous)
+	}
 }
 
 // configure initializes the logger based on the given config.
@@ -59,6 +88,12 @@ func (l *Logger) configure(cfg Config) {
 		level = zap.InfoLevel
 	}
 
+	if l.newCore != nil {
+		l.zapLogger = zap.New(l.newCore(level))
+		l.config = cfg
+		return
+	}
+
 	var zapCfg zap.Config
 	if cfg.Environment == "development" {
 		zapCfg = zap.NewDevelopmentConfig()
@@ -84,34 +119,47 @@ func (l *Logger) UpdateConfig(cfg Config) {
 	l.configure(cfg)
 }
 
+// Enabled reports whether messages at level are logged, letting callers skip
+// building expensive fields.
+func (l *Logger) Enabled(level zapcore.Level) bool {
+	return l.zap().Core().Enabled(level)
+}
+
+// zap returns the current zap logger, which UpdateConfig may swap.
+func (l *Logger) zap() *zap.Logger {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.zapLogger
+}
+
 // Debug logs a debug message.
 func (l *Logger) Debug(msg string, fields ...zap.Field) {
-	l.zapLogger.Debug(msg, fields...)
+	l.zap().Debug(msg, fields...)
 }
 
 // Info logs an informational message.
 func (l *Logger) Info(msg string, fields ...zap.Field) {
-	l.zapLogger.Info(msg, fields...)
+	l.zap().Info(msg, fields...)
 }
 
 // Warn logs a warning message.
 func (l *Logger) Warn(msg string, fields ...zap.Field) {
-	l.zapLogger.Warn(msg, fields...)
+	l.zap().Warn(msg, fields...)
 }
 
 // Error logs an error message.
 func (l *Logger) Error(msg string, fields ...zap.Field) {
-	l.zapLogger.Error(msg, fields...)
+	l.zap().Error(msg, fields...)
 }
 
 // Fatal logs a fatal message and exits the application.
 func (l *Logger) Fatal(msg string, fields ...zap.Field) {
-	l.zapLogger.Fatal(msg, fields...)
+	l.zap().Fatal(msg, fields...)
 }
 
 // Sync flushes any buffered log entries.
 func (l *Logger) Sync() {
-	_ = l.zapLogger.Sync()
+	_ = l.zap().Sync()
 }
 
 // LogHTTPRequest logs details of an HTTP request.
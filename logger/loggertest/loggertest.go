@@ -0,0 +1,33 @@
+// Package loggertest provides in-memory loggers for asserting on log output
+// in tests. It wraps logger.NewObserved and logger.NewForTest.
+package loggertest
+
+import (
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/logger"
+)
+
+// Logger is a logger.Logger that records entries in memory for assertions
+// with its AssertLogged and AssertNotLogged methods.
+type Logger struct {
+	*logger.Logger
+}
+
+// New creates a debug-level logger backed by an in-memory observer.
+func New(t testing.TB) *Logger {
+	t.Helper()
+	return &Logger{Logger: logger.NewObserved()}
+}
+
+// Use installs l as the global logger until the test finishes.
+func Use(t testing.TB, l *logger.Logger) {
+	t.Helper()
+	t.Cleanup(logger.ReplaceGlobal(l))
+}
+
+// ObserveGlobal creates a test logger and installs it globally for the test.
+func ObserveGlobal(t testing.TB) *Logger {
+	t.Helper()
+	return &Logger{Logger: logger.NewForTest(t)}
+}
@@ -0,0 +1,148 @@
+package logger
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// NewObserved creates a debug-level logger recording entries in memory
+// instead of writing them, for assertions with AssertLogged.
+func NewObserved() *Logger {
+	observed, logs := observer.New(zap.DebugLevel)
+	l := NewWithCore(Config{Level: "debug", Environment: "test"}, func(level zapcore.Level) zapcore.Core {
+		core, err := zapcore.NewIncreaseLevelCore(observed, level)
+		if err != nil {
+			return observed
+		}
+		return core
+	})
+	l.observed = logs
+	return l
+}
+
+// NewForTest creates an in-memory logger and installs it as the global logger
+// until the test finishes, so the package-level AssertLogged sees its entries.
+func NewForTest(t testing.TB) *Logger {
+	t.Helper()
+	l := NewObserved()
+	t.Cleanup(ReplaceGlobal(l))
+	return l
+}
+
+// Logs returns the entries recorded by a logger from NewObserved or
+// NewForTest, or nil for other loggers.
+func (l *Logger) Logs() *observer.ObservedLogs {
+	return l.observed
+}
+
+// Reset discards all recorded entries.
+func (l *Logger) Reset() {
+	if l.observed != nil {
+		l.observed.TakeAll()
+	}
+}
+
+// AssertLogged fails the test unless the global logger, installed with
+// NewForTest, recorded an entry with the given level and message containing
+// every expected field.
+func AssertLogged(t testing.TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	return GetLogger().AssertLogged(t, level, msg, fields...)
+}
+
+// AssertNotLogged fails the test if the global logger recorded an entry with
+// the given level, message and fields.
+func AssertNotLogged(t testing.TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	return GetLogger().AssertNotLogged(t, level, msg, fields...)
+}
+
+// AssertLogged fails the test unless an entry with the given level and message
+// was recorded containing every expected field.
+func (l *Logger) AssertLogged(t testing.TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	if !l.recording(t) {
+		return false
+	}
+	if l.find(level, msg, fields) {
+		return true
+	}
+	t.Errorf("expected %s entry %q with fields %s, got:\n%s", level, msg, formatFields(fields), l.dump())
+	return false
+}
+
+// AssertNotLogged fails the test if an entry with the given level, message and
+// fields was recorded.
+func (l *Logger) AssertNotLogged(t testing.TB, level zapcore.Level, msg string, fields ...zap.Field) bool {
+	t.Helper()
+	if !l.recording(t) {
+		return false
+	}
+	if !l.find(level, msg, fields) {
+		return true
+	}
+	t.Errorf("unexpected %s entry %q with fields %s", level, msg, formatFields(fields))
+	return false
+}
+
+// recording fails the test unless the logger records its entries.
+func (l *Logger) recording(t testing.TB) bool {
+	t.Helper()
+	if l.observed == nil {
+		t.Errorf("logger does not record entries; create it with NewForTest or NewObserved")
+		return false
+	}
+	return true
+}
+
+// find reports whether a matching entry exists.
+func (l *Logger) find(level zapcore.Level, msg string, fields []zap.Field) bool {
+	expected := fieldMap(fields)
+	for _, entry := range l.observed.FilterLevelExact(level).FilterMessage(msg).All() {
+		if containsFields(entry.ContextMap(), expected) {
+			return true
+		}
+	}
+	return false
+}
+
+// fieldMap encodes fields the same way the observer does for comparison.
+func fieldMap(fields []zap.Field) map[string]interface{} {
+	enc := zapcore.NewMapObjectEncoder()
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return enc.Fields
+}
+
+func containsFields(actual, expected map[string]interface{}) bool {
+	for k, v := range expected {
+		got, ok := actual[k]
+		if !ok || !reflect.DeepEqual(got, v) {
+			return false
+		}
+	}
+	return true
+}
+
+func formatFields(fields []zap.Field) string {
+	return fmt.Sprintf("%v", fieldMap(fields))
+}
+
+// dump renders recorded entries for failure messages.
+func (l *Logger) dump() string {
+	var b strings.Builder
+	for _, entry := range l.observed.All() {
+		fmt.Fprintf(&b, "  %s %q %v\n", entry.Level, entry.Message, entry.ContextMap())
+	}
+	if b.Len() == 0 {
+		return "  (no entries)\n"
+	}
+	return b.String()
+}
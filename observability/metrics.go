@@ -3,34 +3,78 @@ package observability
 import (
 	"net/http"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
+// Metrics groups the Prometheus collectors used by the library.
+type Metrics struct {
+	gatherer         prometheus.Gatherer
+	requestCounter   *prometheus.CounterVec
+	responseDuration *prometheus.HistogramVec
+	cacheLookups     *prometheus.CounterVec
+	encodingErrors   prometheus.Counter
+}
+
+// NewMetrics creates collectors and registers them with reg.
+// When reg is nil a dedicated registry is created.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	if reg == nil {
+		reg = prometheus.NewRegistry()
+	}
+
+	m := &Metrics{
+		requestCounter: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_requests_total",
+				Help: "Total HTTP requests",
+			},
+			[]string{"method", "path", "status_code", "host", "protocol"},
+		),
+		responseDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "http_response_duration_seconds",
+				Help:    "Histogram of response durations for HTTP requests",
+				Buckets: prometheus.DefBuckets,
+			},
+			[]string{"method", "path", "status_code", "host", "protocol"},
+		),
+		cacheLookups: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "http_response_cache_lookups_total",
+				Help: "Response cache lookups by result (hit, stale or miss)",
+			},
+			[]string{"result"},
+		),
+		encodingErrors: prometheus.NewCounter(
+			prometheus.CounterOpts{
+				Name: "http_response_encoding_errors_total",
+				Help: "Responses replaced by a server error because they could not be encoded",
+			},
+		),
+	}
+	reg.MustRegister(m.requestCounter, m.responseDuration, m.cacheLookups, m.encodingErrors)
+
+	if g, ok := reg.(prometheus.Gatherer); ok {
+		m.gatherer = g
+	}
+	return m
+}
+
 var (
-	requestCounter = prometheus.NewCounterVec(
-		prometheus.CounterOpts{
-			Name: "http_requests_total",
-			Help: "Total HTTP requests",
-		},
-		[]string{"method", "path", "status_code", "host", "protocol"},
-	)
-
-	responseDuration = prometheus.NewHistogramVec(
-		prometheus.HistogramOpts{
-			Name:    "http_response_duration_seconds",
-			Help:    "Histogram of response durations for HTTP requests",
-			Buckets: prometheus.DefBuckets,
-		},
-		[]string{"method", "path", "status_code", "host", "protocol"},
-	)
+	defaultMetrics     *Metrics
+	defaultMetricsOnce sync.Once
 )
 
-func init() {
-	prometheus.MustRegister(requestCounter)
-	prometheus.MustRegister(responseDuration)
+// DefaultMetrics returns the collectors registered with the global Prometheus registry.
+func DefaultMetrics() *Metrics {
+	defaultMetricsOnce.Do(func() {
+		defaultMetrics = NewMetrics(prometheus.DefaultRegisterer)
+	})
+	return defaultMetrics
 }
 
 // sanitizePath prevents label explosion by normalizing paths.
@@ -50,25 +94,52 @@ func isDynamic(segment string) bool {
 	return strings.ContainsAny(segment, "0123456789")
 }
 
-// MetricsMiddleware collects HTTP request metrics.
-func MetricsMiddleware(next http.Handler) http.Handler {
+// ObserveResponse records a completed HTTP response.
+func (m *Metrics) ObserveResponse(method, path string, statusCode int, host, protocol string, duration time.Duration) {
+	sanitizedPath := sanitizePath(path)
+
+	m.requestCounter.WithLabelValues(
+		method, sanitizedPath, http.StatusText(statusCode), host, protocol,
+	).Inc()
+
+	m.responseDuration.WithLabelValues(
+		method, sanitizedPath, http.StatusText(statusCode), host, protocol,
+	).Observe(duration.Seconds())
+}
+
+// ObserveCacheLookup records the result of a response cache lookup.
+func (m *Metrics) ObserveCacheLookup(result string) {
+	m.cacheLookups.WithLabelValues(result).Inc()
+}
+
+// ObserveEncodingError records a response that could not be encoded.
+func (m *Metrics) ObserveEncodingError() {
+	m.encodingErrors.Inc()
+}
+
+// Middleware collects HTTP request metrics.
+func (m *Metrics) Middleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		startTime := time.Now()
 		rr := &responseRecorder{ResponseWriter: w, statusCode: http.StatusOK}
 
 		next.ServeHTTP(rr, r)
 
-		duration := time.Since(startTime).Seconds()
-		sanitizedPath := sanitizePath(r.URL.Path)
+		m.ObserveResponse(r.Method, r.URL.Path, rr.statusCode, r.Host, r.Proto, time.Since(startTime))
+	})
+}
 
-		requestCounter.WithLabelValues(
-			r.Method, sanitizedPath, http.StatusText(rr.statusCode), r.Host, r.Proto,
-		).Inc()
+// Handler exposes the collectors of this instance's registry.
+func (m *Metrics) Handler() http.Handler {
+	if m.gatherer == nil || m.gatherer == prometheus.DefaultGatherer {
+		return promhttp.Handler()
+	}
+	return promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{})
+}
 
-		responseDuration.WithLabelValues(
-			r.Method, sanitizedPath, http.StatusText(rr.statusCode), r.Host, r.Proto,
-		).Observe(duration)
-	})
+// MetricsMiddleware collects HTTP request metrics.
+func MetricsMiddleware(next http.Handler) http.Handler {
+	return DefaultMetrics().Middleware(next)
 }
 
 // responseRecorder captures status codes for HTTP responses.
@@ -84,5 +155,6 @@ func (rr *responseRecorder) WriteHeader(code int) {
 
 // HTTPHandlerForMetrics exposes Prometheus metrics at /metrics endpoint.
 func HTTPHandlerForMetrics() http.Handler {
+	DefaultMetrics()
 	return promhttp.Handler()
 }
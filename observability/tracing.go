@@ -40,11 +40,25 @@ func InitTracer(serviceName, exporterURL string) func() {
 	}
 }
 
+// Tracer returns the tracer set up by InitTracer, or one from the global
+// OpenTelemetry provider when tracing has not been initialized.
+func Tracer() trc.Tracer {
+	if globalTracer == nil {
+		return otel.Tracer("FlexiResponseGo")
+	}
+	return globalTracer
+}
+
 // StartSpan starts a new span in the context with attributes.
 func StartSpan(ctx context.Context, spanName string, attributes map[string]string) (context.Context, trc.Span) {
-	opts := []trc.SpanStartOption{}
+	return StartSpanWith(ctx, Tracer(), spanName, attributes)
+}
+
+// StartSpanWith starts a new span using the given tracer.
+func StartSpanWith(ctx context.Context, tracer trc.Tracer, spanName string, attributes map[string]string) (context.Context, trc.Span) {
+	attrs := make([]attribute.KeyValue, 0, len(attributes))
 	for k, v := range attributes {
-		opts = append(opts, trc.WithAttributes(attribute.String(k, v)))
+		attrs = append(attrs, attribute.String(k, v))
 	}
-	return globalTracer.Start(ctx, spanName, opts...)
+	return tracer.Start(ctx, spanName, trc.WithAttributes(attrs...))
 }
@@ -0,0 +1,119 @@
+package adapters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/logger"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/valyala/fasthttp"
+)
+
+// benchmarkItem is a typical small resource returned by list endpoints.
+type benchmarkItem struct {
+	ID    int       `json:"id"`
+	Name  string    `json:"name"`
+	Price float64   `json:"price"`
+	Tags  []string  `json:"tags"`
+	At    time.Time `json:"created_at"`
+}
+
+func benchmarkData() []benchmarkItem {
+	items := make([]benchmarkItem, 10)
+	for i := range items {
+		items[i] = benchmarkItem{ID: i, Name: "item", Price: 9.99, Tags: []string{"a", "b"}, At: time.Unix(1700000000, 0).UTC()}
+	}
+	return items
+}
+
+// benchmarkAdapter returns an adapter whose logger discards output, so the
+// benchmarks measure response handling rather than log I/O.
+func benchmarkAdapter(b *testing.B) *adapters.Adapter {
+	log := logger.New(logger.Config{Level: "error", Environment: "production"})
+	return adapters.New(core.NewResponder(core.WithLogger(log)))
+}
+
+// discardWriter is a reusable http.ResponseWriter that drops the body.
+type discardWriter struct{ header http.Header }
+
+func (w *discardWriter) Header() http.Header         { return w.header }
+func (w *discardWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardWriter) WriteHeader(int)             {}
+
+func BenchmarkHTTPSuccessResponse(b *testing.B) {
+	adapter := benchmarkAdapter(b)
+	data := benchmarkData()
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("X-Trace-ID", "bench")
+	w := &discardWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		adapter.HTTPSuccessResponse(w, req, "Items", data)
+	}
+}
+
+func BenchmarkGinSuccessResponse(b *testing.B) {
+	gin.SetMode(gin.ReleaseMode)
+	adapter := benchmarkAdapter(b)
+	data := benchmarkData()
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		adapter.GinSuccessResponse(c, "Items", data)
+	})
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("X-Trace-ID", "bench")
+	w := &discardWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		engine.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkEchoSuccessResponse(b *testing.B) {
+	adapter := benchmarkAdapter(b)
+	data := benchmarkData()
+	e := echo.New()
+	e.GET("/items", func(c echo.Context) error {
+		return adapter.EchoSuccessResponse(c, "Items", data)
+	})
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("X-Trace-ID", "bench")
+	w := &discardWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		e.ServeHTTP(w, req)
+	}
+}
+
+func BenchmarkFiberSuccessResponse(b *testing.B) {
+	adapter := benchmarkAdapter(b)
+	data := benchmarkData()
+	app := fiber.New()
+	app.Get("/items", func(c *fiber.Ctx) error {
+		return adapter.FiberSuccessResponse(c, "Items", data)
+	})
+	handler := app.Handler()
+	var ctx fasthttp.RequestCtx
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		ctx.Request.Reset()
+		ctx.Response.Reset()
+		ctx.Request.Header.SetMethod("GET")
+		ctx.Request.SetRequestURI("/items")
+		ctx.Request.Header.Set("X-Trace-ID", "bench")
+		handler(&ctx)
+	}
+}
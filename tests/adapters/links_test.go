@@ -0,0 +1,66 @@
+package adapters_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/orders/:id", func(c *gin.Context) {})
+	engine.GET("/files/*filepath", func(c *gin.Context) {})
+	ginRoutes := adapters.GinRoutes(engine)
+	assert.Equal(t, "/orders/{id}", ginRoutes["GET /orders/:id"])
+	assert.Equal(t, "/files/{+filepath}", ginRoutes["GET /files/*filepath"])
+
+	e := echo.New()
+	e.GET("/orders/:id", func(c echo.Context) error { return nil }).Name = "order"
+	assert.Equal(t, "/orders/{id}", adapters.EchoRoutes(e)["order"])
+
+	app := fiber.New()
+	app.Get("/orders/:id/items/:item?", func(c *fiber.Ctx) error { return nil }).Name("order-item")
+	assert.Equal(t, "/orders/{id}/items/{item}", adapters.FiberRoutes(app)["order-item"])
+
+	httpRoutes := adapters.HTTPRoutes(map[string]string{
+		"order":  "GET /orders/{id}",
+		"static": "example.com/static/{path...}",
+		"root":   "/{$}",
+	})
+	assert.Equal(t, "/orders/{id}", httpRoutes["order"])
+	assert.Equal(t, "/static/{+path}", httpRoutes["static"])
+	assert.Equal(t, "/", httpRoutes["root"])
+}
+
+func TestSuccessResponseWithLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/orders/:id", func(c *gin.Context) {
+		links, err := core.NewLinkBuilder(adapters.GinRoutes(engine)).
+			Route("self", "GET /orders/:id", core.Params{"id": c.Param("id")}).
+			Route("cancel", "GET /orders/:id", core.Params{"id": c.Param("id")}, core.LinkMethod("DELETE")).
+			Build()
+		require.NoError(t, err)
+		adapters.GinSuccessResponse(c, "Order", map[string]string{"id": c.Param("id")}, core.WithLinks(links))
+	})
+
+	rec := tests.PerformRequest(engine, "GET", "/orders/42", nil)
+	require.Equal(t, http.StatusOK, rec.Code)
+	var resp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(rec, &resp))
+	assert.Equal(t, core.Links{"self": {Href: "/orders/42"}, "cancel": {Href: "/orders/42"}}, resp.Links)
+
+	rec = httptest.NewRecorder()
+	adapters.HTTPSuccessResponse(rec, httptest.NewRequest("GET", "/", nil), "No links", nil)
+	assert.NotContains(t, rec.Body.String(), `"links"`)
+}
@@ -0,0 +1,180 @@
+package adapters_test
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusHelpers sends a response through one helper of each framework.
+type statusHelpers struct {
+	http  func(a *adapters.Adapter, w http.ResponseWriter, r *http.Request) error
+	gin   func(a *adapters.Adapter, c *gin.Context) error
+	echo  func(a *adapters.Adapter, c echo.Context) error
+	fiber func(a *adapters.Adapter, c *fiber.Ctx) error
+}
+
+// serve sends a POST /items request to a handler of each framework and
+// returns the recorded responses by framework.
+func (h statusHelpers) serve(t *testing.T, adapter *adapters.Adapter) map[string]*http.Response {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	request := func() *http.Request { return httptest.NewRequest("POST", "/items", nil) }
+	record := func(handler http.Handler) *http.Response {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, request())
+		return rec.Result()
+	}
+
+	engine := gin.New()
+	engine.POST("/items", func(c *gin.Context) { assert.NoError(t, h.gin(adapter, c)) })
+	e := echo.New()
+	e.POST("/items", func(c echo.Context) error { return h.echo(adapter, c) })
+	app := fiber.New()
+	app.Post("/items", func(c *fiber.Ctx) error { return h.fiber(adapter, c) })
+	fiberResp, err := app.Test(request())
+	require.NoError(t, err)
+
+	return map[string]*http.Response{
+		"net/http": record(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			assert.NoError(t, h.http(adapter, w, r))
+		})),
+		"gin":   record(engine),
+		"echo":  record(e),
+		"fiber": fiberResp,
+	}
+}
+
+func TestCreatedResponse(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	adapter := adapters.New(core.NewResponder(core.WithMetrics(observability.NewMetrics(registry))))
+	data := map[string]int{"id": 7}
+	responses := statusHelpers{
+		http: func(a *adapters.Adapter, w http.ResponseWriter, r *http.Request) error {
+			return a.HTTPCreatedResponse(w, r, "/items/7", "Created", data)
+		},
+		gin: func(a *adapters.Adapter, c *gin.Context) error {
+			return a.GinCreatedResponse(c, "/items/7", "Created", data)
+		},
+		echo: func(a *adapters.Adapter, c echo.Context) error {
+			return a.EchoCreatedResponse(c, "/items/7", "Created", data)
+		},
+		fiber: func(a *adapters.Adapter, c *fiber.Ctx) error {
+			return a.FiberCreatedResponse(c, "/items/7", "Created", data)
+		},
+	}.serve(t, adapter)
+
+	for framework, resp := range responses {
+		assert.Equal(t, http.StatusCreated, resp.StatusCode, framework)
+		assert.Equal(t, "/items/7", resp.Header.Get("Location"), framework)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"data":{"id":7}`, framework)
+	}
+	assert.Equal(t, 4, countResponses(t, registry, http.StatusCreated), "metrics report the real status")
+}
+
+func TestAcceptedResponse(t *testing.T) {
+	adapter := adapters.New(core.NewResponder())
+	responses := statusHelpers{
+		http: func(a *adapters.Adapter, w http.ResponseWriter, r *http.Request) error {
+			return a.HTTPAcceptedResponse(w, r, "/jobs/1", "Accepted", nil)
+		},
+		gin: func(a *adapters.Adapter, c *gin.Context) error {
+			return a.GinAcceptedResponse(c, "/jobs/1", "Accepted", nil)
+		},
+		echo: func(a *adapters.Adapter, c echo.Context) error {
+			return a.EchoAcceptedResponse(c, "/jobs/1", "Accepted", nil)
+		},
+		fiber: func(a *adapters.Adapter, c *fiber.Ctx) error {
+			return a.FiberAcceptedResponse(c, "/jobs/1", "Accepted", nil)
+		},
+	}.serve(t, adapter)
+
+	for framework, resp := range responses {
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode, framework)
+		assert.Equal(t, "/jobs/1", resp.Header.Get("Location"), framework)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Contains(t, string(body), `"links":{"monitor":"/jobs/1"}`, framework)
+	}
+}
+
+func TestNoContentResponse(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	adapter := adapters.New(core.NewResponder(core.WithMetrics(observability.NewMetrics(registry))))
+	noStore := core.WithCachePolicy(core.CachePolicy{NoStore: true})
+	responses := statusHelpers{
+		http: func(a *adapters.Adapter, w http.ResponseWriter, r *http.Request) error {
+			return a.HTTPNoContentResponse(w, r, noStore)
+		},
+		gin: func(a *adapters.Adapter, c *gin.Context) error {
+			return a.GinNoContentResponse(c, noStore)
+		},
+		echo: func(a *adapters.Adapter, c echo.Context) error {
+			return a.EchoNoContentResponse(c, noStore)
+		},
+		fiber: func(a *adapters.Adapter, c *fiber.Ctx) error {
+			return a.FiberNoContentResponse(c, noStore)
+		},
+	}.serve(t, adapter)
+
+	for framework, resp := range responses {
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode, framework)
+		assert.Empty(t, resp.Header.Get("Content-Type"), framework)
+		assert.Empty(t, resp.Header.Get("Content-Length"), framework)
+		assert.NotEmpty(t, resp.Header.Get("X-Trace-ID"), framework)
+		assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"), framework)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Empty(t, body, framework)
+	}
+	assert.Equal(t, 4, countResponses(t, registry, http.StatusNoContent))
+}
+
+func TestSuccessResponseWithStatus(t *testing.T) {
+	adapter := adapters.New(core.NewResponder())
+	rec := httptest.NewRecorder()
+	err := adapter.HTTPSuccessResponseWithStatus(rec, httptest.NewRequest("PUT", "/items/7", nil), http.StatusResetContent, "Reset", "ignored")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusResetContent, rec.Code)
+	assert.Empty(t, rec.Body.String(), "205 responses carry no content")
+
+	rec = httptest.NewRecorder()
+	err = adapter.HTTPSuccessResponseWithStatus(rec, httptest.NewRequest("GET", "/report", nil), http.StatusPartialContent, "Partial", []int{1})
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusPartialContent, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"data":[1]`)
+}
+
+// countResponses returns the number of responses counted with statusCode.
+func countResponses(t *testing.T, registry *prometheus.Registry, statusCode int) int {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	total := 0
+	for _, family := range families {
+		if family.GetName() != "http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "status_code" && label.GetValue() == http.StatusText(statusCode) {
+					total += int(metric.GetCounter().GetValue())
+				}
+			}
+		}
+	}
+	return total
+}
@@ -0,0 +1,140 @@
+package audit_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/audit"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/andreascandle/FlexiResponseGo/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuditor(t *testing.T, path string) *audit.Auditor {
+	sink, err := audit.NewFileSink(path)
+	require.NoError(t, err)
+	auditor, err := audit.New(sink, audit.DefaultPolicy())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = auditor.Close() })
+	return auditor
+}
+
+func TestAuditChainVerifies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditor := newAuditor(t, path)
+
+	for i := 0; i < 3; i++ {
+		require.NoError(t, auditor.Record(audit.Event{
+			Actor:    "user-1",
+			Resource: "/admin",
+			Action:   "GET",
+			Outcome:  audit.OutcomeDenied,
+			Category: core.AuthorizationError,
+			TraceID:  "trace-123",
+			ClientIP: "10.0.0.1",
+		}))
+	}
+
+	count, err := audit.VerifyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+
+	// Reopening resumes the chain instead of starting a new one.
+	resumed := newAuditor(t, path)
+	require.NoError(t, resumed.Record(audit.Event{Actor: "user-2", Outcome: audit.OutcomeDenied}))
+	count, err = audit.VerifyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, count)
+}
+
+func TestAuditVerifyDetectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	auditor := newAuditor(t, path)
+	require.NoError(t, auditor.Record(audit.Event{Actor: "alice", Outcome: audit.OutcomeDenied}))
+	require.NoError(t, auditor.Record(audit.Event{Actor: "bob", Outcome: audit.OutcomeDenied}))
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, []byte(strings.Replace(string(raw), "bob", "eve", 1)), 0o600))
+
+	count, err := audit.VerifyFile(path)
+	assert.ErrorIs(t, err, audit.ErrTampered)
+	assert.Equal(t, 1, count)
+}
+
+func TestAuditAdapterRecordsAuthFailures(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.log")
+	audit.SetDefault(newAuditor(t, path))
+	defer audit.SetDefault(nil)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := http.StatusForbidden
+		if r.URL.Path == "/missing" {
+			status = http.StatusNotFound
+		}
+		adapters.HTTPErrorResponse(w, r, status, "Denied", "Forbidden")
+	})
+	tests.PerformRequest(handler, "GET", "/admin", nil)
+	tests.PerformRequest(handler, "GET", "/missing", nil)
+
+	count, err := audit.VerifyFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Contains(t, string(raw), `"resource":"/admin"`)
+	assert.Contains(t, string(raw), `"outcome":"denied"`)
+}
+
+func TestAuditClientIPIgnoresUntrustedForwardingHeaders(t *testing.T) {
+	proxies, err := utils.ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	cases := []struct {
+		name       string
+		adapter    *adapters.Adapter
+		remoteAddr string
+		want       string
+	}{
+		{"no trusted proxies", adapters.Default(), "10.0.0.5:4000", "10.0.0.5"},
+		{"untrusted peer", adapters.New(core.NewResponder(core.WithTrustedProxies(proxies))), "192.0.2.1:4000", "192.0.2.1"},
+		{"trusted proxy", adapters.New(core.NewResponder(core.WithTrustedProxies(proxies))), "10.0.0.5:4000", "198.51.100.7"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "audit.log")
+			audit.SetDefault(newAuditor(t, path))
+			defer audit.SetDefault(nil)
+
+			req := httptest.NewRequest(http.MethodGet, "/admin", nil)
+			req.RemoteAddr = tc.remoteAddr
+			req.Header.Set("X-Forwarded-For", "203.0.113.9, 198.51.100.7, 10.0.0.6")
+			tc.adapter.HTTPErrorResponse(httptest.NewRecorder(), req, http.StatusForbidden, "Denied", "Forbidden")
+
+			raw, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Contains(t, string(raw), `"client_ip":"`+tc.want+`"`)
+		})
+	}
+}
+
+func TestAuditPolicyRoutesMatchPrefixes(t *testing.T) {
+	sink, err := audit.NewFileSink(filepath.Join(t.TempDir(), "audit.log"))
+	require.NoError(t, err)
+	auditor, err := audit.New(sink, audit.Policy{Routes: []string{"/admin/*", "/login"}})
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = auditor.Close() })
+
+	assert.True(t, auditor.Matches(core.ClientError, "/admin/users/1"))
+	assert.True(t, auditor.Matches(core.ClientError, "/login"))
+	assert.False(t, auditor.Matches(core.ClientError, "/login/reset"))
+	assert.False(t, auditor.Matches(core.ClientError, "/items"))
+}
@@ -0,0 +1,222 @@
+package batch_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/batch"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/logger/loggertest"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var errOutOfStock = core.NewAPIError(core.ClientError, http.StatusConflict, "Conflict", "Out of stock.")
+
+// order creates orders, failing those for quantities above 10.
+func order(_ context.Context, item batch.Item) batch.Outcome {
+	var req struct{ Quantity int }
+	if err := item.Decode(&req); err != nil {
+		return batch.Failure(core.NewAPIError(core.ClientError, http.StatusBadRequest, "Bad Request", "Invalid order."))
+	}
+	if req.Quantity > 10 {
+		return batch.Failure(errOutOfStock)
+	}
+	return batch.Success(http.StatusCreated, map[string]int{"quantity": req.Quantity})
+}
+
+func items(quantities ...int) []batch.Item {
+	out := make([]batch.Item, len(quantities))
+	for i, q := range quantities {
+		out[i] = batch.Item{ID: string(rune('a' + i)), Body: json.RawMessage(`{"quantity":` + strconv.Itoa(q) + `}`)}
+	}
+	return out
+}
+
+func TestRunReportsEveryItem(t *testing.T) {
+	report := batch.New(order).Run(context.Background(), items(1, 20, 3))
+	require.Len(t, report.Results, 3)
+	assert.Equal(t, batch.Counts{Total: 3, Succeeded: 2, Failed: 1}, report.Counts)
+	assert.Equal(t, http.StatusMultiStatus, report.StatusCode())
+
+	assert.Equal(t, http.StatusCreated, report.Results[0].Status)
+	assert.Equal(t, "a", report.Results[0].ID)
+	assert.Equal(t, http.StatusConflict, report.Results[1].Status)
+	assert.Equal(t, errOutOfStock.Details, report.Results[1].Error.Details)
+	assert.Equal(t, 2, report.Results[2].Index)
+}
+
+func TestRunLimitsConcurrency(t *testing.T) {
+	var running, peak atomic.Int32
+	slow := func(ctx context.Context, item batch.Item) batch.Outcome {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		running.Add(-1)
+		return batch.Success(http.StatusOK, item.ID)
+	}
+	report := batch.New(slow, batch.WithConcurrency(3)).Run(context.Background(), items(1, 1, 1, 1, 1, 1, 1, 1))
+	assert.Equal(t, 8, report.Counts.Succeeded)
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+	assert.Greater(t, peak.Load(), int32(1))
+	for i, res := range report.Results {
+		assert.Equal(t, i, res.Index, "results keep the order of the items")
+	}
+}
+
+func TestAllOrNothing(t *testing.T) {
+	var mu sync.Mutex
+	var rolledBack []string
+	rollback := func(_ context.Context, item batch.Item, _ batch.Result) error {
+		mu.Lock()
+		defer mu.Unlock()
+		rolledBack = append(rolledBack, item.ID)
+		if item.ID == "b" {
+			return errors.New("already shipped")
+		}
+		return nil
+	}
+	processor := batch.New(order, batch.WithAllOrNothing(rollback))
+
+	report := processor.Run(context.Background(), items(1, 2, 30, 4))
+	assert.False(t, report.Applied())
+	assert.Equal(t, http.StatusUnprocessableEntity, report.StatusCode())
+	assert.Equal(t, []string{"b", "a"}, rolledBack, "succeeded items are rolled back, last first")
+	assert.Equal(t, batch.Counts{Total: 4, Failed: 2, Skipped: 2}, report.Counts)
+	assert.Equal(t, http.StatusFailedDependency, report.Results[0].Status)
+	assert.Equal(t, batch.ErrRollbackFailed.Details, report.Results[1].Error.Details)
+	assert.Equal(t, http.StatusConflict, report.Results[2].Status)
+	assert.Equal(t, http.StatusFailedDependency, report.Results[3].Status, "no item starts after a failure")
+
+	report = processor.Run(context.Background(), items(1, 2))
+	assert.True(t, report.Applied())
+	assert.Equal(t, http.StatusMultiStatus, report.StatusCode())
+}
+
+func TestHandlerPanicsFailTheItem(t *testing.T) {
+	tl := loggertest.New(t)
+	processor := batch.New(func(_ context.Context, item batch.Item) batch.Outcome {
+		if item.ID == "b" {
+			panic("boom")
+		}
+		return batch.Outcome{}
+	}, batch.WithResponder(core.NewResponder(core.WithLogger(tl.Logger))))
+	report := processor.Run(context.Background(), items(1, 2))
+	for _, res := range report.Results {
+		assert.Equal(t, http.StatusInternalServerError, res.Status)
+		assert.Equal(t, batch.ErrItemFailed.Details, res.Error.Details)
+	}
+
+	tl.AssertLogged(t, zap.ErrorLevel, "Batch item handler panicked",
+		zap.Int("index", 1), zap.String("id", "b"), zap.Any("panic", "boom"))
+	entries := tl.Logs().FilterMessage("Batch item handler panicked").All()
+	require.Len(t, entries, 1)
+	assert.Contains(t, entries[0].ContextMap()["stack"], "batch_test.go", "the stack reaches the panicking handler")
+}
+
+func TestDecodeLimitsTheBody(t *testing.T) {
+	processor := batch.New(order, batch.WithMaxItems(2), batch.WithMaxBodySize(64))
+
+	decoded, status, apiErr := processor.Decode(strings.NewReader(`[{"id":"a","body":{}},{"id":"b","body":{}}]`))
+	require.Nil(t, apiErr)
+	assert.Equal(t, 0, status)
+	assert.Len(t, decoded, 2)
+
+	_, status, apiErr = processor.Decode(strings.NewReader(`[{},{},` + strings.Repeat(" ", 64) + `{}]`))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Equal(t, "The batch is larger than 64 bytes.", apiErr.Details)
+
+	// Decoding stops at the first item past the limit, before reading the rest.
+	body := &countingReader{r: strings.NewReader(`[{},{},{}` + strings.Repeat(",{}", 1000) + `]`)}
+	_, status, apiErr = batch.New(order, batch.WithMaxItems(2)).Decode(body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, status)
+	assert.Equal(t, "The batch has more than 2 items.", apiErr.Details)
+	assert.Less(t, body.n, 1024)
+
+	_, status, _ = processor.Decode(strings.NewReader(`[{}`))
+	assert.Equal(t, http.StatusBadRequest, status)
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	if len(p) > 16 {
+		p = p[:16]
+	}
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestBatchAdapters(t *testing.T) {
+	processor := batch.New(order, batch.WithMaxItems(3), batch.WithConcurrency(2))
+	adapter := adapters.New(core.NewResponder())
+	e := echo.New()
+	e.POST("/orders/batch", adapter.EchoBatch(processor))
+	app := fiber.New()
+	app.Post("/orders/batch", adapter.FiberBatch(processor))
+	post := map[string]func(body string) (int, []byte){
+		"net/http": func(body string) (int, []byte) {
+			rec := httptest.NewRecorder()
+			adapter.HTTPBatch(processor).ServeHTTP(rec, httptest.NewRequest("POST", "/orders/batch", strings.NewReader(body)))
+			return rec.Code, rec.Body.Bytes()
+		},
+		"echo": func(body string) (int, []byte) {
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, httptest.NewRequest("POST", "/orders/batch", strings.NewReader(body)))
+			return rec.Code, rec.Body.Bytes()
+		},
+		"fiber": func(body string) (int, []byte) {
+			resp, err := app.Test(httptest.NewRequest("POST", "/orders/batch", strings.NewReader(body)))
+			require.NoError(t, err)
+			raw, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp.StatusCode, raw
+		},
+	}
+
+	for framework, send := range post {
+		status, raw := send(`[{"id":"x","body":{"quantity":2}},{"id":"y","body":{"quantity":50}}]`)
+		assert.Equal(t, http.StatusMultiStatus, status, framework)
+		var resp struct {
+			Status   string                 `json:"status"`
+			Data     []batch.Result         `json:"data"`
+			Metadata map[string]interface{} `json:"metadata"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &resp), framework)
+		assert.Equal(t, "success", resp.Status, framework)
+		require.Len(t, resp.Data, 2, framework)
+		assert.Equal(t, "y", resp.Data[1].ID, framework)
+		assert.Equal(t, map[string]interface{}{"total": 2.0, "succeeded": 1.0, "failed": 1.0, "skipped": 0.0}, resp.Metadata[batch.MetadataKey], framework)
+
+		status, _ = send(`{"not":"an array"}`)
+		assert.Equal(t, http.StatusBadRequest, status, framework)
+		status, _ = send(`[]`)
+		assert.Equal(t, http.StatusBadRequest, status, framework)
+		status, _ = send(`[{},{},{},{}]`)
+		assert.Equal(t, http.StatusRequestEntityTooLarge, status, framework)
+	}
+}
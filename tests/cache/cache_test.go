@@ -0,0 +1,220 @@
+package cache_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/cache"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// clock is a manually advanced time source.
+type clock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func TestCachePolicyHeaders(t *testing.T) {
+	policy := core.PublicCache(time.Minute).WithStaleWhileRevalidate(30 * time.Second).WithVary("x-region")
+	assert.Equal(t, "public, max-age=60, stale-while-revalidate=30", policy.CacheControl())
+	assert.Equal(t, "private, max-age=5", core.PrivateCache(5*time.Second).CacheControl())
+	assert.Equal(t, "no-store", core.NoStoreCache().CacheControl())
+
+	responder := core.NewResponder()
+	resp := responder.NewSuccessResponse("t-1", "ok", nil)
+	core.WithCachePolicy(policy)(&resp)
+	core.WithCacheTags("orders", "order:1")(&resp)
+	rec := httptest.NewRecorder()
+	require.NoError(t, responder.WriteJSON(rec, http.StatusOK, resp))
+	assert.Equal(t, "public, max-age=60, stale-while-revalidate=30", rec.Header().Get("Cache-Control"))
+	assert.Equal(t, "X-Region", rec.Header().Get("Vary"))
+	assert.Equal(t, "orders,order:1", rec.Header().Get("Cache-Tag"))
+
+	rec = httptest.NewRecorder()
+	failed := responder.NewErrorResponse("t-2", "failed", "boom")
+	core.WithCachePolicy(policy)(&failed)
+	require.NoError(t, responder.WriteJSON(rec, http.StatusInternalServerError, failed))
+	assert.Empty(t, rec.Header().Get("Cache-Control"), "error responses are never given a cache policy")
+}
+
+func TestResponseCacheMiddleware(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := cache.NewStore(cache.WithMetrics(observability.NewMetrics(registry)), cache.WithClock(clk.Now))
+
+	calls := 0
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		adapters.HTTPSuccessResponse(w, r, "Orders", []int{calls},
+			core.WithCachePolicy(core.PublicCache(time.Minute).WithStaleWhileRevalidate(time.Minute).WithVary("X-Region")),
+			core.WithCacheTags("orders"), core.WithETag())
+	}))
+	get := func(target string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", target, nil)
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/orders?b=2&a=1", nil)
+	assert.Equal(t, "MISS", first.Header().Get("X-Cache"))
+	hit := get("/orders?a=1&b=2", nil)
+	assert.Equal(t, "HIT", hit.Header().Get("X-Cache"))
+	assert.Equal(t, first.Body.String(), hit.Body.String())
+	assert.Equal(t, 1, calls)
+
+	assert.Equal(t, "MISS", get("/orders?a=1&b=2", map[string]string{"X-Region": "eu"}).Header().Get("X-Cache"))
+	assert.Equal(t, "HIT", get("/orders?a=1&b=2", map[string]string{"X-Region": "eu"}).Header().Get("X-Cache"))
+	assert.Equal(t, 2, calls)
+
+	notModified := get("/orders?a=1&b=2", map[string]string{"If-None-Match": first.Header().Get("ETag")})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, get("/orders?a=1&b=2", map[string]string{"Cache-Control": "no-cache"}).Header().Get("X-Cache"))
+	assert.Equal(t, 3, calls, "no-cache requests bypass the cache")
+
+	// Within the stale-while-revalidate window the next request refreshes the entry.
+	clk.Advance(90 * time.Second)
+	callsBefore := calls
+	assert.Equal(t, "MISS", get("/orders?a=1&b=2", nil).Header().Get("X-Cache"))
+	assert.Equal(t, callsBefore+1, calls)
+	assert.Equal(t, "HIT", get("/orders?a=1&b=2", nil).Header().Get("X-Cache"))
+
+	assert.Equal(t, 2, store.InvalidateTag("orders"))
+	assert.Equal(t, "MISS", get("/orders?a=1&b=2", nil).Header().Get("X-Cache"))
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	results := map[string]float64{}
+	for _, family := range families {
+		if family.GetName() == "http_response_cache_lookups_total" {
+			for _, metric := range family.GetMetric() {
+				results[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+	assert.Equal(t, map[string]float64{"hit": 4, "miss": 4}, results)
+}
+
+func TestStaleWhileRevalidate(t *testing.T) {
+	clk := &clock{now: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}
+	store := cache.NewStore(cache.WithClock(clk.Now))
+	req := httptest.NewRequest("GET", "/report", nil)
+	header := http.Header{"Cache-Control": {"max-age=10, stale-while-revalidate=20"}}
+	require.True(t, store.Save("GET", req.Host, req.URL, req.Header, http.StatusOK, header, []byte(`{}`)))
+
+	clk.Advance(15 * time.Second)
+	_, result := store.Lookup("GET", req.Host, req.URL, req.Header)
+	assert.Equal(t, cache.Miss, result, "the first request after expiry refreshes")
+	entry, result := store.Lookup("GET", req.Host, req.URL, req.Header)
+	assert.Equal(t, cache.Stale, result)
+	assert.Equal(t, `{}`, string(entry.Body))
+
+	store.Save("GET", req.Host, req.URL, req.Header, http.StatusInternalServerError, http.Header{}, nil)
+	_, result = store.Lookup("GET", req.Host, req.URL, req.Header)
+	assert.Equal(t, cache.Miss, result, "a failed refresh lets another request retry")
+
+	clk.Advance(time.Minute)
+	_, result = store.Lookup("GET", req.Host, req.URL, req.Header)
+	assert.Equal(t, cache.Miss, result)
+	assert.Equal(t, 0, store.Len())
+
+	assert.False(t, store.Save("GET", req.Host, req.URL, req.Header, http.StatusOK, http.Header{"Cache-Control": {"private, max-age=60"}}, nil))
+	assert.False(t, store.Save("GET", req.Host, req.URL, req.Header, http.StatusOK, http.Header{}, nil), "no max-age and no default TTL")
+}
+
+func TestGinCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewStore()
+	engine := gin.New()
+	engine.Use(adapters.GinCache(store))
+	calls := 0
+	engine.GET("/items", func(c *gin.Context) {
+		calls++
+		adapters.GinSuccessResponse(c, "Items", calls, core.WithCachePolicy(core.PublicCache(time.Minute)))
+	})
+
+	for i := 0; i < 3; i++ {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest("GET", "/items", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"data":1`)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestCacheSeparatesHostsAndTenants(t *testing.T) {
+	store := cache.NewStore()
+	adapter := adapters.New(core.NewResponder(core.WithTenantResolver(config.HeaderTenantResolver{})))
+	calls := 0
+	handler := store.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		adapter.HTTPSuccessResponse(w, r, "Items", calls, core.WithCachePolicy(core.PublicCache(time.Minute)))
+	}))
+	get := func(host, tenant string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("GET", "http://"+host+"/items", nil)
+		if tenant != "" {
+			req.Header.Set("X-Tenant-ID", tenant)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("a.example.com", "acme")
+	assert.Equal(t, "X-Tenant-Id", first.Header().Get("Vary"), "responses vary on the tenant resolver's header")
+	assert.NotEmpty(t, first.Header().Get("X-Trace-ID"))
+	hit := get("a.example.com", "acme")
+	assert.Equal(t, "HIT", hit.Header().Get("X-Cache"))
+	assert.Empty(t, hit.Header().Get("X-Trace-ID"), "trace IDs are not replayed to other clients")
+
+	assert.Equal(t, "MISS", get("b.example.com", "acme").Header().Get("X-Cache"))
+	assert.Equal(t, "MISS", get("a.example.com", "globex").Header().Get("X-Cache"))
+	assert.Equal(t, 3, calls)
+}
+
+func TestGinCacheSkipsOversizedBodies(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	store := cache.NewStore(cache.WithMaxBodySize(64))
+	engine := gin.New()
+	engine.Use(adapters.GinCache(store))
+	calls := 0
+	engine.GET("/report", func(c *gin.Context) {
+		calls++
+		adapters.GinSuccessResponse(c, "Report", strings.Repeat("x", 128), core.WithCachePolicy(core.PublicCache(time.Minute)))
+	})
+
+	for i := 0; i < 2; i++ {
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, httptest.NewRequest("GET", "/report", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "MISS", rec.Header().Get("X-Cache"))
+	}
+	assert.Equal(t, 2, calls)
+	assert.Equal(t, 0, store.Len())
+}
@@ -0,0 +1,217 @@
+package compression_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/compression"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andybalholm/brotli"
+	"github.com/gin-gonic/gin"
+	"github.com/klauspost/compress/gzip"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func decompress(t *testing.T, encoding string, body []byte) string {
+	t.Helper()
+	var r io.Reader
+	switch encoding {
+	case "gzip":
+		gz, err := gzip.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		r = gz
+	case "br":
+		r = brotli.NewReader(bytes.NewReader(body))
+	case "zstd":
+		zr, err := zstd.NewReader(bytes.NewReader(body))
+		require.NoError(t, err)
+		defer zr.Close()
+		r = zr
+	default:
+		return string(body)
+	}
+	out, err := io.ReadAll(r)
+	require.NoError(t, err)
+	return string(out)
+}
+
+func TestNegotiate(t *testing.T) {
+	var config compression.Config
+	cases := map[string]compression.Encoding{
+		"":                          compression.Identity,
+		"gzip":                      compression.Gzip,
+		"gzip, deflate, br":         compression.Brotli,
+		"gzip;q=1, br;q=0.5":        compression.Gzip,
+		"br;q=0, zstd;q=0.1":        compression.Zstd,
+		"*":                         compression.Brotli,
+		"*;q=0.5, br;q=0":           compression.Zstd,
+		"identity":                  compression.Identity,
+		"x-gzip":                    compression.Gzip,
+		"deflate, gzip;q=0, br;q=0": compression.Identity,
+	}
+	for header, want := range cases {
+		assert.Equal(t, want, config.Negotiate(header), header)
+	}
+	gzipOnly := compression.Config{Encodings: []compression.Encoding{compression.Gzip}}
+	assert.Equal(t, compression.Gzip, gzipOnly.Negotiate("br, gzip"))
+
+	assert.True(t, config.Compressible("application/json; charset=utf-8"))
+	assert.True(t, config.Compressible("text/csv"))
+	assert.False(t, config.Compressible("image/png"))
+	assert.False(t, config.Eligible("application/json", 10))
+}
+
+func TestPrepareCompresses(t *testing.T) {
+	responder := core.NewResponder(core.WithCompression(compression.Config{}))
+	data := strings.Repeat("compressible ", 200)
+
+	for _, encoding := range []string{"gzip", "br", "zstd"} {
+		scoped := responder.ForRequest(http.Header{"Accept-Encoding": {encoding}}, "")
+		resp := scoped.NewSuccessResponse("t-1", "ok", data)
+		core.WithStrongETag()(&resp)
+		prepared, err := scoped.Prepare(http.StatusOK, resp)
+		require.NoError(t, err)
+		assert.Equal(t, encoding, prepared.Header.Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", prepared.Header.Get("Vary"))
+		assert.Contains(t, decompress(t, encoding, prepared.Body), data)
+		assert.Less(t, len(prepared.Body), len(data))
+		assert.True(t, strings.HasSuffix(prepared.Header.Get("ETag"), "-"+encoding+`"`), "strong ETags are coding-specific")
+
+		weak := scoped.NewSuccessResponse("t-1", "ok", data)
+		core.WithETag()(&weak)
+		prepared, err = scoped.Prepare(http.StatusOK, weak)
+		require.NoError(t, err)
+		etag := prepared.Header.Get("ETag")
+		assert.True(t, strings.HasPrefix(etag, "W/") && !strings.Contains(etag, "-"+encoding), "weak ETags are shared by every coding")
+
+		conditional := scoped.NewSuccessResponse("t-2", "ok", data)
+		core.WithETag()(&conditional)
+		core.WithConditions(http.MethodGet, http.Header{"If-None-Match": {etag}})(&conditional)
+		notModified, err := scoped.Prepare(http.StatusOK, conditional)
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusNotModified, notModified.StatusCode)
+		assert.Empty(t, notModified.Body)
+		assert.Empty(t, notModified.Header.Get("Content-Encoding"))
+		assert.Equal(t, "Accept-Encoding", notModified.Header.Get("Vary"))
+	}
+
+	identity := responder.ForRequest(http.Header{}, "")
+	prepared, err := identity.Prepare(http.StatusOK, identity.NewSuccessResponse("t-3", "ok", data))
+	require.NoError(t, err)
+	assert.Empty(t, prepared.Header.Get("Content-Encoding"))
+	assert.Equal(t, "Accept-Encoding", prepared.Header.Get("Vary"), "uncompressed responses still vary on Accept-Encoding")
+
+	small := responder.ForRequest(http.Header{"Accept-Encoding": {"gzip"}}, "")
+	prepared, err = small.Prepare(http.StatusOK, small.NewSuccessResponse("t-4", "ok", "tiny"))
+	require.NoError(t, err)
+	assert.Empty(t, prepared.Header.Get("Content-Encoding"))
+	assert.Empty(t, prepared.Header.Get("Vary"), "bodies below the threshold are never compressed")
+}
+
+func TestAdaptersCompress(t *testing.T) {
+	adapter := adapters.New(core.NewResponder(core.WithCompression(compression.Config{MinSize: 64})))
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/items", func(c *gin.Context) {
+		adapter.GinSuccessResponse(c, "Items", strings.Repeat("item ", 50))
+	})
+
+	req := httptest.NewRequest("GET", "/items", nil)
+	req.Header.Set("Accept-Encoding", "gzip, br")
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+	assert.Contains(t, decompress(t, "br", rec.Body.Bytes()), `"message":"Items"`)
+}
+
+func TestMiddlewareStreams(t *testing.T) {
+	config := compression.Config{MinSize: 32}
+	rec := httptest.NewRecorder()
+	flushed := 0
+	handler := config.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Length", "999")
+		w.Header().Set("ETag", `"v1"`)
+		_, _ = io.WriteString(w, `{"event":1}`+"\n")
+		w.(http.Flusher).Flush()
+		flushed = rec.Body.Len()
+		_, _ = io.WriteString(w, `{"event":2}`+"\n")
+	}))
+
+	req := httptest.NewRequest("GET", "/events", nil)
+	req.Header.Set("Accept-Encoding", "gzip")
+	handler.ServeHTTP(rec, req)
+	assert.Positive(t, flushed, "a flush sends the compressed data written so far")
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Empty(t, rec.Header().Get("Content-Length"))
+	assert.Equal(t, `"v1-gzip"`, rec.Header().Get("ETag"))
+	assert.Equal(t, `{"event":1}`+"\n"+`{"event":2}`+"\n", decompress(t, "gzip", rec.Body.Bytes()))
+
+	small := config.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = io.WriteString(w, `{}`)
+	}))
+	rec = httptest.NewRecorder()
+	small.ServeHTTP(rec, req)
+	assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	assert.Equal(t, `{}`, rec.Body.String())
+
+	// Responses prepared by a compressing responder pass through unchanged.
+	responder := core.NewResponder(core.WithCompression(compression.Config{MinSize: 32}))
+	precompressed := config.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapters.New(responder).HTTPSuccessResponse(w, r, "ok", strings.Repeat("x", 100))
+	}))
+	rec = httptest.NewRecorder()
+	precompressed.ServeHTTP(rec, req)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	assert.Contains(t, decompress(t, "gzip", rec.Body.Bytes()), strings.Repeat("x", 100))
+}
+
+func TestMiddlewareRevalidatesCompressedETags(t *testing.T) {
+	body := strings.Repeat(`{"item":1}`, 200)
+	handler := compression.Config{}.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("ETag", `"v1"`)
+		http.ServeContent(w, r, "", time.Time{}, strings.NewReader(body))
+	}))
+	send := func(method string, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/items", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	get := send(http.MethodGet, nil)
+	require.Equal(t, http.StatusOK, get.Code)
+	etag := get.Header().Get("ETag")
+	assert.Equal(t, `"v1-gzip"`, etag)
+	assert.Equal(t, body, decompress(t, "gzip", get.Body.Bytes()))
+
+	head := send(http.MethodHead, nil)
+	assert.Equal(t, http.StatusOK, head.Code)
+	assert.Empty(t, head.Body.Bytes())
+	for _, name := range []string{"Content-Encoding", "Content-Length", "ETag", "Vary"} {
+		assert.Equal(t, get.Header().Get(name), head.Header().Get(name), "HEAD sends the %s of GET", name)
+	}
+
+	notModified := send(http.MethodGet, map[string]string{"If-None-Match": `"v0-gzip", ` + etag})
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.Bytes())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusOK, send(http.MethodGet, map[string]string{"If-Match": etag}).Code)
+	assert.Equal(t, http.StatusPreconditionFailed, send(http.MethodGet, map[string]string{"If-Match": `"v0-gzip"`}).Code)
+}
@@ -0,0 +1,176 @@
+package config_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/audit"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/utils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func adminRequest(handler http.Handler, method, body, ifMatch string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/admin/config", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("X-User-ID", "ops-1")
+	if ifMatch != "" {
+		req.Header.Set("If-Match", ifMatch)
+	}
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestAdminHandlerGetMasksSecrets(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("paymentApiKey", "sk_live_123")
+	conf.UpdateMetadata("db", map[string]interface{}{
+		"host":     "db.internal",
+		"password": "hunter2",
+		"replicas": []interface{}{map[string]interface{}{"host": "r1", "token": "tok_456"}},
+	})
+	handler := config.NewAdminHandler(conf)
+
+	rec := adminRequest(handler, http.MethodGet, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NotEmpty(t, rec.Header().Get("ETag"))
+	assert.NotContains(t, rec.Body.String(), "sk_live_123")
+	assert.NotContains(t, rec.Body.String(), "hunter2")
+	assert.NotContains(t, rec.Body.String(), "tok_456")
+
+	var view map[string]interface{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &view))
+	metadata := view["GlobalMetadata"].(map[string]interface{})
+	assert.Equal(t, "********", metadata["paymentApiKey"])
+	db := metadata["db"].(map[string]interface{})
+	assert.Equal(t, "db.internal", db["host"])
+	assert.Equal(t, "********", db["password"])
+	replica := db["replicas"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, map[string]interface{}{"host": "r1", "token": "********"}, replica)
+}
+
+func TestAdminHandlerRecordsTrustedClientIP(t *testing.T) {
+	proxies, err := utils.ParseTrustedProxies("10.0.0.0/8")
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		name string
+		opts []config.AdminOption
+		want string
+	}{
+		{"no trusted proxies", nil, "10.1.2.3"},
+		{"trusted proxy", []config.AdminOption{config.WithTrustedProxies(proxies)}, "198.51.100.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var changes []config.Change
+			opts := append(tc.opts, config.WithChangeRecorder(func(c config.Change) { changes = append(changes, c) }))
+			handler := config.NewAdminHandler(config.New(), opts...)
+
+			req := httptest.NewRequest(http.MethodPatch, "/admin/config", strings.NewReader(`{"LogLevel": "debug"}`))
+			req.RemoteAddr = "10.1.2.3:5000"
+			req.Header.Set("X-Forwarded-For", "198.51.100.7")
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+
+			require.Len(t, changes, 1)
+			assert.Equal(t, tc.want, changes[0].ClientIP)
+		})
+	}
+}
+
+func TestAdminHandlerPatch(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("branding", map[string]interface{}{"color": "blue", "logo": "a.png"})
+
+	auditPath := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := audit.NewFileSink(auditPath)
+	require.NoError(t, err)
+	auditor, err := audit.New(sink, audit.DefaultPolicy())
+	require.NoError(t, err)
+	defer auditor.Close()
+
+	handler := config.NewAdminHandler(conf, config.WithChangeRecorder(audit.ConfigChangeRecorder(auditor)))
+	etag := adminRequest(handler, http.MethodGet, "", "").Header().Get("ETag")
+
+	patch := `{"LogLevel": "debug", "GlobalMetadata": {"region": null, "branding": {"color": "red"}}}`
+	rec := adminRequest(handler, http.MethodPatch, patch, "")
+	assert.Equal(t, http.StatusPreconditionRequired, rec.Code)
+
+	rec = adminRequest(handler, http.MethodPatch, patch, etag)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.NotEqual(t, etag, rec.Header().Get("ETag"))
+
+	snap := conf.Snapshot()
+	assert.Equal(t, "debug", snap.LogLevel)
+	_, hasRegion := snap.Metadata("region")
+	assert.False(t, hasRegion)
+	branding, _ := snap.Metadata("branding")
+	assert.Equal(t, map[string]interface{}{"color": "red", "logo": "a.png"}, branding)
+
+	// Reusing the stale ETag loses the race.
+	rec = adminRequest(handler, http.MethodPatch, `{"LogLevel": "warn"}`, etag)
+	assert.Equal(t, http.StatusPreconditionFailed, rec.Code)
+	assert.Equal(t, "debug", conf.Snapshot().LogLevel)
+
+	etag = adminRequest(handler, http.MethodGet, "", "").Header().Get("ETag")
+	rec = adminRequest(handler, http.MethodPatch, `{"LogLevel": "loud"}`, etag)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	rec = adminRequest(handler, http.MethodPatch, `{"ServiceName": "x"}`, etag)
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+
+	count, err := audit.VerifyFile(auditPath)
+	require.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
+
+func TestAdminHandlerPatchAcceptsWildcardIfMatch(t *testing.T) {
+	conf := config.New()
+	handler := config.NewAdminHandler(conf)
+
+	rec := adminRequest(handler, http.MethodPatch, `{"LogLevel": "debug"}`, "*")
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, "debug", conf.Snapshot().LogLevel)
+}
+
+func TestAdminHandlerPatchIgnoresMaskedSecrets(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("paymentApiKey", "sk_live_123")
+	conf.UpdateMetadata("db", map[string]interface{}{
+		"host":     "db.internal",
+		"password": "hunter2",
+		"replicas": []interface{}{map[string]interface{}{"host": "r1", "token": "tok_456"}},
+	})
+	handler := config.NewAdminHandler(conf)
+
+	// Send back what GET returned, with one visible change.
+	get := adminRequest(handler, http.MethodGet, "", "")
+	var view map[string]interface{}
+	require.NoError(t, json.Unmarshal(get.Body.Bytes(), &view))
+	metadata := view["GlobalMetadata"].(map[string]interface{})
+	metadata["db"].(map[string]interface{})["host"] = "db2.internal"
+	delete(metadata["db"].(map[string]interface{}), "replicas")
+	patch, err := json.Marshal(map[string]interface{}{"GlobalMetadata": metadata})
+	require.NoError(t, err)
+
+	rec := adminRequest(handler, http.MethodPatch, string(patch), get.Header().Get("ETag"))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	snap := conf.Snapshot()
+	key, _ := snap.Metadata("paymentApiKey")
+	assert.Equal(t, "sk_live_123", key)
+	db, _ := snap.Metadata("db")
+	assert.Equal(t, "db2.internal", db.(map[string]interface{})["host"])
+	assert.Equal(t, "hunter2", db.(map[string]interface{})["password"])
+
+	// Arrays are replaced wholesale, so a masked secret inside one cannot be kept.
+	rec = adminRequest(handler, http.MethodPatch,
+		`{"GlobalMetadata": {"db": {"replicas": [{"host": "r1", "token": "********"}]}}}`, "*")
+	assert.Equal(t, http.StatusUnprocessableEntity, rec.Code)
+	db, _ = conf.Snapshot().Metadata("db")
+	assert.NotNil(t, db.(map[string]interface{})["replicas"])
+}
@@ -0,0 +1,240 @@
+package config_test
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestLoadFromEnv(t *testing.T) {
+	t.Setenv("FLEXI_LOG_LEVEL", "debug")
+	t.Setenv("FLEXI_ENVIRONMENT", "staging")
+	t.Setenv("FLEXI_METADATA_API_VERSION", "v2")
+	t.Setenv("FLEXI_METADATA_ENABLE_LOCALIZATION", "true")
+
+	conf := config.New()
+	require.NoError(t, conf.LoadFromEnv("FLEXI"))
+
+	assert.Equal(t, "debug", conf.LogLevel)
+	assert.Equal(t, "staging", conf.Environment)
+	value, _ := conf.GetMetadata("apiVersion")
+	assert.Equal(t, "v2", value)
+	value, _ = conf.GetMetadata("enableLocalization")
+	assert.Equal(t, true, value)
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	file := writeFile(t, "config.json", `{
+		"GlobalMetadata": {"version": "1.0.0", "region": "eu-west-1"},
+		"LogLevel": "warn",
+		"Environment": "production",
+		"ServiceName": "from-file",
+		"Region": "eu-west-1"
+	}`)
+	t.Setenv("FLEXI_LOG_LEVEL", "info")
+	t.Setenv("FLEXI_ENVIRONMENT", "staging")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := config.New()
+	binding := conf.BindFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-environment", "development", "-metadata", "version=2.0.0"}))
+
+	require.NoError(t, conf.Load(config.LoadOptions{File: file, EnvPrefix: "FLEXI", Flags: binding}))
+
+	assert.Equal(t, "info", conf.LogLevel)
+	assert.Equal(t, "development", conf.Environment)
+	assert.Equal(t, "from-file", conf.ServiceName)
+	version, _ := conf.GetMetadata("version")
+	assert.Equal(t, "2.0.0", version)
+
+	sources := conf.Sources()
+	assert.Equal(t, config.SourceEnv, sources[config.KeyLogLevel])
+	assert.Equal(t, config.SourceFlag, sources[config.KeyEnvironment])
+	assert.Equal(t, config.SourceFile, sources[config.KeyServiceName])
+	assert.Equal(t, config.SourceFlag, sources["GlobalMetadata.version"])
+	assert.Equal(t, config.SourceFile, sources["GlobalMetadata.region"])
+	assert.Contains(t, conf.SourceReport(), "Environment=flag\n")
+}
+
+func TestReloadKeepsEnvAndFlagOverrides(t *testing.T) {
+	file := writeFile(t, "config.json", `{
+		"GlobalMetadata": {"version": "1.0.0", "region": "eu-west-1"},
+		"LogLevel": "info",
+		"Environment": "production",
+		"ServiceName": "from-file"
+	}`)
+	t.Setenv("FLEXI_LOG_LEVEL", "debug")
+
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	conf := config.New()
+	binding := conf.BindFlags(fs)
+	require.NoError(t, fs.Parse([]string{"-metadata", "version=2.0.0"}))
+	require.NoError(t, conf.Load(config.LoadOptions{File: file, EnvPrefix: "FLEXI", Flags: binding}))
+
+	require.NoError(t, os.WriteFile(file, []byte(`{
+		"GlobalMetadata": {"version": "1.1.0", "region": "us-east-1"},
+		"LogLevel": "warn",
+		"ServiceName": "reloaded"
+	}`), 0o600))
+	require.NoError(t, conf.ReloadFromFile(file))
+
+	assert.Equal(t, "debug", conf.LogLevel)
+	assert.Equal(t, "reloaded", conf.ServiceName)
+	version, _ := conf.GetMetadata("version")
+	assert.Equal(t, "2.0.0", version)
+	region, _ := conf.GetMetadata("region")
+	assert.Equal(t, "us-east-1", region)
+
+	sources := conf.Sources()
+	assert.Equal(t, config.SourceEnv, sources[config.KeyLogLevel])
+	assert.Equal(t, config.SourceFile, sources[config.KeyServiceName])
+	assert.Equal(t, config.SourceFlag, sources["GlobalMetadata.version"])
+	assert.Equal(t, config.SourceFile, sources["GlobalMetadata.region"])
+}
+
+func TestSourcesDefaultAndRuntime(t *testing.T) {
+	conf := config.New()
+	assert.Equal(t, config.SourceDefault, conf.Sources()[config.KeyLogLevel])
+
+	conf.UpdateLogLevel("error")
+	assert.Equal(t, config.SourceRuntime, conf.Sources()[config.KeyLogLevel])
+}
+
+func TestLoadFromFileFormats(t *testing.T) {
+	files := map[string]string{
+		"config.json": `{"LogLevel": "debug", "GlobalMetadata": {"apiVersion": "v3"}}`,
+		"config.yaml": "LogLevel: debug\nGlobalMetadata:\n  apiVersion: v3\n",
+		"config.toml": "LogLevel = \"debug\"\n[GlobalMetadata]\napiVersion = \"v3\"\n",
+	}
+	for name, content := range files {
+		t.Run(name, func(t *testing.T) {
+			conf := config.New()
+			require.NoError(t, conf.LoadFromFile(writeFile(t, name, content)))
+
+			assert.Equal(t, "debug", conf.LogLevel)
+			assert.Equal(t, "production", conf.Environment, "absent keys keep their value")
+			value, _ := conf.GetMetadata("apiVersion")
+			assert.Equal(t, "v3", value)
+		})
+	}
+}
+
+func TestLoadFromFileRejectsInvalidContent(t *testing.T) {
+	path := writeFile(t, "config.yaml", "LogLevel: verbose\nEnvironment: moon\nRegion: eu\n")
+	conf := config.New()
+	err := conf.LoadFromFile(path)
+
+	var errs config.ValidationErrors
+	require.ErrorAs(t, err, &errs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 1, errs[0].Line)
+	assert.Equal(t, config.KeyLogLevel, errs[0].Field)
+	assert.Equal(t, 2, errs[1].Line)
+	assert.Equal(t, "info", conf.LogLevel, "invalid files must not change the config")
+
+	path = writeFile(t, "config.toml", "LogLevel = \"info\"\nColour = \"blue\"\nShape = \"round\"\n")
+	require.ErrorAs(t, conf.LoadFromFile(path), &errs)
+	require.Len(t, errs, 2)
+	assert.Equal(t, 2, errs[0].Line)
+	assert.Equal(t, "Colour", errs[0].Field)
+
+	path = writeFile(t, "config.json", "{\n  \"LogLevel\": \"info\",\n  \"Colour\": \"blue\"\n}")
+	require.ErrorAs(t, conf.LoadFromFile(path), &errs)
+	assert.Equal(t, 3, errs[0].Line)
+	assert.Contains(t, err.Error(), "config.yaml:1: LogLevel:")
+
+	assert.Error(t, conf.LoadFromFile(writeFile(t, "config.ini", "")))
+}
+
+func TestSaveToFileRoundTrip(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("apiVersion", "v9")
+	path := filepath.Join(t.TempDir(), "saved.yaml")
+	require.NoError(t, conf.SaveToFile(path))
+
+	loaded := config.New()
+	require.NoError(t, loaded.LoadFromFile(path))
+	value, _ := loaded.GetMetadata("apiVersion")
+	assert.Equal(t, "v9", value)
+}
+
+func TestLoadSettingsAndVisibilityFromFile(t *testing.T) {
+	path := writeFile(t, "config.yaml", `Settings:
+  enableLocalization: true
+MetadataVisibility:
+  region: errors
+RouteMetadataVisibility:
+  /health:
+    version: never
+`)
+	conf := config.New()
+	require.NoError(t, conf.LoadFromFile(path))
+
+	value, ok := conf.GetSetting("enableLocalization")
+	assert.True(t, ok)
+	assert.Equal(t, true, value)
+	policy := conf.Snapshot().MetadataPolicy
+	assert.Equal(t, config.VisibilityErrors, policy.VisibilityFor("/users", "region"))
+	assert.Equal(t, config.VisibilityNever, policy.VisibilityFor("/health", "version"))
+	// File rules are merged over the built-in ones.
+	assert.Equal(t, config.VisibilityNever, policy.VisibilityFor("/users", "enableLocalization"))
+
+	bad := writeFile(t, "bad.yaml", "MetadataVisibility:\n  region: sometimes\n")
+	assert.Error(t, config.New().LoadFromFile(bad))
+}
+
+func TestReloadKeepsRuntimeVisibilityAndEnvelope(t *testing.T) {
+	path := writeFile(t, "config.yaml", `MetadataVisibility:
+  region: errors
+  version: debug
+RouteMetadataVisibility:
+  /health:
+    version: never
+Envelope:
+  Naming: kebab
+`)
+	conf := config.New()
+	require.NoError(t, conf.LoadFromFile(path))
+
+	conf.SetMetadataVisibility("region", config.VisibilityAlways)
+	conf.SetRouteMetadataVisibility("/public/*", "region", config.VisibilityNever)
+	conf.UpdateEnvelope(config.EnvelopeConfig{Naming: config.NamingCamel})
+
+	require.NoError(t, os.WriteFile(path, []byte(`MetadataVisibility:
+  region: never
+RouteMetadataVisibility:
+  /status:
+    version: never
+Envelope:
+  Naming: snake
+`), 0o600))
+	require.NoError(t, conf.ReloadFromFile(path))
+
+	snap := conf.Snapshot()
+	policy := snap.MetadataPolicy
+	assert.Equal(t, config.VisibilityAlways, policy.VisibilityFor("/users", "region"))
+	assert.Equal(t, config.VisibilityNever, policy.VisibilityFor("/public/docs", "region"))
+	assert.Equal(t, config.NamingCamel, snap.Envelope.Naming)
+	// File rules dropped from the file are dropped from the config.
+	assert.Equal(t, config.VisibilityAlways, policy.VisibilityFor("/users", "version"))
+	assert.Equal(t, config.VisibilityAlways, policy.VisibilityFor("/health", "version"))
+	assert.Equal(t, config.VisibilityNever, policy.VisibilityFor("/status", "version"))
+	assert.Equal(t, config.VisibilityNever, policy.VisibilityFor("/users", "enableLocalization"))
+
+	sources := conf.Sources()
+	assert.Equal(t, config.SourceRuntime, sources["MetadataVisibility.region"])
+	assert.Equal(t, config.SourceRuntime, sources["RouteMetadataVisibility./public/*.region"])
+	assert.Equal(t, config.SourceRuntime, sources[config.KeyEnvelope])
+}
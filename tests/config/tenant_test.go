@@ -0,0 +1,92 @@
+package config_test
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantResolvers(t *testing.T) {
+	headers := http.Header{}
+	headers.Set("X-Tenant-ID", "acme")
+	assert.Equal(t, "acme", config.HeaderTenantResolver{}.ResolveTenant(headers, ""))
+
+	sub := config.SubdomainTenantResolver{BaseDomain: "api.example.com"}
+	assert.Equal(t, "globex", sub.ResolveTenant(nil, "globex.api.example.com:8080"))
+	assert.Equal(t, "", sub.ResolveTenant(nil, "api.example.com"))
+	assert.Equal(t, "", sub.ResolveTenant(nil, "a.b.api.example.com"))
+
+	payload := base64.RawURLEncoding.EncodeToString([]byte(`{"sub":"u1","tenant_id":"initech"}`))
+	jwt := http.Header{}
+	jwt.Set("Authorization", "Bearer header."+payload+".signature")
+	assert.Equal(t, "initech", config.JWTClaimTenantResolver{}.ResolveTenant(jwt, ""))
+
+	chain := config.TenantResolverChain{config.HeaderTenantResolver{}, config.JWTClaimTenantResolver{}}
+	assert.Equal(t, "initech", chain.ResolveTenant(jwt, ""))
+}
+
+func TestDirectoryLoaderAndStore(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "acme.yaml"), []byte("GlobalMetadata:\n  brand: Acme\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "globex.json"), []byte(`{"GlobalMetadata": {"brand": "Globex"}}`), 0o600))
+
+	store := config.NewTenantStore(config.DirectoryLoader{Dir: dir}, 2)
+
+	overlay, err := store.Overlay("acme")
+	require.NoError(t, err)
+	assert.Equal(t, "Acme", overlay.GlobalMetadata["brand"])
+
+	overlay, err = store.Overlay("unknown")
+	require.NoError(t, err)
+	assert.Nil(t, overlay)
+
+	_, err = store.Overlay("globex")
+	require.NoError(t, err)
+	assert.Equal(t, 2, store.Len(), "cache must stay bounded")
+
+	_, err = config.DirectoryLoader{Dir: dir}.LoadOverlay("../etc/passwd")
+	assert.Error(t, err)
+}
+
+func TestTenantOverlayMergedIntoMetadata(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("brand", "Default")
+	conf.UpdateMetadata("apiVersion", "v1")
+
+	store := config.NewTenantStore(nil, 10)
+	store.Set(&config.Overlay{TenantID: "acme", GlobalMetadata: map[string]interface{}{"brand": "Acme"}})
+
+	adapter := adapters.New(core.NewResponder(
+		core.WithConfig(conf),
+		core.WithTenantStore(store),
+		core.WithTenantResolver(config.HeaderTenantResolver{}),
+	))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapter.HTTPSuccessResponse(w, r, "ok", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Tenant-ID", "acme")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(rec, &resp))
+	assert.Equal(t, "Acme", resp.Metadata["brand"])
+	assert.Equal(t, "v1", resp.Metadata["apiVersion"])
+
+	rec = tests.PerformRequest(handler, "GET", "/", nil)
+	resp = core.StandardResponse{}
+	require.NoError(t, tests.ParseJSON(rec, &resp))
+	assert.Equal(t, "Default", resp.Metadata["brand"])
+}
@@ -0,0 +1,111 @@
+package config_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnChangeNotifiesSubscribers(t *testing.T) {
+	conf := config.New()
+	var changes []config.Snapshot
+	unsubscribe := conf.OnChange(func(old, new config.Snapshot) {
+		assert.Equal(t, "info", old.LogLevel)
+		changes = append(changes, new)
+	})
+
+	conf.UpdateLogLevel("debug")
+	unsubscribe()
+	conf.UpdateLogLevel("warn")
+
+	require.Len(t, changes, 1)
+	assert.Equal(t, "debug", changes[0].LogLevel)
+}
+
+func TestWatcherReloadsOnChange(t *testing.T) {
+	for name, opts := range map[string][]config.WatchOption{
+		"native":  nil,
+		"polling": {config.WithPolling(), config.WithPollInterval(10 * time.Millisecond)},
+	} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "config.yaml")
+			require.NoError(t, os.WriteFile(path, []byte("LogLevel: info\n"), 0o600))
+
+			conf := config.New()
+			changed := make(chan config.Snapshot, 10)
+			conf.OnChange(func(old, new config.Snapshot) { changed <- new })
+			failed := make(chan error, 10)
+
+			opts = append(opts, config.WithDebounce(20*time.Millisecond),
+				config.WithErrorHandler(func(err error) { failed <- err }))
+			watcher, err := conf.Watch(path, opts...)
+			require.NoError(t, err)
+			defer watcher.Close()
+
+			// Give the polling notifier a chance to record the initial state.
+			time.Sleep(30 * time.Millisecond)
+			require.NoError(t, os.WriteFile(path, []byte("LogLevel: debug\nEnvironment: staging\n"), 0o600))
+
+			select {
+			case snap := <-changed:
+				assert.Equal(t, "debug", snap.LogLevel)
+				assert.Equal(t, "staging", snap.Environment)
+			case <-time.After(2 * time.Second):
+				t.Fatal("config was not reloaded")
+			}
+
+			time.Sleep(30 * time.Millisecond)
+			require.NoError(t, os.WriteFile(path, []byte("LogLevel: loud\n"), 0o600))
+			select {
+			case err := <-failed:
+				assert.ErrorContains(t, err, "LogLevel")
+			case <-time.After(2 * time.Second):
+				t.Fatal("invalid config was not reported")
+			}
+			assert.Equal(t, "debug", conf.Snapshot().LogLevel, "invalid file must not replace the config")
+		})
+	}
+}
+
+func TestWatcherFollowsSymlinkSwaps(t *testing.T) {
+	// Lay the directory out like a Kubernetes ConfigMap volume: config.yaml
+	// links into ..data, which is atomically re-pointed on update.
+	dir := t.TempDir()
+	writeVersion := func(version, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, version), 0o700))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, version, "config.yaml"), []byte(content), 0o600))
+	}
+	writeVersion("..v1", "LogLevel: info\n")
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	path := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.Symlink(filepath.Join("..data", "config.yaml"), path))
+
+	conf := config.New()
+	changed := make(chan config.Snapshot, 10)
+	conf.OnChange(func(old, new config.Snapshot) { changed <- new })
+	watcher, err := conf.Watch(path, config.WithDebounce(20*time.Millisecond))
+	require.NoError(t, err)
+	defer watcher.Close()
+
+	swap := func(version, content string) {
+		writeVersion(version, content)
+		tmp := filepath.Join(dir, "..data_tmp")
+		require.NoError(t, os.Symlink(version, tmp))
+		require.NoError(t, os.Rename(tmp, filepath.Join(dir, "..data")))
+	}
+	for version, level := range map[string]string{"..v2": "debug", "..v3": "warn"} {
+		time.Sleep(30 * time.Millisecond)
+		swap(version, "LogLevel: "+level+"\n")
+		select {
+		case snap := <-changed:
+			assert.Equal(t, level, snap.LogLevel)
+		case <-time.After(2 * time.Second):
+			t.Fatalf("config was not reloaded after swapping to %s", version)
+		}
+	}
+}
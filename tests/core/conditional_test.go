@@ -0,0 +1,104 @@
+package core_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/gin-gonic/gin"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteJSONConditionalRequests(t *testing.T) {
+	responder := core.NewResponder()
+	write := func(traceID string, headers http.Header, opts ...core.ResponseOption) *httptest.ResponseRecorder {
+		resp := responder.NewSuccessResponse(traceID, "ok", map[string]int{"id": 1})
+		for _, opt := range append(opts, core.WithConditions(http.MethodGet, headers)) {
+			opt(&resp)
+		}
+		rec := httptest.NewRecorder()
+		require.NoError(t, responder.WriteJSON(rec, http.StatusOK, resp))
+		return rec
+	}
+
+	assert.Empty(t, write("t-0", nil).Header().Get("ETag"), "ETags are opt-in")
+
+	first := write("t-1", nil, core.WithETag())
+	etag := first.Header().Get("ETag")
+	require.True(t, strings.HasPrefix(etag, `W/"`), "payload ETags leave out the trace ID and timestamp, so they are weak")
+	assert.Equal(t, etag, write("t-2", nil, core.WithETag()).Header().Get("ETag"), "trace IDs do not change the ETag")
+
+	strong := write("t-1", nil, core.WithStrongETag()).Header().Get("ETag")
+	assert.False(t, strings.HasPrefix(strong, "W/"))
+	assert.NotEqual(t, strong, write("t-2", nil, core.WithStrongETag()).Header().Get("ETag"), "strong ETags hash the exact bytes sent")
+
+	notModified := write("t-3", http.Header{"If-None-Match": {`"other", ` + etag}}, core.WithETag())
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.String())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	failed := write("t-4", http.Header{"If-Match": {`"stale"`}}, core.WithETag())
+	assert.Equal(t, http.StatusPreconditionFailed, failed.Code)
+	var body core.StandardResponse
+	require.NoError(t, json.Unmarshal(failed.Body.Bytes(), &body))
+	assert.Equal(t, "error", body.Status)
+	assert.Equal(t, "t-4", body.TraceID)
+	assert.EqualValues(t, http.StatusPreconditionFailed, body.Metadata["code"])
+	assert.Empty(t, failed.Header().Get("ETag"))
+
+	weak := write("t-5", http.Header{"If-None-Match": {`W/"7"`}}, core.WithVersion("7"))
+	assert.Equal(t, http.StatusNotModified, weak.Code)
+	assert.Equal(t, `W/"7"`, weak.Header().Get("ETag"))
+
+	modified := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	since := write("t-6", http.Header{"If-Modified-Since": {modified.Format(http.TimeFormat)}}, core.WithLastModified(modified))
+	assert.Equal(t, http.StatusNotModified, since.Code)
+	changed := write("t-7", http.Header{"If-Modified-Since": {modified.Add(-time.Hour).Format(http.TimeFormat)}}, core.WithLastModified(modified))
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.Equal(t, "Wed, 01 May 2024 12:00:00 GMT", changed.Header().Get("Last-Modified"))
+}
+
+func TestEvaluatePreconditionsForUnsafeMethods(t *testing.T) {
+	etag := core.WeakETag("3")
+	assert.Equal(t, 0, core.EvaluatePreconditions(http.MethodPut, http.Header{}, etag, time.Time{}))
+	assert.Equal(t, http.StatusPreconditionFailed,
+		core.EvaluatePreconditions(http.MethodPut, http.Header{"If-Match": {`"2"`}}, `"3"`, time.Time{}))
+	assert.Equal(t, 0, core.EvaluatePreconditions(http.MethodPut, http.Header{"If-Match": {`"3"`}}, `"3"`, time.Time{}))
+	assert.Equal(t, http.StatusPreconditionFailed,
+		core.EvaluatePreconditions(http.MethodPut, http.Header{"If-None-Match": {"*"}}, etag, time.Time{}))
+}
+
+func TestAdaptersHandleConditionalRequests(t *testing.T) {
+	adapter := adapters.New(core.NewResponder())
+
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/items/1", func(c *gin.Context) {
+		adapter.GinSuccessResponse(c, "Item", map[string]int{"id": 1}, core.WithETag())
+	})
+	rec := httptest.NewRecorder()
+	engine.ServeHTTP(rec, httptest.NewRequest("GET", "/items/1", nil))
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	req := httptest.NewRequest("GET", "/items/1", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	engine.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+	assert.Empty(t, rec.Body.String())
+
+	e := echo.New()
+	req = httptest.NewRequest("GET", "/items/1", nil)
+	req.Header.Set("If-None-Match", `W/"4"`)
+	rec = httptest.NewRecorder()
+	require.NoError(t, adapter.EchoSuccessResponse(e.NewContext(req, rec), "Item", map[string]int{"id": 1}, core.WithVersion("4")))
+	assert.Equal(t, http.StatusNotModified, rec.Code)
+}
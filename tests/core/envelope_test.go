@@ -0,0 +1,61 @@
+package core_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLegacyEnvelopeSchema(t *testing.T) {
+	responder := core.NewResponder(core.WithEnvelopeSchema(core.EnvelopeSchema{
+		Rename: map[string]string{"status": "success", "data": "result", "error": "errors"},
+		Omit:   []string{"metadata"},
+		Extra:  map[string]interface{}{"apiVersion": 1},
+		Transforms: map[string]core.FieldTransform{
+			"status": func(v interface{}) interface{} { return v == "success" },
+			"error":  func(v interface{}) interface{} { return []interface{}{v} },
+		},
+	}))
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, responder.WriteJSON(rec, http.StatusBadRequest, responder.NewErrorResponse("t-1", "Bad input", "name is required")))
+	assert.JSONEq(t, `{"success":false,"message":"Bad input","errors":["name is required"],"trace_id":"t-1","apiVersion":1}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	require.NoError(t, responder.WriteJSON(rec, http.StatusOK, responder.NewSuccessResponse("t-2", "ok", []int{1})))
+	assert.JSONEq(t, `{"success":true,"message":"ok","result":[1],"trace_id":"t-2","apiVersion":1}`, rec.Body.String())
+}
+
+func TestEnvelopeFromConfigAppliesToAdapters(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`Envelope:
+  Naming: camel
+  Omit: [metadata]
+  Transforms:
+    error: list
+`), 0o600))
+	conf := config.New()
+	require.NoError(t, conf.LoadFromFile(path))
+	adapter := adapters.New(core.NewResponder(core.WithConfig(conf)))
+
+	e := echo.New()
+	req := httptest.NewRequest("GET", "/", nil)
+	req.Header.Set("X-Trace-ID", "t-3")
+	rec := httptest.NewRecorder()
+	require.NoError(t, adapter.EchoErrorResponse(e.NewContext(req, rec), http.StatusBadRequest, "Invalid", "bad"))
+
+	assert.JSONEq(t, `{"status":"error","message":"Invalid","error":["bad"],"traceId":"t-3"}`, rec.Body.String())
+
+	bad := filepath.Join(t.TempDir(), "bad.yaml")
+	require.NoError(t, os.WriteFile(bad, []byte("Envelope:\n  Naming: pascal\n"), 0o600))
+	assert.Error(t, config.New().LoadFromFile(bad))
+}
@@ -0,0 +1,136 @@
+package core_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// assertServerError checks that body is a ServerError envelope for traceID.
+func assertServerError(t *testing.T, body []byte, traceID string) {
+	t.Helper()
+	var resp core.StandardResponse
+	require.NoError(t, json.Unmarshal(body, &resp), string(body))
+	assert.Equal(t, "error", resp.Status)
+	assert.Equal(t, traceID, resp.TraceID)
+	assert.Equal(t, string(core.ServerError), resp.Metadata["category"])
+	assert.EqualValues(t, http.StatusInternalServerError, resp.Metadata["code"])
+}
+
+func TestWriteJSONEncodingFailure(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	metrics := observability.NewMetrics(registry)
+	responder := core.NewResponder(core.WithMetrics(metrics))
+
+	rec := httptest.NewRecorder()
+	err := responder.WriteJSON(rec, http.StatusCreated, responder.NewSuccessResponse("t-1", "ok", math.Inf(1)))
+
+	var encodingErr *core.EncodingError
+	require.True(t, errors.As(err, &encodingErr))
+	assert.Equal(t, "t-1", encodingErr.TraceID)
+	assert.Equal(t, http.StatusCreated, encodingErr.StatusCode)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "t-1", rec.Header().Get("X-Trace-ID"))
+	assertServerError(t, rec.Body.Bytes(), "t-1")
+	assert.Equal(t, 1.0, counterValue(t, registry, "http_response_encoding_errors_total"))
+}
+
+func TestFailingEncoderFallsBackToJSON(t *testing.T) {
+	broken := core.EncoderFunc(func(io.Writer, interface{}) error { return errors.New("broken encoder") })
+	responder := core.NewResponder(core.WithEncoder(broken))
+
+	rec := httptest.NewRecorder()
+	err := responder.WriteJSON(rec, http.StatusOK, responder.NewSuccessResponse("t-2", "ok", nil))
+	assert.ErrorContains(t, err, "broken encoder")
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assertServerError(t, rec.Body.Bytes(), "t-2")
+}
+
+func TestAdaptersSurfaceEncodingFailures(t *testing.T) {
+	adapter := adapters.New(core.NewResponder())
+	unencodable := make(chan int)
+
+	t.Run("net/http", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Trace-ID", "t-http")
+		rec := httptest.NewRecorder()
+		err := adapter.HTTPSuccessResponse(rec, req, "ok", unencodable)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assertServerError(t, rec.Body.Bytes(), "t-http")
+	})
+
+	t.Run("gin", func(t *testing.T) {
+		gin.SetMode(gin.TestMode)
+		engine := gin.New()
+		var handlerErr error
+		engine.GET("/", func(c *gin.Context) {
+			handlerErr = adapter.GinSuccessResponse(c, "ok", unencodable)
+			assert.Len(t, c.Errors, 1)
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Trace-ID", "t-gin")
+		rec := httptest.NewRecorder()
+		engine.ServeHTTP(rec, req)
+		assert.Error(t, handlerErr)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assertServerError(t, rec.Body.Bytes(), "t-gin")
+	})
+
+	t.Run("echo", func(t *testing.T) {
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Trace-ID", "t-echo")
+		rec := httptest.NewRecorder()
+		err := adapter.EchoSuccessResponse(echo.New().NewContext(req, rec), "ok", unencodable)
+		assert.Error(t, err)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		assertServerError(t, rec.Body.Bytes(), "t-echo")
+	})
+
+	t.Run("fiber", func(t *testing.T) {
+		var handlerErr error
+		app := fiber.New()
+		app.Get("/", func(c *fiber.Ctx) error {
+			handlerErr = adapter.FiberSuccessResponse(c, "ok", unencodable)
+			return nil
+		})
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("X-Trace-ID", "t-fiber")
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Error(t, handlerErr)
+		assert.Equal(t, http.StatusInternalServerError, resp.StatusCode)
+		assertServerError(t, body, "t-fiber")
+	})
+}
+
+// counterValue returns the value of an unlabelled counter registered in registry.
+func counterValue(t *testing.T, registry *prometheus.Registry, name string) float64 {
+	t.Helper()
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for _, family := range families {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetCounter().GetValue()
+		}
+	}
+	t.Fatalf("metric %s not registered", name)
+	return 0
+}
@@ -0,0 +1,144 @@
+package core_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type owner struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+type audited struct {
+	CreatedBy string `json:"created_by,omitempty"`
+}
+
+type project struct {
+	audited
+	ID     int               `json:"id"`
+	Name   string            `json:"name"`
+	Owner  *owner            `json:"owner"`
+	Tags   []string          `json:"tags,omitempty"`
+	Labels map[string]string `json:"labels"`
+	secret string
+}
+
+func project1() project {
+	return project{
+		audited: audited{CreatedBy: "ops"},
+		ID:      1,
+		Name:    "Apollo",
+		Owner:   &owner{Email: "a@example.com", Password: "hunter2"},
+		Labels:  map[string]string{"team": "core", "tier": "1"},
+		secret:  "x",
+	}
+}
+
+func projectJSON(t *testing.T, spec string, data interface{}) string {
+	t.Helper()
+	selector, err := core.ParseFieldSelector(spec)
+	require.NoError(t, err)
+	out, err := selector.Project(data)
+	require.NoError(t, err)
+	encoded, err := json.Marshal(out)
+	require.NoError(t, err)
+	return string(encoded)
+}
+
+func TestFieldSelectorProjection(t *testing.T) {
+	p := project1()
+
+	assert.JSONEq(t, `{"id":1,"name":"Apollo","owner":{"email":"a@example.com"}}`,
+		projectJSON(t, "id,name,owner.email", p))
+	assert.JSONEq(t, `{"created_by":"ops","id":1,"name":"Apollo","owner":{"email":"a@example.com"},"labels":{"team":"core"}}`,
+		projectJSON(t, "-owner.password,-labels.tier", p))
+	assert.JSONEq(t, `{"owner":{"email":"a@example.com"}}`, projectJSON(t, "owner,-owner.password", &p))
+	assert.JSONEq(t, `[{"id":1},{"id":1}]`, projectJSON(t, "id", []project{p, p}))
+	assert.JSONEq(t, `[{"id":7}]`, projectJSON(t, "id", []map[string]interface{}{{"id": 7, "name": "x"}}))
+	assert.JSONEq(t, `{"id":1,"tags":null}`, projectJSON(t, "id,tags", map[string]interface{}{"id": 1, "tags": nil}))
+
+	// Fields that exist but are empty, nil or absent from an empty list are
+	// valid selections.
+	assert.JSONEq(t, `{}`, projectJSON(t, "tags", p))
+	assert.JSONEq(t, `{"owner":null}`, projectJSON(t, "owner.email", project{}))
+	assert.JSONEq(t, `[]`, projectJSON(t, "owner.email", []project{}))
+}
+
+func TestFieldSelectorRejectsUnknownFields(t *testing.T) {
+	selector, err := core.ParseFieldSelector("id,owner.phone,secret,nope")
+	require.NoError(t, err)
+	_, err = selector.Project(project1())
+	var unknown *core.UnknownFieldsError
+	require.ErrorAs(t, err, &unknown)
+	assert.Equal(t, []string{"nope", "owner.phone", "secret"}, unknown.Fields)
+
+	_, err = core.ParseFieldSelector("owner..email")
+	assert.Error(t, err)
+}
+
+func TestFieldSelectionInAdapters(t *testing.T) {
+	adapter := adapters.New(core.NewResponder(core.WithFieldSelection()))
+	app := fiber.New()
+	app.Get("/projects/1", func(c *fiber.Ctx) error {
+		return adapter.FiberSuccessResponse(c, "Project", project1())
+	})
+
+	request := func(query string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest("GET", "/projects/1"+query, nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &decoded))
+		return resp.StatusCode, decoded
+	}
+
+	status, body := request("?fields=id,owner.email")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"id": float64(1), "owner": map[string]interface{}{"email": "a@example.com"}}, body["data"])
+
+	status, body = request("?fields=id,owner.phone")
+	assert.Equal(t, http.StatusUnprocessableEntity, status)
+	assert.Equal(t, "Invalid field selection", body["message"])
+	assert.Equal(t, map[string]interface{}{"fields": []interface{}{`unknown field "owner.phone"`}}, body["field_errors"])
+}
+
+func TestFieldSelectionIsOptIn(t *testing.T) {
+	adapter := adapters.New(core.NewResponder())
+	app := fiber.New()
+	app.Get("/projects/1", func(c *fiber.Ctx) error {
+		return adapter.FiberSuccessResponse(c, "Project", project1())
+	})
+	app.Get("/projects/1/summary", func(c *fiber.Ctx) error {
+		return adapter.FiberSuccessResponse(c, "Project", project1(), core.WithSelectableFields())
+	})
+
+	request := func(path string) (int, map[string]interface{}) {
+		resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+		require.NoError(t, err)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(body, &decoded))
+		return resp.StatusCode, decoded
+	}
+
+	// Endpoints using a fields parameter of their own are left alone.
+	status, body := request("/projects/1?fields=nope")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Contains(t, body["data"], "name")
+
+	status, body = request("/projects/1/summary?fields=id")
+	assert.Equal(t, http.StatusOK, status)
+	assert.Equal(t, map[string]interface{}{"id": float64(1)}, body["data"])
+}
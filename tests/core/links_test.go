@@ -0,0 +1,106 @@
+package core_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandURITemplate(t *testing.T) {
+	values := map[string]interface{}{
+		"var":   "value",
+		"hello": "Hello World!",
+		"path":  "/foo/bar",
+		"list":  []string{"red", "green", "blue"},
+		"keys":  map[string]string{"semi": ";", "dot": "."},
+		"empty": "",
+		"x":     1024,
+		"y":     768,
+	}
+	cases := map[string]string{
+		"{var}":           "value",
+		"{hello}":         "Hello%20World%21",
+		"{+path}/here":    "/foo/bar/here",
+		"{#path}":         "#/foo/bar",
+		"map?{x,y}":       "map?1024,768",
+		"X{.var}":         "X.value",
+		"{/var,x}/here":   "/value/1024/here",
+		"{;x,y,empty}":    ";x=1024;y=768;empty",
+		"{?x,y,empty}":    "?x=1024&y=768&empty=",
+		"?fixed=yes{&x}":  "?fixed=yes&x=1024",
+		"{/list*}":        "/red/green/blue",
+		"{?list}":         "?list=red,green,blue",
+		"{?list*}":        "?list=red&list=green&list=blue",
+		"{?keys*}":        "?dot=.&semi=%3B",
+		"/orders{?undef}": "/orders",
+	}
+	for template, want := range cases {
+		got, err := core.ExpandURITemplate(template, values)
+		require.NoError(t, err, template)
+		assert.Equal(t, want, got, template)
+	}
+
+	_, err := core.ExpandURITemplate("/orders/{id", nil)
+	assert.Error(t, err)
+}
+
+func TestLinkBuilder(t *testing.T) {
+	routes := core.RouteTemplates{"order": "/orders/{id}", "orders": "/orders{?status,page}"}
+	links, err := core.NewLinkBuilder(routes).
+		Route("self", "order", core.Params{"id": 42}).
+		Route("cancel", "order", core.Params{"id": 42}, core.LinkMethod("DELETE")).
+		Route("search", "orders", nil, core.LinkTitle("Find orders")).
+		Href("docs", "https://example.com/docs").
+		Build()
+	require.NoError(t, err)
+
+	assert.Equal(t, core.Link{Href: "/orders/42"}, links["self"])
+	assert.Equal(t, core.Link{Href: "/orders/42", Method: "DELETE"}, links["cancel"])
+	assert.Equal(t, core.Link{Href: "/orders{?status,page}", Templated: true, Title: "Find orders"}, links["search"])
+
+	_, err = core.NewLinkBuilder(routes).Route("self", "missing", nil).Build()
+	assert.Error(t, err)
+
+	links, err = core.NewLinkBuilder(nil).Build()
+	require.NoError(t, err)
+	assert.Nil(t, links)
+}
+
+func TestLinkStyles(t *testing.T) {
+	links := core.Links{
+		"self":   {Href: "/orders/1"},
+		"search": {Href: "/orders{?page}", Templated: true},
+	}
+	build := func(responder *core.Responder, opts ...core.ResponseOption) string {
+		resp := responder.NewSuccessResponse("t-1", "ok", nil)
+		for _, opt := range opts {
+			opt(&resp)
+		}
+		rec := httptest.NewRecorder()
+		require.NoError(t, responder.WriteJSON(rec, http.StatusOK, resp))
+		return rec.Body.String()
+	}
+
+	simple := core.NewResponder()
+	assert.Contains(t, build(simple, core.WithLinks(links)), `"links":{"search":"/orders{?page}","self":"/orders/1"}`)
+	assert.NotContains(t, build(simple), `"links"`)
+
+	hal := core.NewResponder(core.WithEnvelopeSchema(core.EnvelopeSchema{
+		Naming: config.NamingCamel,
+		Omit:   []string{"metadata"},
+		Links:  config.LinkStyleHAL,
+	}))
+	assert.JSONEq(t, `{"status":"success","message":"ok","traceId":"t-1",
+		"_links":{"self":{"href":"/orders/1"},"search":{"href":"/orders{?page}","templated":true}}}`,
+		build(hal, core.WithLinks(links)))
+
+	var decoded core.StandardResponse
+	require.NoError(t, json.Unmarshal([]byte(`{"links":{"self":"/orders/1","next":{"href":"/orders/2"}}}`), &decoded))
+	assert.Equal(t, core.Links{"self": {Href: "/orders/1"}, "next": {Href: "/orders/2"}}, decoded.Links)
+}
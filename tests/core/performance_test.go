@@ -0,0 +1,165 @@
+package core_test
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// standardEncoder encodes like core.JSONEncoder but, being a different
+// encoder, keeps responders off the envelope fast path.
+var standardEncoder = core.EncoderFunc(func(w io.Writer, v interface{}) error {
+	return core.JSONEncoder.Encode(w, v)
+})
+
+func TestFastPathMatchesStandardEncoding(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("region", "eu-west-1")
+	conf.UpdateMetadata("replicas", 3)
+	conf.UpdateMetadata("features", []string{"a", "b"})
+	clock := core.WithClock(func() time.Time { return time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC) })
+	fast := core.NewResponder(core.WithConfig(conf), clock)
+	standard := core.NewResponder(core.WithConfig(conf), clock, core.WithEncoder(standardEncoder))
+
+	responses := map[string]func(r *core.Responder) core.StandardResponse{
+		"success": func(r *core.Responder) core.StandardResponse {
+			return r.NewSuccessResponse("t-1", "<ok> & done", map[string]interface{}{"id": 1, "tags": []string{"x"}})
+		},
+		"api error": func(r *core.Responder) core.StandardResponse {
+			return r.NewAPIErrorResponse(http.StatusPreconditionFailed, "t-2", core.ErrPreconditionFailed)
+		},
+		"validation": func(r *core.Responder) core.StandardResponse {
+			return r.NewValidationErrorResponse("t-3", "invalid", map[string]interface{}{"name": "required"})
+		},
+		"modified metadata": func(r *core.Responder) core.StandardResponse {
+			resp := r.NewSuccessResponse("t-4", "ok", nil)
+			resp.Metadata["region"] = "us-east-1"
+			resp.Metadata["extra"] = true
+			return resp
+		},
+		"links": func(r *core.Responder) core.StandardResponse {
+			resp := r.NewSuccessResponse("", "ok", []int{1})
+			core.WithLinks(core.Links{"self": {Href: "/items"}})(&resp)
+			return resp
+		},
+	}
+	for name, build := range responses {
+		want, err := standard.Encode(http.StatusOK, build(standard))
+		require.NoError(t, err, name)
+		got, err := fast.Encode(http.StatusOK, build(fast))
+		require.NoError(t, err, name)
+		if name == "links" {
+			// Generated trace IDs differ between calls.
+			assert.Equal(t, len(want), len(got), name)
+			continue
+		}
+		assert.Equal(t, string(want), string(got), name)
+	}
+}
+
+func TestStaticMetadataFollowsConfigChanges(t *testing.T) {
+	conf := config.New()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	responder := core.NewResponder(core.WithConfig(conf), core.WithClock(func() time.Time { return now }))
+
+	first := responder.NewSuccessResponse("t-1", "ok", nil)
+	conf.UpdateMetadata("datacenter", "ap-south-1")
+	now = now.Add(1500 * time.Millisecond)
+	second := responder.NewSuccessResponse("t-2", "ok", nil)
+
+	assert.NotContains(t, first.Metadata, "datacenter")
+	assert.Equal(t, "ap-south-1", second.Metadata["datacenter"])
+	assert.Equal(t, "2024-01-02T03:04:06Z", second.Metadata["timestamp"], "cached timestamps advance with the clock")
+
+	body, err := responder.Encode(http.StatusOK, second)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), `"datacenter":"ap-south-1"`)
+}
+
+func TestETagIgnoresTraceIDAndTimestamp(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for _, opts := range [][]core.Option{nil, {core.WithEncoder(standardEncoder)}} {
+		responder := core.NewResponder(append(opts, core.WithClock(func() time.Time { return now }))...)
+		etag := func(traceID string) string {
+			resp := responder.NewSuccessResponse(traceID, "ok", map[string]int{"id": 1})
+			core.WithETag()(&resp)
+			prepared, err := responder.Prepare(http.StatusOK, resp)
+			require.NoError(t, err)
+			return prepared.Header.Get("ETag")
+		}
+		first := etag("t-1")
+		now = now.Add(time.Minute)
+		assert.Equal(t, first, etag("t-2"))
+	}
+}
+
+func TestConcurrentResponsesShareCaches(t *testing.T) {
+	conf := config.New()
+	responder := core.NewResponder(core.WithConfig(conf))
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if i == 0 && j%10 == 0 {
+					conf.UpdateMetadata("counter", j)
+				}
+				resp := responder.NewSuccessResponse("", "ok", j)
+				_, err := responder.Encode(http.StatusOK, resp)
+				assert.NoError(t, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestWriteJSONAllocations(t *testing.T) {
+	responder := core.NewResponder()
+	data := map[string]string{"id": "42"}
+	responder.NewSuccessResponse("warm", "ok", data)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		resp := responder.NewSuccessResponse("t-1", "ok", data)
+		if _, err := responder.Prepare(http.StatusOK, resp); err != nil {
+			t.Fatal(err)
+		}
+	})
+	assert.LessOrEqual(t, allocs, 20.0, "building and preparing a small response")
+}
+
+func BenchmarkNewSuccessResponse(b *testing.B) {
+	responder := core.NewResponder()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		responder.NewSuccessResponse("t-1", "ok", i)
+	}
+}
+
+func BenchmarkWriteJSON(b *testing.B) {
+	responder := core.NewResponder()
+	data := map[string]interface{}{"id": 42, "name": "widget", "tags": []string{"a", "b"}}
+	w := &discardResponseWriter{header: make(http.Header)}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		clear(w.header)
+		resp := responder.NewSuccessResponse("t-1", "ok", data)
+		if err := responder.WriteJSON(w, http.StatusOK, resp); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// discardResponseWriter is a reusable http.ResponseWriter that drops the body.
+type discardResponseWriter struct{ header http.Header }
+
+func (w *discardResponseWriter) Header() http.Header         { return w.header }
+func (w *discardResponseWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *discardResponseWriter) WriteHeader(int)             {}
@@ -0,0 +1,53 @@
+package core_test
+
+import (
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestConcurrentConfigUpdatesAndResponses is meant to be run with -race.
+func TestConcurrentConfigUpdatesAndResponses(t *testing.T) {
+	conf := config.New()
+	responder := core.NewResponder(core.WithConfig(conf))
+
+	path := filepath.Join(t.TempDir(), "config.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"GlobalMetadata": {"version": "2.0.0"}}`), 0o600))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				conf.UpdateMetadata(fmt.Sprintf("key-%d", j%10), j)
+				if j%50 == 0 {
+					assert.NoError(t, conf.LoadFromFile(path))
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				resp := responder.NewSuccessResponse("", "ok", nil)
+				assert.Contains(t, resp.Metadata, "timestamp")
+				assert.NoError(t, responder.WriteJSON(httptest.NewRecorder(), 200, resp))
+			}
+		}()
+	}
+	wg.Wait()
+
+	saved := filepath.Join(t.TempDir(), "saved.json")
+	require.NoError(t, conf.SaveToFile(saved))
+	raw, err := os.ReadFile(saved)
+	require.NoError(t, err)
+	assert.NotContains(t, string(raw), "mu")
+}
@@ -0,0 +1,103 @@
+package core_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/logger/loggertest"
+	"github.com/andreascandle/FlexiResponseGo/observability"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+func TestRespondersAreIndependent(t *testing.T) {
+	fixed := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	billingConf := config.New()
+	billingConf.UpdateMetadata("serviceName", "billing")
+
+	billing := core.NewResponder(
+		core.WithConfig(billingConf),
+		core.WithClock(func() time.Time { return fixed }),
+		core.WithIDGenerator(func() string { return "billing-id" }),
+	)
+	search := core.NewResponder()
+
+	resp := billing.NewSuccessResponse("", "ok", nil)
+	assert.Equal(t, "billing-id", resp.TraceID)
+	assert.Equal(t, "billing", resp.Metadata["serviceName"])
+	assert.Equal(t, fixed.Format(time.RFC3339), resp.Metadata["timestamp"])
+
+	other := search.NewSuccessResponse("trace-1", "ok", nil)
+	assert.Equal(t, "FlexiResponseGo", other.Metadata["serviceName"])
+}
+
+func TestPackageFunctionsUseDefaultResponder(t *testing.T) {
+	previous := core.Default()
+	defer core.SetDefault(previous)
+
+	core.SetDefault(core.NewResponder(core.WithIDGenerator(func() string { return "default-id" })))
+	resp := core.NewErrorResponse("", "failed", "boom")
+	assert.Equal(t, "default-id", resp.TraceID)
+}
+
+func TestAdapterUsesResponderLoggerAndMetrics(t *testing.T) {
+	tl := loggertest.New(t)
+	registry := prometheus.NewRegistry()
+	metrics := observability.NewMetrics(registry)
+
+	adapter := adapters.New(core.NewResponder(
+		core.WithLogger(tl.Logger),
+		core.WithMetrics(metrics),
+		core.WithIDGenerator(func() string { return "adapter-id" }),
+	))
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapter.HTTPSuccessResponse(w, r, "ok", nil)
+	})
+	rec := tests.PerformRequest(handler, "GET", "/items", nil)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "adapter-id", rec.Header().Get("X-Trace-ID"))
+	tl.AssertLogged(t, zapcore.InfoLevel, "Outgoing response", zap.String("trace_id", "adapter-id"))
+
+	count, err := testutil.GatherAndCount(registry, "http_requests_total")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+
+	metricsRec := httptest.NewRecorder()
+	metrics.Handler().ServeHTTP(metricsRec, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, metricsRec.Body.String(), `http_requests_total{host="example.com"`)
+}
+
+func TestResponderLoggerFollowsConfigChanges(t *testing.T) {
+	conf := config.New()
+	tl := loggertest.New(t)
+	responder := core.NewResponder(core.WithConfig(conf), core.WithLogger(tl.Logger))
+
+	conf.UpdateLogLevel("warn")
+	responder.Logger().Info("Suppressed")
+	responder.Logger().Warn("Kept")
+
+	tl.AssertNotLogged(t, zapcore.InfoLevel, "Suppressed")
+	tl.AssertLogged(t, zapcore.WarnLevel, "Kept")
+}
+
+func TestClosedResponderStopsFollowingConfig(t *testing.T) {
+	conf := config.New()
+	tl := loggertest.New(t)
+	responder := core.NewResponder(core.WithConfig(conf), core.WithLogger(tl.Logger))
+	assert.NoError(t, responder.Close())
+
+	conf.UpdateLogLevel("error")
+	responder.Logger().Warn("Kept")
+	tl.AssertLogged(t, zapcore.WarnLevel, "Kept")
+}
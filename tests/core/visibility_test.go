@@ -0,0 +1,62 @@
+package core_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInternalSettingsDoNotLeak(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("enableLocalization", true)
+	conf.UpdateSetting("featureFlag", true)
+
+	resp := core.NewResponder(core.WithConfig(conf)).NewSuccessResponse("t", "ok", nil)
+	assert.NotContains(t, resp.Metadata, "enableLocalization")
+	assert.NotContains(t, resp.Metadata, "featureFlag")
+	assert.Equal(t, "FlexiResponseGo", resp.Metadata["serviceName"])
+}
+
+func TestMetadataVisibilityRules(t *testing.T) {
+	conf := config.New()
+	conf.UpdateMetadata("supportEmail", "help@example.com")
+	conf.UpdateMetadata("build", "abc123")
+	conf.SetMetadataVisibility("supportEmail", config.VisibilityErrors)
+	conf.SetMetadataVisibility("build", config.VisibilityDebug)
+	responder := core.NewResponder(core.WithConfig(conf))
+
+	success := responder.NewSuccessResponse("t", "ok", nil)
+	assert.NotContains(t, success.Metadata, "supportEmail")
+	assert.NotContains(t, success.Metadata, "build")
+
+	failure := responder.NewErrorResponse("t", "failed", "boom")
+	assert.Equal(t, "help@example.com", failure.Metadata["supportEmail"])
+	assert.NotContains(t, failure.Metadata, "build")
+
+	conf.UpdateLogLevel("debug")
+	debug := responder.NewSuccessResponse("t", "ok", nil)
+	assert.Equal(t, "abc123", debug.Metadata["build"])
+}
+
+func TestRouteMetadataVisibilityOverride(t *testing.T) {
+	conf := config.New()
+	conf.SetRouteMetadataVisibility("/public/*", "region", config.VisibilityNever)
+	adapter := adapters.New(core.NewResponder(core.WithConfig(conf)))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapter.HTTPSuccessResponse(w, r, "ok", nil)
+	})
+
+	var resp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(tests.PerformRequest(handler, "GET", "/public/status", nil), &resp))
+	assert.NotContains(t, resp.Metadata, "region")
+
+	resp = core.StandardResponse{}
+	require.NoError(t, tests.ParseJSON(tests.PerformRequest(handler, "GET", "/internal/status", nil), &resp))
+	assert.Equal(t, "default-region", resp.Metadata["region"])
+}
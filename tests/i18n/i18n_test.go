@@ -0,0 +1,96 @@
+package i18n_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/i18n"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestFormatPluralsAndParams(t *testing.T) {
+	pattern := "{name} has {count, plural, =0 {no items} one {# item} other {# items}}"
+
+	for count, want := range map[int]string{0: "Ana has no items", 1: "Ana has 1 item", 5: "Ana has 5 items"} {
+		got, err := i18n.Format(language.English, pattern, map[string]interface{}{"name": "Ana", "count": count})
+		require.NoError(t, err)
+		assert.Equal(t, want, got)
+	}
+
+	// Polish uses the "few" and "many" CLDR categories.
+	pl := "{n, plural, one {# plik} few {# pliki} many {# plików} other {# pliku}}"
+	got, _ := i18n.Format(language.Polish, pl, map[string]interface{}{"n": 3})
+	assert.Equal(t, "3 pliki", got)
+	got, _ = i18n.Format(language.Polish, pl, map[string]interface{}{"n": 5})
+	assert.Equal(t, "5 plików", got)
+
+	got, _ = i18n.Format(language.English, "{g, select, female {her} other {their}} '{quoted}'", map[string]interface{}{"g": "female"})
+	assert.Equal(t, "her {quoted}", got)
+
+	_, err := i18n.Format(language.English, "{n, plural, one {x}}", map[string]interface{}{"n": 1})
+	assert.Error(t, err, "plural without other must be rejected")
+}
+
+func TestBundleFilesAndNegotiation(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "en.json"), []byte(`{"greeting": "Hello", "errors": {"notFound": "Not found"}}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "fr.yaml"), []byte("greeting: Bonjour\n"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pt-BR.toml"), []byte("greeting = \"Olá\"\n"), 0o600))
+
+	bundle := i18n.NewBundle(language.English)
+	require.NoError(t, bundle.LoadDir(dir))
+
+	assert.Equal(t, language.French, bundle.Negotiate("de;q=0.9, fr-CA;q=0.8"))
+	assert.Equal(t, language.English, bundle.Negotiate("ja"))
+	assert.Equal(t, language.English, bundle.Negotiate(""))
+
+	text, ok := bundle.Localize(language.French, "errors.notFound", nil)
+	assert.True(t, ok, "missing keys fall back to the default locale")
+	assert.Equal(t, "Not found", text)
+
+	text, _ = bundle.Localize(language.MustParse("pt-BR"), "greeting", nil)
+	assert.Equal(t, "Olá", text)
+}
+
+func TestAdapterLocalizesMessages(t *testing.T) {
+	bundle := i18n.NewBundle(language.English)
+	bundle.AddMessages(language.English, map[string]string{"user.created": "User created", "field.required": "This field is required"})
+	bundle.AddMessages(language.French, map[string]string{"user.created": "Utilisateur créé", "field.required": "Ce champ est obligatoire"})
+
+	responder := core.NewResponder(core.WithLocalizer(bundle))
+	adapter := adapters.New(responder)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		adapter.HTTPSuccessResponse(w, r, "user.created", nil)
+	})
+
+	req := httptest.NewRequest("GET", "/users", nil)
+	req.Header.Set("Accept-Language", "fr-FR,fr;q=0.9,en;q=0.5")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	var resp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(rec, &resp))
+	assert.Equal(t, "Utilisateur créé", resp.Message)
+	assert.Equal(t, "fr", rec.Header().Get("Content-Language"))
+
+	fr := responder.ForLocale(language.French)
+	validation := fr.NewValidationErrorResponse("t", "Invalid", map[string]interface{}{"email": "field.required"})
+	assert.Equal(t, "Ce champ est obligatoire", validation.FieldErrors["email"])
+
+	apiRec := httptest.NewRecorder()
+	require.NoError(t, fr.WriteErrorResponse(apiRec, http.StatusBadRequest, "t", core.NewAPIError(core.ClientError, 400, "field.required", "")))
+	var apiResp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(apiRec, &apiResp))
+	assert.Equal(t, "Ce champ est obligatoire", apiResp.Message)
+
+	plain := core.NewResponder().NewSuccessResponse("t", "user.created", nil)
+	assert.Equal(t, "user.created", plain.Message, "responders without catalogs leave messages untouched")
+}
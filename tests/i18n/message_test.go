@@ -0,0 +1,81 @@
+package i18n_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/config"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/i18n"
+	"github.com/andreascandle/FlexiResponseGo/tests"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func newCatalogResponder(conf *config.Config) *core.Responder {
+	bundle := i18n.NewBundle(language.English)
+	bundle.AddMessages(language.English, map[string]string{
+		"cart.items": "{count, plural, one {# item} other {# items}} in your cart",
+	})
+	bundle.AddMessages(language.German, map[string]string{
+		"cart.items": "{count, plural, one {# Artikel} other {# Artikel}} im Warenkorb",
+		"min.length": "Mindestens {min} Zeichen",
+	})
+	return core.NewResponder(core.WithConfig(conf), core.WithLocalizer(bundle))
+}
+
+func TestKeyedMessagesRenderAtWriteTime(t *testing.T) {
+	conf := config.New()
+	responder := newCatalogResponder(conf)
+
+	resp := responder.NewSuccessResponseWithKey("t", core.NewMessage("cart.items", core.Params{"count": 3}), nil)
+	assert.Equal(t, "3 items in your cart", resp.Message)
+	assert.Empty(t, resp.MessageKey)
+
+	conf.UpdateSetting("includeMessageKey", true)
+	rec := httptest.NewRecorder()
+	require.NoError(t, responder.ForLocale(language.German).WriteJSON(rec, http.StatusOK, resp))
+
+	var written core.StandardResponse
+	require.NoError(t, tests.ParseJSON(rec, &written))
+	assert.Equal(t, "3 Artikel im Warenkorb", written.Message)
+	assert.Equal(t, "cart.items", written.MessageKey)
+	assert.Equal(t, "de", rec.Header().Get("Content-Language"))
+}
+
+func TestKeyedMessageFallbackAndFieldErrors(t *testing.T) {
+	responder := core.NewResponder()
+
+	resp := responder.NewErrorResponseWithKey("t",
+		core.NewMessage("quota.exceeded", core.Params{"limit": 10}).WithFallback("Quota of {limit} exceeded"), "")
+	assert.Equal(t, "Quota of 10 exceeded", resp.Message)
+
+	validation := newCatalogResponder(config.New()).ForLocale(language.German).NewValidationErrorResponseWithKey("t",
+		core.NewMessage("validation.failed", nil),
+		map[string]interface{}{"password": core.NewMessage("min.length", core.Params{"min": 8})})
+	assert.Equal(t, "validation.failed", validation.Message)
+	assert.Equal(t, "Mindestens 8 Zeichen", validation.FieldErrors["password"])
+}
+
+func TestGinKeyedResponseUsesNegotiatedLocale(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	adapter := adapters.New(newCatalogResponder(config.New()))
+	router := gin.New()
+	router.GET("/cart", func(c *gin.Context) {
+		adapter.GinSuccessResponseWithKey(c, core.NewMessage("cart.items", core.Params{"count": 1}), nil)
+	})
+
+	req := httptest.NewRequest("GET", "/cart", nil)
+	req.Header.Set("Accept-Language", "de-DE")
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var resp core.StandardResponse
+	require.NoError(t, tests.ParseJSON(rec, &resp))
+	assert.Equal(t, "1 Artikel im Warenkorb", resp.Message)
+	assert.Equal(t, "de", rec.Header().Get("Content-Language"))
+}
@@ -0,0 +1,376 @@
+package idempotency_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/idempotency"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func stores(t *testing.T, clock func() time.Time) map[string]idempotency.Store {
+	file, err := idempotency.NewFileStore(t.TempDir(), idempotency.WithStoreClock(clock))
+	require.NoError(t, err)
+	return map[string]idempotency.Store{
+		"memory": idempotency.NewMemoryStore(idempotency.WithStoreClock(clock)),
+		"file":   file,
+	}
+}
+
+func TestStores(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, store := range stores(t, func() time.Time { return now }) {
+		first := idempotency.Record{Fingerprint: "a", ExpiresAt: now.Add(time.Minute)}
+		_, reserved, err := store.Reserve("key", first)
+		require.NoError(t, err, name)
+		assert.True(t, reserved, name)
+
+		existing, reserved, err := store.Reserve("key", idempotency.Record{Fingerprint: "b", ExpiresAt: now.Add(time.Minute)})
+		require.NoError(t, err, name)
+		assert.False(t, reserved, name)
+		assert.Equal(t, "a", existing.Fingerprint, name)
+
+		done := idempotency.Record{Fingerprint: "a", Completed: true, StatusCode: 201, Header: http.Header{"Location": {"/p/1"}}, Body: []byte(`{}`), ExpiresAt: now.Add(time.Hour)}
+		require.NoError(t, store.Save("key", done), name)
+		existing, _, err = store.Reserve("key", first)
+		require.NoError(t, err, name)
+		assert.True(t, existing.Completed, name)
+		assert.Equal(t, "/p/1", existing.Header.Get("Location"), name)
+		assert.Equal(t, []byte(`{}`), existing.Body, name)
+
+		now = now.Add(2 * time.Hour)
+		_, reserved, err = store.Reserve("key", idempotency.Record{Fingerprint: "c", ExpiresAt: now.Add(time.Minute)})
+		require.NoError(t, err, name)
+		assert.True(t, reserved, "%s: expired records are replaced", name)
+
+		require.NoError(t, store.Delete("key"), name)
+		require.NoError(t, store.Delete("key"), name)
+	}
+}
+
+func TestFileStorePersistsAndCleansUp(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := idempotency.WithStoreClock(func() time.Time { return now })
+	dir := t.TempDir()
+	store, err := idempotency.NewFileStore(dir, clock)
+	require.NoError(t, err)
+	require.NoError(t, store.Save("../escape", idempotency.Record{Fingerprint: "a", Completed: true, ExpiresAt: now.Add(time.Minute)}))
+	require.NoError(t, store.Save("other", idempotency.Record{Fingerprint: "b", Completed: true, ExpiresAt: now.Add(time.Hour)}))
+
+	reopened, err := idempotency.NewFileStore(dir, clock)
+	require.NoError(t, err)
+	existing, reserved, err := reopened.Reserve("../escape", idempotency.Record{})
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "a", existing.Fingerprint)
+
+	now = now.Add(30 * time.Minute)
+	removed, err := reopened.Cleanup()
+	require.NoError(t, err)
+	assert.Equal(t, 1, removed)
+}
+
+func TestFileStoreTakesOverExpiredRecordsOnce(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	dir := t.TempDir()
+	newStore := func() *idempotency.FileStore {
+		store, err := idempotency.NewFileStore(dir, idempotency.WithStoreClock(func() time.Time { return now }))
+		require.NoError(t, err)
+		return store
+	}
+	require.NoError(t, newStore().Save("key", idempotency.Record{Fingerprint: "old", Completed: true, ExpiresAt: now.Add(-time.Minute)}))
+
+	// Separate stores stand in for separate processes sharing the directory.
+	var wg sync.WaitGroup
+	var reservations atomic.Int32
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func(store *idempotency.FileStore) {
+			defer wg.Done()
+			_, reserved, err := store.Reserve("key", idempotency.Record{Fingerprint: "new", ExpiresAt: now.Add(time.Minute)})
+			if err == nil && reserved {
+				reservations.Add(1)
+			}
+		}(newStore())
+	}
+	wg.Wait()
+	assert.EqualValues(t, 1, reservations.Load())
+
+	existing, reserved, err := newStore().Reserve("key", idempotency.Record{Fingerprint: "later", ExpiresAt: now.Add(time.Minute)})
+	require.NoError(t, err)
+	assert.False(t, reserved)
+	assert.Equal(t, "new", existing.Fingerprint)
+}
+
+func TestMiddlewareBoundsBodies(t *testing.T) {
+	var calls atomic.Int32
+	guard := idempotency.New(idempotency.NewMemoryStore(), idempotency.WithMaxBodySize(16))
+	handler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		_, _ = io.WriteString(w, `{"part":1}`)
+		w.(http.Flusher).Flush()
+		if r.URL.Query().Get("large") != "" {
+			_, _ = io.WriteString(w, `{"part":2}`)
+		}
+	}))
+	post := func(key, target, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", target, strings.NewReader(body))
+		req.Header.Set("Idempotency-Key", key)
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post("too-large", "/payments", strings.Repeat("x", 17))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+	assert.Zero(t, calls.Load())
+
+	rec = post("small", "/payments", `{}`)
+	assert.True(t, rec.Flushed)
+	assert.Equal(t, `{"part":1}`, post("small", "/payments", `{}`).Body.String())
+	assert.EqualValues(t, 1, calls.Load(), "small responses are replayed")
+
+	assert.Equal(t, `{"part":1}{"part":2}`, post("large", "/payments?large=1", `{}`).Body.String())
+	assert.Equal(t, `{"part":1}{"part":2}`, post("large", "/payments?large=1", `{}`).Body.String())
+	assert.EqualValues(t, 3, calls.Load(), "responses over the limit are not stored")
+
+	// Requests without a key are not buffered, whatever their size.
+	req := httptest.NewRequest("POST", "/payments", strings.NewReader(strings.Repeat("x", 64)))
+	unkeyed := httptest.NewRecorder()
+	handler.ServeHTTP(unkeyed, req)
+	assert.Equal(t, http.StatusOK, unkeyed.Code)
+}
+
+func TestGuard(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return now }
+	guard := idempotency.New(idempotency.NewMemoryStore(idempotency.WithStoreClock(clock)),
+		idempotency.WithClock(clock), idempotency.WithTTL(time.Hour))
+	headers := http.Header{"Idempotency-Key": {"k-1"}, "X-Trace-Id": {"t-1"}}
+	body := []byte(`{"amount":100}`)
+
+	pending, prepared := guard.Begin("POST", "/payments", headers, body)
+	require.NotNil(t, pending)
+	assert.Nil(t, prepared)
+
+	_, prepared = guard.Begin("POST", "/payments", headers, body)
+	require.NotNil(t, prepared)
+	assert.Equal(t, http.StatusConflict, prepared.StatusCode, "in-flight duplicates are rejected")
+	assert.Equal(t, "1", prepared.Header.Get("Retry-After"))
+	assert.Contains(t, string(prepared.Body), `"trace_id":"t-1"`)
+
+	_, prepared = guard.Begin("POST", "/payments", headers, []byte(`{"amount":999}`))
+	require.NotNil(t, prepared)
+	assert.Equal(t, http.StatusUnprocessableEntity, prepared.StatusCode, "a different body is rejected")
+
+	require.NoError(t, pending.Finish(http.StatusCreated, http.Header{"Location": {"/payments/1"}, "Content-Length": {"2"}}, []byte(`{}`)))
+	_, prepared = guard.Begin("POST", "/payments", headers, body)
+	require.NotNil(t, prepared)
+	assert.Equal(t, http.StatusCreated, prepared.StatusCode)
+	assert.Equal(t, "/payments/1", prepared.Header.Get("Location"))
+	assert.Equal(t, "true", prepared.Header.Get(idempotency.ReplayedHeader))
+	assert.Empty(t, prepared.Header.Get("Content-Length"))
+	assert.Equal(t, `{}`, string(prepared.Body))
+
+	stolen := http.Header{"Idempotency-Key": {"k-1"}, "Authorization": {"Bearer other"}}
+	_, prepared = guard.Begin("POST", "/payments", stolen, body)
+	assert.Equal(t, http.StatusUnprocessableEntity, prepared.StatusCode, "other clients never get the stored response")
+
+	now = now.Add(2 * time.Hour)
+	pending, prepared = guard.Begin("POST", "/payments", headers, body)
+	assert.NotNil(t, pending, "expired keys run the handler again")
+	assert.Nil(t, prepared)
+	require.NoError(t, pending.Finish(http.StatusBadGateway, http.Header{}, nil))
+	pending, _ = guard.Begin("POST", "/payments", headers, body)
+	assert.NotNil(t, pending, "server errors release the key")
+	pending.Abandon()
+
+	pending, prepared = guard.Begin("GET", "/payments", headers, nil)
+	assert.True(t, pending == nil && prepared == nil, "safe methods are not guarded")
+	pending, prepared = guard.Begin("POST", "/payments", http.Header{}, body)
+	assert.True(t, pending == nil && prepared == nil, "requests without a key pass through")
+	_, prepared = guard.Begin("POST", "/payments", http.Header{"Idempotency-Key": {strings.Repeat("k", 256)}}, body)
+	assert.Equal(t, http.StatusBadRequest, prepared.StatusCode)
+}
+
+func TestFrameworks(t *testing.T) {
+	var calls atomic.Int32
+	var create = func(body []byte) (int, string) {
+		n := calls.Add(1)
+		return http.StatusCreated, `{"payment":` + string(rune('0'+n)) + `,"request":` + string(body) + `}`
+	}
+
+	gin.SetMode(gin.TestMode)
+	newHandlers := func(guard *idempotency.Guard) map[string]http.Handler {
+		httpHandler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, _ := io.ReadAll(r.Body)
+			status, out := create(body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_, _ = io.WriteString(w, out)
+		}))
+		engine := gin.New()
+		engine.Use(adapters.GinIdempotency(guard))
+		engine.POST("/payments", func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			status, out := create(body)
+			c.Data(status, "application/json", []byte(out))
+		})
+		e := echo.New()
+		e.Use(adapters.EchoIdempotency(guard))
+		e.POST("/payments", func(c echo.Context) error {
+			body, _ := io.ReadAll(c.Request().Body)
+			status, out := create(body)
+			return c.Blob(status, "application/json", []byte(out))
+		})
+		app := fiber.New()
+		app.Use(adapters.FiberIdempotency(guard))
+		app.Post("/payments", func(c *fiber.Ctx) error {
+			status, out := create(c.Body())
+			c.Set("Content-Type", "application/json")
+			return c.Status(status).SendString(out)
+		})
+		return map[string]http.Handler{
+			"net/http": httpHandler,
+			"gin":      engine,
+			"echo":     e,
+			"fiber":    fiberHandler{app},
+		}
+	}
+
+	for framework, handler := range newHandlers(idempotency.New(idempotency.NewMemoryStore())) {
+		calls.Store(0)
+		post := func(key, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+			req.Header.Set("Idempotency-Key", key)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		first := post(framework, `{"amount":5}`)
+		assert.Equal(t, http.StatusCreated, first.Code, framework)
+		replay := post(framework, `{"amount":5}`)
+		assert.Equal(t, http.StatusCreated, replay.Code, framework)
+		assert.Equal(t, first.Body.String(), replay.Body.String(), framework)
+		assert.Equal(t, "application/json", replay.Header().Get("Content-Type"), framework)
+		assert.Equal(t, "true", replay.Header().Get(idempotency.ReplayedHeader), framework)
+		assert.EqualValues(t, 1, calls.Load(), "%s: the handler runs once", framework)
+
+		reused := post(framework, `{"amount":6}`)
+		assert.Equal(t, http.StatusUnprocessableEntity, reused.Code, framework)
+		var resp core.StandardResponse
+		require.NoError(t, json.Unmarshal(reused.Body.Bytes(), &resp), framework)
+		assert.Equal(t, "error", resp.Status, framework)
+		assert.EqualValues(t, 1, calls.Load(), framework)
+	}
+}
+
+func TestFrameworksHandleHandlerErrors(t *testing.T) {
+	var calls atomic.Int32
+	errCrashed := errors.New("crashed")
+	// fail reports whether the request body asks for a conflict or a crash.
+	fail := func(body []byte) string {
+		calls.Add(1)
+		var req struct{ Fail string }
+		_ = json.Unmarshal(body, &req)
+		return req.Fail
+	}
+
+	guard := idempotency.New(idempotency.NewMemoryStore())
+	httpHandler := guard.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if fail(body) == "conflict" {
+			http.Error(w, "conflict", http.StatusConflict)
+			return
+		}
+		http.Error(w, "crashed", http.StatusInternalServerError)
+	}))
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.Use(adapters.GinIdempotency(guard))
+	engine.POST("/payments", func(c *gin.Context) {
+		body, _ := io.ReadAll(c.Request.Body)
+		if fail(body) == "conflict" {
+			c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "conflict"})
+			return
+		}
+		_ = c.AbortWithError(http.StatusInternalServerError, errCrashed)
+	})
+	e := echo.New()
+	e.Use(adapters.EchoIdempotency(guard))
+	e.POST("/payments", func(c echo.Context) error {
+		body, _ := io.ReadAll(c.Request().Body)
+		if fail(body) == "conflict" {
+			return echo.NewHTTPError(http.StatusConflict, "conflict")
+		}
+		return errCrashed
+	})
+	app := fiber.New()
+	app.Use(adapters.FiberIdempotency(guard))
+	app.Post("/payments", func(c *fiber.Ctx) error {
+		if fail(c.Body()) == "conflict" {
+			return fiber.NewError(http.StatusConflict, "conflict")
+		}
+		return errCrashed
+	})
+
+	handlers := map[string]http.Handler{"net/http": httpHandler, "gin": engine, "echo": e, "fiber": fiberHandler{app}}
+	for framework, handler := range handlers {
+		post := func(key, body string) *httptest.ResponseRecorder {
+			req := httptest.NewRequest("POST", "/payments", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Idempotency-Key", key)
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			return rec
+		}
+
+		calls.Store(0)
+		first := post(framework+"-conflict", `{"fail":"conflict"}`)
+		assert.Equal(t, http.StatusConflict, first.Code, framework)
+		assert.NotEmpty(t, first.Body.String(), framework)
+		replay := post(framework+"-conflict", `{"fail":"conflict"}`)
+		assert.Equal(t, http.StatusConflict, replay.Code, "%s: client errors are replayed", framework)
+		assert.Equal(t, first.Body.String(), replay.Body.String(), framework)
+		assert.Equal(t, "true", replay.Header().Get(idempotency.ReplayedHeader), framework)
+		assert.EqualValues(t, 1, calls.Load(), framework)
+
+		calls.Store(0)
+		assert.Equal(t, http.StatusInternalServerError, post(framework+"-crash", `{"fail":"crash"}`).Code, framework)
+		retry := post(framework+"-crash", `{"fail":"crash"}`)
+		assert.Equal(t, http.StatusInternalServerError, retry.Code, framework)
+		assert.Empty(t, retry.Header().Get(idempotency.ReplayedHeader), "%s: server errors release the key", framework)
+		assert.EqualValues(t, 2, calls.Load(), framework)
+	}
+}
+
+// fiberHandler serves a Fiber app as an http.Handler for tests.
+type fiberHandler struct{ app *fiber.App }
+
+func (h fiberHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resp, err := h.app.Test(r)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	for key, values := range resp.Header {
+		w.Header()[key] = values
+	}
+	w.WriteHeader(resp.StatusCode)
+	_, _ = io.Copy(w, resp.Body)
+}
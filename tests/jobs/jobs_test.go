@@ -0,0 +1,173 @@
+package jobs_test
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/jobs"
+	"github.com/gin-gonic/gin"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// statusBody is the decoded response of a job status URL.
+type statusBody struct {
+	Message string            `json:"message"`
+	Data    jobs.Job          `json:"data"`
+	Links   map[string]string `json:"links"`
+}
+
+func TestJobLifecycle(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return now }
+	tracker := jobs.NewTracker(jobs.NewMemoryStore(jobs.WithStoreClock(clock)), jobs.WithClock(clock), jobs.WithTTL(time.Hour))
+
+	job, err := tracker.Create()
+	require.NoError(t, err)
+	assert.Equal(t, jobs.Pending, job.State)
+	assert.Len(t, job.ID, 32)
+	assert.Equal(t, now.Add(time.Hour), job.ExpiresAt)
+
+	now = now.Add(time.Minute)
+	job, err = tracker.Start(job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, jobs.Running, job.State)
+	assert.Equal(t, now.Add(time.Hour), job.ExpiresAt, "changes renew the expiry")
+
+	job, err = tracker.Succeed(job.ID, map[string]int{"rows": 10})
+	require.NoError(t, err)
+	assert.Equal(t, jobs.Succeeded, job.State)
+	_, err = tracker.Fail(job.ID, core.ErrEncodingFailed)
+	assert.ErrorIs(t, err, jobs.ErrFinished)
+
+	_, err = tracker.Start("missing")
+	assert.ErrorIs(t, err, jobs.ErrNotFound)
+}
+
+func TestMemoryStoreExpiry(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	clock := func() time.Time { return now }
+	store := jobs.NewMemoryStore(jobs.WithStoreClock(clock))
+	tracker := jobs.NewTracker(store, jobs.WithClock(clock), jobs.WithTTL(time.Minute))
+
+	expiring, err := tracker.Create()
+	require.NoError(t, err)
+	now = now.Add(30 * time.Second)
+	kept, err := tracker.Create()
+	require.NoError(t, err)
+
+	now = now.Add(30 * time.Second)
+	_, err = tracker.Get(expiring.ID)
+	assert.ErrorIs(t, err, jobs.ErrNotFound, "expired jobs are hidden before cleanup")
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, 1, store.Cleanup())
+	_, err = tracker.Get(kept.ID)
+	assert.NoError(t, err)
+
+	now = now.Add(time.Minute)
+	stop := store.StartCleanup(time.Millisecond)
+	defer stop()
+	assert.Eventually(t, func() bool { return store.Len() == 0 }, time.Second, time.Millisecond)
+}
+
+func TestAcceptedResponse(t *testing.T) {
+	responder := core.NewResponder()
+	tracker := jobs.NewTracker(jobs.NewMemoryStore(), jobs.WithStatusPath("/exports/status"), jobs.WithRetryAfter(1500*time.Millisecond))
+	job, err := tracker.Create()
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	require.NoError(t, responder.WriteJSON(rec, http.StatusAccepted, tracker.NewAcceptedResponse(responder, "t-1", job)))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, "/exports/status/"+job.ID, rec.Header().Get("Location"))
+	assert.Equal(t, "2", rec.Header().Get("Retry-After"), "rounded up to whole seconds")
+	assert.Equal(t, "no-store", rec.Header().Get("Cache-Control"))
+
+	var body statusBody
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, job.ID, body.Data.ID)
+	assert.Equal(t, jobs.Pending, body.Data.State)
+	assert.Equal(t, "/exports/status/"+job.ID, body.Links["monitor"])
+	assert.Equal(t, job.ID, tracker.JobID(body.Links["monitor"]))
+}
+
+func TestJobStatusHandlers(t *testing.T) {
+	tracker := jobs.NewTracker(jobs.NewMemoryStore())
+	pending, err := tracker.Create()
+	require.NoError(t, err)
+	failed, err := tracker.Create()
+	require.NoError(t, err)
+	_, err = tracker.Fail(failed.ID, core.NewAPIError(core.ServerError, http.StatusBadGateway, "Upstream failed", "timeout"))
+	require.NoError(t, err)
+
+	adapter := adapters.New(core.NewResponder())
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/jobs/:id", adapter.GinJobStatus(tracker))
+	app := fiber.New()
+	app.Get("/jobs/:id", adapter.FiberJobStatus(tracker))
+	serve := map[string]func(path string) (*http.Response, []byte){
+		"net/http": func(path string) (*http.Response, []byte) {
+			rec := httptest.NewRecorder()
+			adapter.HTTPJobStatus(tracker).ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			return rec.Result(), rec.Body.Bytes()
+		},
+		"gin": func(path string) (*http.Response, []byte) {
+			rec := httptest.NewRecorder()
+			engine.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+			return rec.Result(), rec.Body.Bytes()
+		},
+		"fiber": func(path string) (*http.Response, []byte) {
+			resp, err := app.Test(httptest.NewRequest("GET", path, nil))
+			require.NoError(t, err)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			return resp, body
+		},
+	}
+
+	for framework, get := range serve {
+		resp, raw := get(tracker.StatusURL(pending.ID))
+		assert.Equal(t, http.StatusOK, resp.StatusCode, framework)
+		assert.Equal(t, "5", resp.Header.Get("Retry-After"), framework)
+		var body statusBody
+		require.NoError(t, json.Unmarshal(raw, &body), framework)
+		assert.Equal(t, "Job pending", body.Message, framework)
+
+		resp, raw = get(tracker.StatusURL(failed.ID))
+		assert.Equal(t, http.StatusOK, resp.StatusCode, framework)
+		assert.Empty(t, resp.Header.Get("Retry-After"), "finished jobs are not polled again")
+		body = statusBody{}
+		require.NoError(t, json.Unmarshal(raw, &body), framework)
+		assert.Equal(t, jobs.Failed, body.Data.State, framework)
+		require.NotNil(t, body.Data.Error, framework)
+		assert.Equal(t, core.ServerError, body.Data.Error.Category, framework)
+		assert.Equal(t, http.StatusBadGateway, body.Data.Error.Code, framework)
+
+		resp, _ = get("/jobs/unknown")
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode, framework)
+	}
+}
+
+// failingStore is a JobStore that cannot be read.
+type failingStore struct{ jobs.JobStore }
+
+func (failingStore) Get(string) (jobs.Job, error) {
+	return jobs.Job{}, errors.New("connection refused")
+}
+
+func TestJobStoreUnavailable(t *testing.T) {
+	tracker := jobs.NewTracker(failingStore{})
+	rec := httptest.NewRecorder()
+	adapters.New(core.NewResponder()).HTTPJobStatus(tracker).ServeHTTP(rec, httptest.NewRequest("GET", "/jobs/abc", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.NotContains(t, rec.Body.String(), "connection refused")
+}
@@ -0,0 +1,170 @@
+package jsonapi_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/andreascandle/FlexiResponseGo/adapters"
+	"github.com/andreascandle/FlexiResponseGo/core"
+	"github.com/andreascandle/FlexiResponseGo/jsonapi"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type person struct {
+	ID   string `jsonapi:"primary,people"`
+	Name string `jsonapi:"attr,name"`
+}
+
+type article struct {
+	ID        int      `jsonapi:"primary,articles"`
+	Title     string   `jsonapi:"attr,title"`
+	Summary   string   `jsonapi:"attr,summary,omitempty"`
+	Author    *person  `jsonapi:"relation,author"`
+	Reviewers []person `jsonapi:"relation,reviewers,omitempty"`
+}
+
+func (a article) JSONAPILinks() map[string]string {
+	return map[string]string{"self": "/articles/1"}
+}
+
+func TestRenderCompoundDocument(t *testing.T) {
+	author := &person{ID: "9", Name: "Dan"}
+	data := []article{{ID: 1, Title: "JSON:API", Author: author}, {ID: 2, Title: "Again", Author: author}}
+	resp := core.NewResponder().NewSuccessResponse("t-1", "ok", jsonapi.Payload{
+		Data:  data,
+		Links: map[string]string{"next": "/articles?page=2"},
+	})
+
+	doc, err := jsonapi.Renderer{}.Render(http.StatusOK, resp)
+	require.NoError(t, err)
+	rec := httptest.NewRecorder()
+	require.NoError(t, core.JSONEncoder.Encode(rec, doc))
+
+	assert.JSONEq(t, `{
+		"jsonapi": {"version": "1.1"},
+		"data": [
+			{"type": "articles", "id": "1", "attributes": {"title": "JSON:API"},
+			 "relationships": {"author": {"data": {"type": "people", "id": "9"}}},
+			 "links": {"self": "/articles/1"}},
+			{"type": "articles", "id": "2", "attributes": {"title": "Again"},
+			 "relationships": {"author": {"data": {"type": "people", "id": "9"}}},
+			 "links": {"self": "/articles/1"}}
+		],
+		"included": [{"type": "people", "id": "9", "attributes": {"name": "Dan"}}],
+		"links": {"next": "/articles?page=2"},
+		"meta": {"message": "ok", "trace_id": "t-1", "version": "1.0.0", "serviceName": "FlexiResponseGo",
+		         "region": "default-region", "timestamp": "`+resp.Metadata["timestamp"].(string)+`"}
+	}`, rec.Body.String())
+
+	_, _, err = jsonapi.Marshal(map[string]int{})
+	assert.ErrorIs(t, err, jsonapi.ErrNotResource)
+}
+
+func TestRenderUntypedDataAsMeta(t *testing.T) {
+	adapter := adapters.New(core.NewResponder(core.WithRenderer(jsonapi.Renderer{})))
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = adapter.HTTPSuccessResponse(w, r, "ok", map[string]interface{}{"a": 1})
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/stats", nil)
+	req.Header.Set("Accept", jsonapi.MediaType)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.Equal(t, jsonapi.MediaType, rec.Header().Get("Content-Type"))
+	var doc struct {
+		Data interface{}            `json:"data"`
+		Meta map[string]interface{} `json:"meta"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Nil(t, doc.Data)
+	assert.Equal(t, map[string]interface{}{"a": float64(1)}, doc.Meta["data"])
+}
+
+type author struct {
+	ID    string  `jsonapi:"primary,people"`
+	Posts []*post `jsonapi:"relation,posts"`
+	Boss  *author `jsonapi:"relation,boss,omitempty"`
+}
+
+type post struct {
+	ID     int     `jsonapi:"primary,posts"`
+	Author *author `jsonapi:"relation,author"`
+}
+
+func TestMarshalCyclicRelationships(t *testing.T) {
+	writer := &author{ID: "9"}
+	first, second := &post{ID: 1, Author: writer}, &post{ID: 2, Author: writer}
+	writer.Posts = []*post{first, second}
+
+	primary, included, err := jsonapi.Marshal(first)
+	require.NoError(t, err)
+	assert.Equal(t, jsonapi.Identifier{Type: "people", ID: "9"}, primary.(*jsonapi.Resource).Relationships["author"].Data)
+	require.Len(t, included, 2)
+	byType := map[string]*jsonapi.Resource{}
+	for _, resource := range included {
+		byType[resource.Type] = resource
+	}
+	assert.Equal(t, []jsonapi.Identifier{{Type: "posts", ID: "1"}, {Type: "posts", ID: "2"}},
+		byType["people"].Relationships["posts"].Data)
+	assert.Equal(t, "2", byType["posts"].ID)
+
+	// Chains of distinct resources are cut off rather than followed forever.
+	chain := &author{ID: "0"}
+	for i, link := 1, chain; i < 100; i++ {
+		link.Boss = &author{ID: strconv.Itoa(i)}
+		link = link.Boss
+	}
+	_, _, err = jsonapi.Marshal(chain)
+	assert.ErrorContains(t, err, "nested deeper")
+}
+
+func TestRenderFieldErrorsWithPointers(t *testing.T) {
+	resp := core.NewResponder().NewValidationErrorResponse("t-2", "Invalid article", map[string]interface{}{
+		"title":       "is required",
+		"author.name": []string{"too short", "must be capitalized"},
+	})
+	doc, err := jsonapi.Renderer{}.Render(http.StatusUnprocessableEntity, resp)
+	require.NoError(t, err)
+
+	errs := doc.(jsonapi.Document).Errors
+	require.Len(t, errs, 3)
+	assert.Equal(t, "/data/attributes/author/name", errs[0].Source.Pointer)
+	assert.Equal(t, "/data/attributes/title", errs[2].Source.Pointer)
+	assert.Equal(t, "422", errs[2].Status)
+	assert.Equal(t, "is required", errs[2].Detail)
+}
+
+func TestRendererSelection(t *testing.T) {
+	adapter := adapters.New(core.NewResponder(
+		core.WithRenderer(jsonapi.Renderer{}),
+		core.WithRouteRenderer("/v2/*", jsonapi.Renderer{}),
+	))
+	app := fiber.New()
+	handler := func(c *fiber.Ctx) error {
+		return adapter.FiberSuccessResponse(c, "ok", person{ID: "1", Name: "Ada"})
+	}
+	app.Get("/v1/people/1", handler)
+	app.Get("/v2/people/1", handler)
+
+	request := func(path, accept string) *http.Response {
+		req := httptest.NewRequest("GET", path, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		resp, err := app.Test(req)
+		require.NoError(t, err)
+		return resp
+	}
+
+	assert.Equal(t, "application/json", request("/v1/people/1", "").Header.Get("Content-Type"))
+	assert.Equal(t, jsonapi.MediaType, request("/v1/people/1", "application/vnd.api+json").Header.Get("Content-Type"))
+	assert.Equal(t, "application/json", request("/v1/people/1", "application/json, application/vnd.api+json;q=0.5").Header.Get("Content-Type"))
+	assert.Equal(t, jsonapi.MediaType, request("/v2/people/1", "").Header.Get("Content-Type"))
+}
@@ -1,11 +1,18 @@
 package logger_test
 
 import (
+	"net/http"
+	"sync"
 	"testing"
 
+	"github.com/andreascandle/FlexiResponseGo/adapters"
 	"github.com/andreascandle/FlexiResponseGo/logger"
+	"github.com/andreascandle/FlexiResponseGo/logger/loggertest"
+	"github.com/andreascandle/FlexiResponseGo/tests"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestLoggerInitialization(t *testing.T) {
@@ -24,3 +31,98 @@ func TestLoggerDynamicConfigUpdate(t *testing.T) {
 	log.Debug("This is a debug message")
 	assert.NotNil(t, log)
 }
+
+func TestLoggertestRecordsEntries(t *testing.T) {
+	tl := loggertest.New(t)
+	tl.Info("Test log", zap.String("key", "value"), zap.Int("count", 2))
+
+	tl.AssertLogged(t, zapcore.InfoLevel, "Test log", zap.String("key", "value"))
+	tl.AssertLogged(t, zapcore.InfoLevel, "Test log", zap.Int("count", 2))
+	tl.AssertNotLogged(t, zapcore.ErrorLevel, "Test log")
+
+	// Level changes keep writing to the observer.
+	tl.UpdateConfig(logger.Config{Level: "warn"})
+	tl.Info("Suppressed")
+	tl.AssertNotLogged(t, zapcore.InfoLevel, "Suppressed")
+}
+
+func TestObserveGlobalRestoresGlobal(t *testing.T) {
+	original := logger.GetLogger()
+
+	t.Run("swapped", func(t *testing.T) {
+		tl := loggertest.ObserveGlobal(t)
+		assert.Same(t, tl.Logger, logger.GetLogger())
+	})
+
+	assert.Same(t, original, logger.GetLogger())
+}
+
+func TestNewForTestAssertsOnTheGlobalLogger(t *testing.T) {
+	original := logger.GetLogger()
+
+	t.Run("installed", func(t *testing.T) {
+		log := logger.NewForTest(t)
+		assert.Same(t, log, logger.GetLogger())
+
+		handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			r.Header.Set("X-Trace-ID", "trace-456")
+			adapters.HTTPSuccessResponse(w, r, "ok", nil)
+		})
+		tests.PerformRequest(handler, "GET", "/items", nil)
+
+		logger.AssertLogged(t, zapcore.InfoLevel, "Outgoing response",
+			zap.String("trace_id", "trace-456"),
+			zap.Int("status_code", http.StatusOK),
+		)
+		logger.AssertNotLogged(t, zapcore.ErrorLevel, "Outgoing response")
+		log.Reset()
+		assert.Zero(t, log.Logs().Len())
+	})
+
+	assert.Same(t, original, logger.GetLogger())
+}
+
+func TestAdapterLogsTraceStatusAndRedactedHeaders(t *testing.T) {
+	tl := loggertest.ObserveGlobal(t)
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.Header.Set("X-Trace-ID", "trace-123")
+		r.Header.Set("Authorization", "Bearer secret")
+		adapters.HTTPErrorResponse(w, r, http.StatusBadRequest, "Bad request", "Detail")
+	})
+	tests.PerformRequest(handler, "POST", "/orders", nil)
+
+	tl.AssertLogged(t, zapcore.InfoLevel, "Outgoing response",
+		zap.String("trace_id", "trace-123"),
+		zap.Int("status_code", http.StatusBadRequest),
+	)
+
+	requests := tl.Logs().FilterMessage("Incoming request").All()
+	require.Len(t, requests, 1)
+	headers, ok := requests[0].ContextMap()["headers"].(http.Header)
+	require.True(t, ok)
+	assert.Equal(t, "[REDACTED]", headers.Get("Authorization"))
+	assert.Equal(t, "application/json", headers.Get("Content-Type"))
+}
+
+func TestLoggerReadsAreSafeDuringUpdateConfig(t *testing.T) {
+	tl := loggertest.New(t)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			tl.UpdateConfig(logger.Config{Level: []string{"debug", "warn"}[i%2]})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			if tl.Enabled(zapcore.InfoLevel) {
+				tl.Info("Concurrent")
+			}
+		}
+	}()
+	wg.Wait()
+}
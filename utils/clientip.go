@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"net"
+	"net/http"
+	"strings"
+)
+
+// TrustedProxies lists the networks of reverse proxies whose forwarding
+// headers are believed. The zero value trusts no proxy, so client addresses
+// are always taken from the connection.
+type TrustedProxies []*net.IPNet
+
+// ParseTrustedProxies parses CIDR ranges or single IP addresses.
+func ParseTrustedProxies(proxies ...string) (TrustedProxies, error) {
+	trusted := make(TrustedProxies, 0, len(proxies))
+	for _, proxy := range proxies {
+		if !strings.Contains(proxy, "/") {
+			ip := net.ParseIP(proxy)
+			if ip == nil {
+				return nil, &net.ParseError{Type: "IP address", Text: proxy}
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			trusted = append(trusted, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(proxy)
+		if err != nil {
+			return nil, err
+		}
+		trusted = append(trusted, network)
+	}
+	return trusted, nil
+}
+
+// Contains reports whether ip belongs to a trusted proxy.
+func (p TrustedProxies) Contains(ip string) bool {
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return false
+	}
+	for _, network := range p {
+		if network.Contains(parsed) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP returns the address of the client that sent a request over the
+// connection from remoteAddr. X-Forwarded-For is only followed through
+// trusted proxies: its entries are walked from the right and the first one
+// not belonging to a trusted proxy is the client. X-Real-IP is used when a
+// trusted proxy sent no X-Forwarded-For.
+func (p TrustedProxies) ClientIP(remoteAddr string, headers http.Header) string {
+	ip := remoteAddr
+	if host, _, err := net.SplitHostPort(remoteAddr); err == nil {
+		ip = host
+	}
+	if !p.Contains(ip) {
+		return ip
+	}
+
+	if forwarded := headers.Values("X-Forwarded-For"); len(forwarded) > 0 {
+		hops := strings.Split(strings.Join(forwarded, ","), ",")
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := strings.TrimSpace(hops[i])
+			if net.ParseIP(hop) == nil {
+				break // A malformed entry ends the chain that can be trusted
+			}
+			ip = hop
+			if !p.Contains(hop) {
+				return ip
+			}
+		}
+		return ip
+	}
+	if realIP := strings.TrimSpace(headers.Get("X-Real-IP")); net.ParseIP(realIP) != nil {
+		return realIP
+	}
+	return ip
+}
@@ -11,6 +11,9 @@ const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 var seededRand *rand.Rand
 var once sync.Once
 
+// randMu guards seededRand, which is not safe for concurrent use.
+var randMu sync.Mutex
+
 // initializeRand ensures randomness is seeded once.
 func initializeRand() {
 	once.Do(func() {
@@ -22,6 +25,8 @@ func initializeRand() {
 func GenerateTraceID(length int) string {
 	initializeRand()
 	b := make([]byte, length)
+	randMu.Lock()
+	defer randMu.Unlock()
 	for i := range b {
 		b[i] = charset[seededRand.Intn(len(charset))]
 	}